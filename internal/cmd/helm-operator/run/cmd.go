@@ -15,14 +15,24 @@
 package run
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -36,8 +46,12 @@ import (
 	"github.com/operator-framework/helm-operator-plugins/internal/metrics"
 	"github.com/operator-framework/helm-operator-plugins/internal/version"
 	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
 	helmmgr "github.com/operator-framework/helm-operator-plugins/pkg/manager"
+	"github.com/operator-framework/helm-operator-plugins/pkg/policy"
+	"github.com/operator-framework/helm-operator-plugins/pkg/postrenderer"
 	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler"
+	"github.com/operator-framework/helm-operator-plugins/pkg/storage"
 	"github.com/operator-framework/helm-operator-plugins/pkg/watches"
 )
 
@@ -75,6 +89,8 @@ func NewCmd() *cobra.Command {
 func run(cmd *cobra.Command, f *flags.Flags) {
 	printVersion()
 	metrics.RegisterBuildInfo(crmetrics.Registry)
+	metrics.RegisterReleaseStorageDriverInfo(crmetrics.Registry)
+	metrics.RegisterRolloutMetrics(crmetrics.Registry)
 
 	// Load config options from the config at f.ManagerConfigPath.
 	// These options will not override those set by flags.
@@ -135,6 +151,38 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 	log.Info("Setting manager options", "Options", optionsLog)
 
 	helmmgr.ConfigureWatchNamespaces(&options, log)
+	if err := helmmgr.ConfigureWatchNamespaceSelector(&options, log); err != nil {
+		log.Error(err, "Failed to configure watch namespace selector.")
+		os.Exit(1)
+	}
+
+	// TODO: remove legacy watches and use watches from lib
+	ws, err := watches.Load(f.WatchesFile)
+	if err != nil {
+		log.Error(err, "Failed to create new manager factories.")
+		os.Exit(1)
+	}
+
+	// Watches restricted to namespaces need to be known before the manager
+	// is created, since they configure its cache. Watches restricted by a
+	// namespace selector get a cache of their own, see newReconciler.
+	namespaces := watchNamespaces(ws)
+	for _, w := range ws {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(w.GroupVersionKind)
+		helmmgr.ConfigureObjectNamespaces(&options, obj, namespaces[w.GroupVersionKind])
+	}
+	// If every watch is restricted to namespaces, dependent resources are
+	// only ever watched in these namespaces, so there is no need to cache
+	// any other namespace.
+	if options.NewCache == nil && len(options.Cache.DefaultNamespaces) == 0 && len(ws) > 0 && len(namespaces) == len(ws) {
+		options.Cache.DefaultNamespaces = map[string]cache.Config{}
+		for _, nss := range namespaces {
+			for _, ns := range nss {
+				options.Cache.DefaultNamespaces[ns] = cache.Config{}
+			}
+		}
+	}
 
 	mgr, err := manager.New(cfg, options)
 	if err != nil {
@@ -151,26 +199,14 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 		os.Exit(1)
 	}
 
-	// TODO: remove legacy watches and use watches from lib
-	ws, err := watches.Load(f.WatchesFile)
+	chunkedConfig, err := chunkedSecretsConfig(f)
 	if err != nil {
-		log.Error(err, "Failed to create new manager factories.")
+		log.Error(err, "Failed to configure the chunked-secrets storage driver.")
 		os.Exit(1)
 	}
 
 	for _, w := range ws {
-		r, err := reconciler.New(
-			reconciler.WithChart(*w.Chart),
-			reconciler.WithGroupVersionKind(w.GroupVersionKind),
-			reconciler.WithOverrideValues(w.OverrideValues),
-			reconciler.WithSelector(*w.Selector),
-			reconciler.SkipDependentWatches(*w.WatchDependentResources),
-			reconciler.WithMaxConcurrentReconciles(f.MaxConcurrentReconciles),
-			reconciler.WithReconcilePeriod(f.ReconcilePeriod),
-			reconciler.WithInstallAnnotations(annotation.DefaultInstallAnnotations...),
-			reconciler.WithUpgradeAnnotations(annotation.DefaultUpgradeAnnotations...),
-			reconciler.WithUninstallAnnotations(annotation.DefaultUninstallAnnotations...),
-		)
+		r, err := newReconciler(mgr, w, f, namespaces[w.GroupVersionKind], chunkedConfig)
 		if err != nil {
 			log.Error(err, "unable to create helm reconciler", "controller", "Helm")
 			os.Exit(1)
@@ -180,7 +216,16 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 			log.Error(err, "unable to create controller", "Helm")
 			os.Exit(1)
 		}
-		log.Info("configured watch", "gvk", w.GroupVersionKind, "chartDir", w.ChartPath, "maxConcurrentReconciles", f.MaxConcurrentReconciles, "reconcilePeriod", f.ReconcilePeriod)
+		log.Info("configured watch", "gvk", w.GroupVersionKind, "chartDir", w.ChartPath, "maxConcurrentReconciles", maxConcurrentReconciles(w, f), "reconcilePeriod", reconcilePeriod(w, f), "storageDriver", storageDriverName(w, f), "namespaces", namespaces[w.GroupVersionKind])
+		metrics.SetReleaseStorageDriver(w.GroupVersionKind, storageDriverName(w, f))
+	}
+
+	if usesStorageDriver(ws, f, helmclient.StorageDriverChunkedSecrets) {
+		repairer := &chunkedSecretsRepairer{cfg: cfg, namespaces: slices.Sorted(maps.Keys(options.Cache.DefaultNamespaces))}
+		if err := mgr.Add(repairer); err != nil {
+			log.Error(err, "Unable to set up the repair of chunked release secrets")
+			os.Exit(1)
+		}
 	}
 
 	log.Info("starting manager")
@@ -191,6 +236,392 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 	}
 }
 
+// usesStorageDriver returns whether any of the watches stores releases with
+// the storage driver name.
+func usesStorageDriver(ws []watches.Watch, f *flags.Flags, name string) bool {
+	return slices.ContainsFunc(ws, func(w watches.Watch) bool {
+		return storageDriverName(w, f) == name
+	})
+}
+
+// chunkedSecretsRepairer is a manager runnable that repairs releases stored
+// by the chunked secrets driver that were left inconsistent, e.g. by a crash
+// during a write. It only runs on the leader, so that replicas do not repair
+// the same secrets concurrently. If namespaces is empty, all namespaces are
+// repaired. Failures are logged, but do not stop the manager.
+type chunkedSecretsRepairer struct {
+	cfg        *rest.Config
+	namespaces []string
+}
+
+var _ manager.LeaderElectionRunnable = &chunkedSecretsRepairer{}
+
+func (r *chunkedSecretsRepairer) NeedLeaderElection() bool {
+	return true
+}
+
+func (r *chunkedSecretsRepairer) Start(ctx context.Context) error {
+	repairChunkedSecrets(ctx, r.cfg, r.namespaces)
+	return nil
+}
+
+func repairChunkedSecrets(ctx context.Context, cfg *rest.Config, namespaces []string) {
+	cl, err := clientcorev1.NewForConfig(cfg)
+	if err != nil {
+		log.Error(err, "Failed to create client to repair chunked release secrets.")
+		return
+	}
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	for _, ns := range namespaces {
+		result, err := storage.RepairChunkedSecrets(ctx, cl, ns, storage.RepairOptions{
+			Owner:        helmclient.DefaultChunkedSecretsOwner,
+			MinOrphanAge: storage.DefaultRepairMinOrphanAge,
+		})
+		if err != nil {
+			log.Error(err, "Failed to repair chunked release secrets.", "namespace", ns)
+		}
+		if result != nil && (len(result.DeletedChunks) > 0 || len(result.AdoptedChunks) > 0 || len(result.DeletedIndexes) > 0) {
+			log.Info("repaired chunked release secrets", "namespace", ns, "deletedChunks", result.DeletedChunks, "adoptedChunks", result.AdoptedChunks, "deletedIndexes", result.DeletedIndexes)
+		}
+	}
+}
+
+// watchNamespaces returns the namespaces of the watches that are restricted
+// to a list of namespaces.
+func watchNamespaces(ws []watches.Watch) map[schema.GroupVersionKind][]string {
+	namespaces := make(map[schema.GroupVersionKind][]string, len(ws))
+	for _, w := range ws {
+		if len(w.Namespaces) > 0 {
+			namespaces[w.GroupVersionKind] = w.Namespaces
+		}
+	}
+	return namespaces
+}
+
+// newReconciler creates a reconciler for w. Options that are not set in the
+// watch fall back to the values of the corresponding flags, or to the
+// reconciler's defaults if there is no such flag.
+func newReconciler(mgr manager.Manager, w watches.Watch, f *flags.Flags, namespaces []string, chunkedConfig storage.ChunkedSecretsConfig) (*reconciler.Reconciler, error) {
+	installAnnotations := annotation.DefaultInstallAnnotations
+	if w.InstallAnnotations != nil {
+		as, err := annotation.InstallByName(w.InstallAnnotations...)
+		if err != nil {
+			return nil, err
+		}
+		installAnnotations = as
+	}
+	upgradeAnnotations := annotation.DefaultUpgradeAnnotations
+	if w.UpgradeAnnotations != nil {
+		as, err := annotation.UpgradeByName(w.UpgradeAnnotations...)
+		if err != nil {
+			return nil, err
+		}
+		upgradeAnnotations = as
+	}
+	uninstallAnnotations := annotation.DefaultUninstallAnnotations
+	if w.UninstallAnnotations != nil {
+		as, err := annotation.UninstallByName(w.UninstallAnnotations...)
+		if err != nil {
+			return nil, err
+		}
+		uninstallAnnotations = as
+	}
+
+	actionClientGetter, err := newActionClientGetter(mgr, w, f, chunkedConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []reconciler.Option{
+		reconciler.WithChart(*w.Chart),
+		reconciler.WithGroupVersionKind(w.GroupVersionKind),
+		reconciler.WithOverrideValues(w.OverrideValues),
+		reconciler.WithSelector(*w.Selector),
+		reconciler.SkipDependentWatches(skipDependentWatches(w)),
+		reconciler.WithMaxConcurrentReconciles(maxConcurrentReconciles(w, f)),
+		reconciler.WithReconcilePeriod(reconcilePeriod(w, f)),
+		reconciler.WithActionClientGetter(actionClientGetter),
+		reconciler.WithInstallAnnotations(installAnnotations...),
+		reconciler.WithUpgradeAnnotations(upgradeAnnotations...),
+		reconciler.WithUninstallAnnotations(uninstallAnnotations...),
+		reconciler.WithNamespaces(namespaces...),
+	}
+	if w.MaxReleaseHistory != nil {
+		opts = append(opts, reconciler.WithMaxReleaseHistory(*w.MaxReleaseHistory))
+	}
+	if w.WaitForDeletionTimeout != nil {
+		opts = append(opts, reconciler.WithWaitForDeletionTimeout(w.WaitForDeletionTimeout.Duration))
+	}
+	if timeout := pendingReleaseTimeout(w, f); timeout > 0 {
+		opts = append(opts, reconciler.WithPendingReleaseRecovery(pendingReleaseRecovery(w, f), timeout))
+	}
+	if w.ReleaseRetry != nil {
+		opts = append(opts, reconciler.WithRetryPolicy(retryPolicy(*w.ReleaseRetry)))
+	}
+	if w.RequireUpgradeApproval != nil {
+		opts = append(opts, reconciler.WithUpgradeApprovalRequired(*w.RequireUpgradeApproval))
+	}
+	if w.MaintenanceWindows != nil {
+		windows, err := maintenanceWindowPolicy(*w.MaintenanceWindows)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, reconciler.WithMaintenanceWindows(windows))
+	}
+	if w.Rollout != nil {
+		opts = append(opts, reconciler.WithRollout(rolloutPolicy(*w.Rollout)))
+	}
+	if w.ReleaseTests != nil {
+		opts = append(opts, reconciler.WithReleaseTests(testPolicy(*w.ReleaseTests)))
+	}
+	if w.Policy != nil {
+		opts = append(opts, reconciler.WithPolicy(policyRules(*w.Policy)))
+	}
+	if w.EnablePostRendererPatches != nil && *w.EnablePostRendererPatches {
+		opts = append(opts, reconciler.WithValueTranslator(postrenderer.PatcherValueTranslator))
+	}
+	if w.CommonMetadata != nil {
+		m, err := commonMetadata(*w.CommonMetadata)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, reconciler.WithCommonMetadata(m))
+	}
+	if w.NamespaceSelector != nil {
+		c, err := helmmgr.NewNamespaceSelectorCache(mgr, *w.NamespaceSelector, log.WithValues("gvk", w.GroupVersionKind))
+		if err != nil {
+			return nil, fmt.Errorf("creating cache for namespaceSelector: %w", err)
+		}
+		opts = append(opts, reconciler.WithCache(c))
+	}
+	return reconciler.New(opts...)
+}
+
+// skipDependentWatches returns whether the reconciler of w must not watch
+// the resources of its releases. Note that watchDependentResources states
+// whether to watch them, i.e. it is the inverse of the reconciler option.
+func skipDependentWatches(w watches.Watch) bool {
+	return w.WatchDependentResources != nil && !*w.WatchDependentResources
+}
+
+// chunkedSecretsConfig returns the configuration of the chunked-secrets
+// storage driver, which is shared by all watches that use it.
+func chunkedSecretsConfig(f *flags.Flags) (storage.ChunkedSecretsConfig, error) {
+	var config storage.ChunkedSecretsConfig
+	codec, err := storage.ParseCodec(f.StorageCompression)
+	if err != nil {
+		return config, fmt.Errorf("invalid --storage-compression: %w", err)
+	}
+	config.Codec = codec
+	if f.StorageEncryptionKeyFile != "" {
+		encrypter, err := storage.LoadAESGCMEncrypter(f.StorageEncryptionKeyFile)
+		if err != nil {
+			return config, fmt.Errorf("loading storage encryption keys: %w", err)
+		}
+		config.Encrypter = encrypter
+	}
+	if f.StorageChunkCacheSize > 0 {
+		config.ChunkCache = storage.NewLRUChunkCache(f.StorageChunkCacheSize)
+	}
+	return config, nil
+}
+
+func newActionClientGetter(mgr manager.Manager, w watches.Watch, f *flags.Flags, chunkedConfig storage.ChunkedSecretsConfig) (helmclient.ActionClientGetter, error) {
+	var storageDriver helmclient.ObjectToStorageDriverMapper
+	switch name := storageDriverName(w, f); name {
+	case helmclient.StorageDriverSecrets:
+		storageDriver = helmclient.DefaultSecretsStorageDriver(helmclient.SecretsStorageDriverOpts{})
+	case helmclient.StorageDriverChunkedSecrets:
+		storageDriver = helmclient.ChunkedSecretsStorageDriver(helmclient.ChunkedSecretsStorageDriverOpts{ChunkedSecretsConfig: chunkedConfig})
+	case helmclient.StorageDriverConfigMaps:
+		storageDriver = helmclient.ConfigMapsStorageDriver(helmclient.ConfigMapsStorageDriverOpts{})
+	case helmclient.StorageDriverSQL:
+		if f.SQLConnectionString == "" {
+			return nil, fmt.Errorf("the %s storage driver requires --storage-sql-connection-string or %s to be set", name, flags.SQLConnectionStringEnvVar)
+		}
+		storageDriver = helmclient.SQLStorageDriver(helmclient.SQLStorageDriverOpts{ConnectionString: f.SQLConnectionString})
+	case helmclient.StorageDriverMemory:
+		log.Info("Using the memory storage driver. Releases will be lost when the operator restarts.", "gvk", w.GroupVersionKind)
+		storageDriver = helmclient.MemoryStorageDriver(helmclient.MemoryStorageDriverOpts{})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", name)
+	}
+	actionConfigGetter, err := helmclient.NewActionConfigGetter(mgr.GetConfig(), mgr.GetRESTMapper(), helmclient.StorageDriverMapper(storageDriver))
+	if err != nil {
+		return nil, fmt.Errorf("creating action config getter: %w", err)
+	}
+
+	var opts []helmclient.ActionClientGetterOption
+	if w.EnableFailureRollbacks != nil {
+		opts = append(opts, helmclient.WithFailureRollbacks(*w.EnableFailureRollbacks))
+	}
+	if w.ImageRewrite != nil {
+		imageRewriter, err := imageRewriter(*w.ImageRewrite)
+		if err != nil {
+			return nil, fmt.Errorf("creating image rewriter: %w", err)
+		}
+		opts = append(opts, helmclient.AppendPostRenderers(postrenderer.Static(imageRewriter)))
+	}
+	if w.EnablePostRendererPatches != nil && *w.EnablePostRendererPatches {
+		// Read ConfigMaps directly to avoid caching all ConfigMaps.
+		opts = append(opts, helmclient.AppendContextPostRenderers(postrenderer.NewPatcher(mgr.GetAPIReader()).Provider()))
+	}
+	actionClientGetter, err := helmclient.NewActionClientGetter(actionConfigGetter, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating action client getter: %w", err)
+	}
+	return actionClientGetter, nil
+}
+
+func storageDriverName(w watches.Watch, f *flags.Flags) string {
+	if w.StorageDriver != "" {
+		return w.StorageDriver
+	}
+	return f.StorageDriver
+}
+
+func maxConcurrentReconciles(w watches.Watch, f *flags.Flags) int {
+	if w.MaxConcurrentReconciles != nil {
+		return *w.MaxConcurrentReconciles
+	}
+	return f.MaxConcurrentReconciles
+}
+
+func reconcilePeriod(w watches.Watch, f *flags.Flags) time.Duration {
+	if w.ReconcilePeriod != nil {
+		return w.ReconcilePeriod.Duration
+	}
+	return f.ReconcilePeriod
+}
+
+func pendingReleaseTimeout(w watches.Watch, f *flags.Flags) time.Duration {
+	if w.PendingReleaseTimeout != nil {
+		return w.PendingReleaseTimeout.Duration
+	}
+	return f.PendingReleaseTimeout
+}
+
+func pendingReleaseRecovery(w watches.Watch, f *flags.Flags) reconciler.PendingReleaseRecoveryPolicy {
+	if w.PendingReleaseRecovery != "" {
+		return reconciler.PendingReleaseRecoveryPolicy(w.PendingReleaseRecovery)
+	}
+	return reconciler.PendingReleaseRecoveryPolicy(f.PendingReleaseRecovery)
+}
+
+// VerifyWatches returns the problems of ws that are only found when their
+// options are converted for the reconciler and the post-renderers, such as
+// invalid maintenance window schedules or metadata templates.
+func VerifyWatches(ws []watches.Watch) []error {
+	var errs []error
+	for _, w := range ws {
+		invalid := func(option string, err error) {
+			errs = append(errs, fmt.Errorf("invalid options for %s: invalid %s: %w", w.GroupVersionKind, option, err))
+		}
+		if w.MaintenanceWindows != nil {
+			if _, err := maintenanceWindowPolicy(*w.MaintenanceWindows); err != nil {
+				invalid("maintenanceWindows", err)
+			}
+		}
+		if w.ImageRewrite != nil {
+			if _, err := imageRewriter(*w.ImageRewrite); err != nil {
+				invalid("imageRewrite", err)
+			}
+		}
+		if w.CommonMetadata != nil {
+			if _, err := commonMetadata(*w.CommonMetadata); err != nil {
+				invalid("commonMetadata", err)
+			}
+		}
+	}
+	return errs
+}
+
+func retryPolicy(p watches.RetryPolicy) reconciler.RetryPolicy {
+	return reconciler.RetryPolicy{
+		MaxAttempts:       p.MaxAttempts,
+		InitialBackoff:    duration(p.InitialBackoff),
+		Factor:            p.BackoffFactor,
+		MaxBackoff:        duration(p.MaxBackoff),
+		OnExhausted:       reconciler.RetryExhaustedAction(p.OnExhausted),
+		SlowRetryInterval: duration(p.SlowRetryInterval),
+	}
+}
+
+func maintenanceWindowPolicy(p watches.MaintenanceWindowPolicy) (reconciler.MaintenanceWindowPolicy, error) {
+	windows := make([]reconciler.MaintenanceWindow, 0, len(p.Windows))
+	for _, spec := range p.Windows {
+		w, err := reconciler.ParseMaintenanceWindow(spec)
+		if err != nil {
+			return reconciler.MaintenanceWindowPolicy{}, err
+		}
+		windows = append(windows, w)
+	}
+	return reconciler.MaintenanceWindowPolicy{
+		Windows:                 windows,
+		RestrictInstalls:        p.RestrictInstalls,
+		RestrictDriftCorrection: p.RestrictDriftCorrection,
+	}, nil
+}
+
+func rolloutPolicy(p watches.RolloutPolicy) reconciler.RolloutPolicy {
+	return reconciler.RolloutPolicy{
+		WaveLabel:        p.WaveLabel,
+		WavePercentages:  p.WavePercentages,
+		FailureThreshold: p.FailureThreshold,
+		ProgressInterval: duration(p.ProgressInterval),
+	}
+}
+
+func testPolicy(p watches.TestPolicy) reconciler.TestPolicy {
+	return reconciler.TestPolicy{
+		AfterRelease:      p.AfterRelease,
+		Interval:          duration(p.Interval),
+		Timeout:           duration(p.Timeout),
+		RollbackOnFailure: p.RollbackOnFailure,
+		LogLines:          p.LogLines,
+	}
+}
+
+func policyRules(p watches.PolicyRules) policy.Rules {
+	return policy.Rules{
+		DenyPrivileged:        p.DenyPrivileged,
+		RequireResourceLimits: p.RequireResourceLimits,
+		AllowedRegistries:     p.AllowedRegistries,
+		DenyLatestTag:         p.DenyLatestTag,
+		Audit:                 p.Audit,
+	}
+}
+
+// imageRewriter creates the image rewriter of r, loading the digests from
+// its digests file.
+func imageRewriter(r watches.ImageRewrite) (*postrenderer.ImageRewriter, error) {
+	mirrors := make([]postrenderer.ImageMirror, 0, len(r.Mirrors))
+	for _, m := range r.Mirrors {
+		mirrors = append(mirrors, postrenderer.ImageMirror{Source: m.Source, Mirror: m.Mirror})
+	}
+	var digests map[string]string
+	if r.DigestsFile != "" {
+		var err error
+		if digests, err = postrenderer.LoadImageDigests(r.DigestsFile); err != nil {
+			return nil, err
+		}
+	}
+	return postrenderer.NewImageRewriter(mirrors, digests)
+}
+
+func commonMetadata(m watches.CommonMetadata) (*postrenderer.CommonMetadata, error) {
+	return postrenderer.NewCommonMetadata(m.Labels, m.Annotations, m.PodTemplates)
+}
+
+func duration(d *metav1.Duration) time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.Duration
+}
+
 // exitIfUnsupported prints an error containing unsupported field names and exits
 // if any of those fields are not their default values.
 func exitIfUnsupported(options manager.Options) {
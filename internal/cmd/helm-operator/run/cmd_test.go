@@ -0,0 +1,116 @@
+// Copyright 2025 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/policy"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler"
+	"github.com/operator-framework/helm-operator-plugins/pkg/watches"
+)
+
+var _ = Describe("skipDependentWatches", func() {
+	var (
+		trueVal  = true
+		falseVal = false
+	)
+
+	It("should watch dependent resources by default", func() {
+		Expect(skipDependentWatches(watches.Watch{})).To(BeFalse())
+	})
+	It("should watch dependent resources if watchDependentResources is true", func() {
+		Expect(skipDependentWatches(watches.Watch{WatchDependentResources: &trueVal})).To(BeFalse())
+	})
+	It("should skip dependent resources if watchDependentResources is false", func() {
+		Expect(skipDependentWatches(watches.Watch{WatchDependentResources: &falseVal})).To(BeTrue())
+	})
+})
+
+var _ = Describe("VerifyWatches", func() {
+	DescribeTable("should report options that cannot be converted",
+		func(w watches.Watch, errMsg string) {
+			w.GroupVersionKind = schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"}
+			errs := VerifyWatches([]watches.Watch{w})
+			Expect(errs).To(ConsistOf(MatchError(ContainSubstring("invalid options for mygroup/v1alpha1, Kind=MyKind: " + errMsg))))
+		},
+		Entry("maintenanceWindows duration",
+			watches.Watch{MaintenanceWindows: &watches.MaintenanceWindowPolicy{Windows: []string{"0 2 * * 6"}}},
+			`invalid maintenanceWindows: invalid maintenance window "0 2 * * 6": duration must be positive`),
+		Entry("maintenanceWindows schedule",
+			watches.Watch{MaintenanceWindows: &watches.MaintenanceWindowPolicy{Windows: []string{"0 25 * * * 1h"}}},
+			`invalid maintenanceWindows: invalid maintenance window "0 25 * * * 1h": invalid hour`),
+		Entry("imageRewrite digestsFile",
+			watches.Watch{ImageRewrite: &watches.ImageRewrite{DigestsFile: "/does/not/exist.yaml"}},
+			"invalid imageRewrite: failed to read image digests"),
+		Entry("commonMetadata template",
+			watches.Watch{CommonMetadata: &watches.CommonMetadata{Labels: map[string]string{"team": "{{ .CR"}}},
+			`invalid commonMetadata: invalid label "team"`),
+	)
+
+	It("should accept valid options", func() {
+		Expect(VerifyWatches([]watches.Watch{{
+			MaintenanceWindows: &watches.MaintenanceWindowPolicy{Windows: []string{"0 2 * * 6 4h"}},
+			ImageRewrite:       &watches.ImageRewrite{Mirrors: []watches.ImageMirror{{Source: "docker.io", Mirror: "mirror.example.com/docker.io"}}},
+			CommonMetadata:     &watches.CommonMetadata{Labels: map[string]string{"team": "{{ .CR.metadata.labels.team }}"}},
+		}})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("option conversions", func() {
+	It("should convert the retry policy", func() {
+		Expect(retryPolicy(watches.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: &metav1.Duration{Duration: 10 * time.Second},
+			BackoffFactor:  2,
+			OnExhausted:    "SlowRetry",
+		})).To(Equal(reconciler.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: 10 * time.Second,
+			Factor:         2,
+			OnExhausted:    reconciler.RetryExhaustedSlowRetry,
+		}))
+	})
+
+	It("should convert the test policy", func() {
+		Expect(testPolicy(watches.TestPolicy{
+			AfterRelease: true,
+			Interval:     &metav1.Duration{Duration: time.Hour},
+			LogLines:     10,
+		})).To(Equal(reconciler.TestPolicy{
+			AfterRelease: true,
+			Interval:     time.Hour,
+			LogLines:     10,
+		}))
+	})
+
+	It("should convert the policy rules", func() {
+		Expect(policyRules(watches.PolicyRules{DenyPrivileged: true, AllowedRegistries: []string{"quay.io"}, Audit: true})).
+			To(Equal(policy.Rules{DenyPrivileged: true, AllowedRegistries: []string{"quay.io"}, Audit: true}))
+	})
+})
+
+var _ = Describe("chunkedSecretsRepairer", func() {
+	It("should only run on the leader", func() {
+		var r manager.LeaderElectionRunnable = &chunkedSecretsRepairer{}
+		Expect(r.NeedLeaderElection()).To(BeTrue())
+	})
+})
@@ -0,0 +1,327 @@
+// Copyright 2025 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/operator-framework/helm-operator-plugins/internal/flags"
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/storage"
+	"github.com/operator-framework/helm-operator-plugins/pkg/watches"
+)
+
+// NewCmd returns the storage command, which groups commands that operate on
+// stored Helm releases.
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Manage stored Helm releases",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newMigrateCmd())
+	cmd.AddCommand(newCheckCmd())
+	return cmd
+}
+
+// driverFlags configures the release storage drivers of storage commands.
+type driverFlags struct {
+	namespace           string
+	chunkedSecretsOwner string
+	encryptionKeyFile   string
+	compression         string
+	sqlConnectionString string
+}
+
+func (f *driverFlags) addTo(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.namespace, "namespace", "n", "", "Namespace of the stored releases")
+	cmd.Flags().StringVar(&f.chunkedSecretsOwner, "chunked-secrets-owner", helmclient.DefaultChunkedSecretsOwner, "Owner label value of releases stored by the chunked-secrets driver")
+	cmd.Flags().StringVar(&f.encryptionKeyFile, "storage-encryption-key-file", "", "Path to the file with the AES keys of releases stored by the chunked-secrets driver, if they are encrypted")
+	cmd.Flags().StringVar(&f.compression, "storage-compression", "gzip:9", "Compression codec of releases written by the chunked-secrets driver, either gzip[:<1-9>] or zstd[:fastest|default|better|best]")
+	cmd.Flags().StringVar(&f.sqlConnectionString, "storage-sql-connection-string", os.Getenv(flags.SQLConnectionStringEnvVar), "Connection string of the PostgreSQL database used by the sql driver. Defaults to the value of the "+flags.SQLConnectionStringEnvVar+" environment variable")
+	_ = cmd.MarkFlagRequired("namespace")
+}
+
+// newDriver returns the storage driver name for the namespace in f.
+func (f *driverFlags) newDriver(clientset kubernetes.Interface, name string) (driver.Driver, error) {
+	switch name {
+	case helmclient.StorageDriverSecrets:
+		return driver.NewSecrets(clientset.CoreV1().Secrets(f.namespace)), nil
+	case helmclient.StorageDriverChunkedSecrets:
+		config, err := f.chunkedSecretsConfig()
+		if err != nil {
+			return nil, err
+		}
+		return storage.NewChunkedSecrets(clientset.CoreV1().Secrets(f.namespace), f.chunkedSecretsOwner, config), nil
+	case helmclient.StorageDriverConfigMaps:
+		return driver.NewConfigMaps(clientset.CoreV1().ConfigMaps(f.namespace)), nil
+	case helmclient.StorageDriverSQL:
+		if f.sqlConnectionString == "" {
+			return nil, fmt.Errorf("the %s storage driver requires --storage-sql-connection-string or %s to be set", name, flags.SQLConnectionStringEnvVar)
+		}
+		return driver.NewSQL(f.sqlConnectionString, func(string, ...interface{}) {}, f.namespace)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q, must be one of %s", name, strings.Join(persistentDrivers(), ", "))
+	}
+}
+
+// chunkedSecretsConfig returns the configuration of the chunked-secrets driver.
+func (f *driverFlags) chunkedSecretsConfig() (storage.ChunkedSecretsConfig, error) {
+	var config storage.ChunkedSecretsConfig
+	codec, err := storage.ParseCodec(f.compression)
+	if err != nil {
+		return config, fmt.Errorf("invalid --storage-compression: %w", err)
+	}
+	config.Codec = codec
+	if f.encryptionKeyFile != "" {
+		encrypter, err := storage.LoadAESGCMEncrypter(f.encryptionKeyFile)
+		if err != nil {
+			return config, fmt.Errorf("loading storage encryption keys: %w", err)
+		}
+		config.Encrypter = encrypter
+	}
+	return config, nil
+}
+
+// persistentDrivers returns the names of the storage drivers that outlive the
+// operator process, which excludes the memory driver.
+func persistentDrivers() []string {
+	var names []string
+	for _, name := range helmclient.StorageDrivers {
+		if name != helmclient.StorageDriverMemory {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func newMigrateCmd() *cobra.Command {
+	var (
+		df           driverFlags
+		from, to     string
+		releaseNames []string
+		deleteSource bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate stored Helm releases between storage drivers",
+		Long: `Migrate the releases in a namespace, including their full history, from one
+release storage driver to another. Revision numbers and custom labels are
+preserved, and every migrated release is read back and compared to the
+original.
+
+Releases that already exist in the target with the same content are skipped,
+so an interrupted migration can be run again. Owner references of the stored
+releases are not migrated; they are set again the next time the operator
+updates a release.
+
+Helm's secrets driver and the chunked-secrets driver store releases in secrets
+with the same names, so migrating between them requires --delete-source, which
+moves releases one at a time.`,
+		Example:       `  helm-operator storage migrate --from secrets --to chunked-secrets --namespace my-namespace --delete-source`,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if from == to {
+				return fmt.Errorf("--from and --to must be different storage drivers")
+			}
+			cfg, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			clientset, err := kubernetes.NewForConfig(cfg)
+			if err != nil {
+				return err
+			}
+			fromDriver, err := df.newDriver(clientset, from)
+			if err != nil {
+				return fmt.Errorf("--from: %w", err)
+			}
+			toDriver, err := df.newDriver(clientset, to)
+			if err != nil {
+				return fmt.Errorf("--to: %w", err)
+			}
+
+			opts := storage.MigrateOptions{
+				DeleteSource: deleteSource,
+				Log: func(format string, args ...interface{}) {
+					fmt.Fprintf(cmd.ErrOrStderr(), format+"\n", args...)
+				},
+			}
+			if len(releaseNames) > 0 {
+				opts.Filter = func(rls *release.Release) bool {
+					for _, name := range releaseNames {
+						if rls.Name == name {
+							return true
+						}
+					}
+					return false
+				}
+			}
+
+			result, err := storage.Migrate(fromDriver, toDriver, opts)
+			if result != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "migrated %d, skipped %d and deleted %d release revision(s) in namespace %s\n",
+					len(result.Migrated), len(result.Skipped), len(result.Deleted), df.namespace)
+			}
+			return err
+		},
+	}
+
+	df.addTo(cmd)
+	cmd.Flags().StringVar(&from, "from", helmclient.StorageDriverSecrets, fmt.Sprintf("Storage driver to migrate releases from, one of %s", strings.Join(persistentDrivers(), ", ")))
+	cmd.Flags().StringVar(&to, "to", helmclient.StorageDriverChunkedSecrets, fmt.Sprintf("Storage driver to migrate releases to, one of %s", strings.Join(persistentDrivers(), ", ")))
+	cmd.Flags().StringSliceVar(&releaseNames, "release", nil, "Names of the releases to migrate. Defaults to all releases in the namespace")
+	cmd.Flags().BoolVar(&deleteSource, "delete-source", false, "Delete the releases from the source storage driver after they have been migrated")
+	return cmd
+}
+
+func newCheckCmd() *cobra.Command {
+	var (
+		df             driverFlags
+		watchesFile    string
+		fix            bool
+		pendingTimeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check stored Helm releases for problems",
+		Long: `Check the releases in a namespace that are stored by Helm's secrets driver and
+by the chunked-secrets driver for problems:
+
+  MultipleDeployed              more than one revision of a release is deployed
+  StuckPending                  a revision has been pending for longer than --pending-timeout
+  OrphanChunk                   a chunk secret is not referenced by an index secret
+  MissingChunk                  an index secret references a chunk secret that does not exist
+  ReleaseWithoutCustomResource  a release has no custom resource of the same name, or
+                                the custom resource of a canary release no longer exists
+  CustomResourceWithoutRelease  a custom resource has no release of the same name
+
+Releases and custom resources are only compared if --watches-file is set.
+
+With --fix, older deployed revisions are marked as superseded, stuck
+revisions are marked as failed, orphan chunks are deleted and revisions with
+missing chunks, which cannot be read, are deleted. Releases and custom
+resources without each other are only reported. Do not use --fix with a
+short --pending-timeout while the operator is running, since it may mark
+revisions as failed that are still being installed or upgraded.
+
+The command fails if any problems remain.`,
+		Example:       `  helm-operator storage check --namespace my-namespace --watches-file watches.yaml --fix`,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			cfg, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			clientset, err := kubernetes.NewForConfig(cfg)
+			if err != nil {
+				return err
+			}
+			chunkedConfig, err := df.chunkedSecretsConfig()
+			if err != nil {
+				return err
+			}
+
+			opts := storage.CheckOptions{
+				Owner:                df.chunkedSecretsOwner,
+				ChunkedSecretsConfig: chunkedConfig,
+				PendingTimeout:       pendingTimeout,
+				MinOrphanAge:         storage.DefaultRepairMinOrphanAge,
+				Fix:                  fix,
+				Log: func(format string, args ...interface{}) {
+					fmt.Fprintf(cmd.ErrOrStderr(), format+"\n", args...)
+				},
+			}
+			if watchesFile != "" {
+				opts.CustomResources, err = customResources(ctx, cfg, watchesFile, df.namespace)
+				if err != nil {
+					return err
+				}
+			}
+
+			result, checkErr := storage.CheckReleases(ctx, clientset.CoreV1(), df.namespace, opts)
+			if result == nil {
+				return checkErr
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "PROBLEM\tRELEASE\tOBJECT\tFIXED\tMESSAGE")
+			for _, p := range result.Problems {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", p.Type, p.Release, p.Object, p.Fixed, p.Message)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if checkErr != nil {
+				return checkErr
+			}
+			if unfixed := result.Unfixed(); len(unfixed) > 0 {
+				return fmt.Errorf("found %d unfixed problem(s) in namespace %s", len(unfixed), df.namespace)
+			}
+			return nil
+		},
+	}
+
+	df.addTo(cmd)
+	cmd.Flags().StringVar(&watchesFile, "watches-file", "", "Path to the watches file of the operator. If set, releases are compared to the custom resources of the watched kinds")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Fix the problems that can be fixed safely")
+	cmd.Flags().DurationVar(&pendingTimeout, "pending-timeout", storage.DefaultCheckPendingTimeout, "Time after which a pending revision is considered stuck")
+	return cmd
+}
+
+// customResourceNames returns the names of the custom resources of all
+// kinds in the watches file in namespace.
+func customResources(ctx context.Context, cfg *rest.Config, watchesFile, namespace string) ([]storage.CustomResource, error) {
+	ws, err := watches.Load(watchesFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading watches file: %w", err)
+	}
+	cl, err := client.New(cfg, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+	crs := []storage.CustomResource{}
+	for _, w := range ws {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(w.GroupVersionKind.GroupVersion().WithKind(w.Kind + "List"))
+		if err := cl.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("listing %s: %w", w.GroupVersionKind, err)
+		}
+		for _, obj := range list.Items {
+			crs = append(crs, storage.CustomResource{Name: obj.GetName(), UID: obj.GetUID()})
+		}
+	}
+	return crs, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operator-framework/helm-operator-plugins/internal/cmd/helm-operator/run"
+	"github.com/operator-framework/helm-operator-plugins/pkg/watches"
+)
+
+func NewCmd() *cobra.Command {
+	var (
+		watchesFile string
+		printSchema bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate-watches",
+		Short: "Validate a watches file",
+		Long: `Validate a watches file and report all problems found in it, including
+unknown fields, invalid options and charts that cannot be loaded.`,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if printSchema {
+				_, err := cmd.OutOrStdout().Write(watches.JSONSchema)
+				return err
+			}
+
+			ws, errs := watches.Validate(watchesFile)
+			errs = append(errs, run.VerifyWatches(ws)...)
+			for _, err := range errs {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", watchesFile, err)
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("found %d problem(s) in %s", len(errs), watchesFile)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is valid\n", watchesFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&watchesFile, "watches-file", "./watches.yaml", "Path to the watches file to validate")
+	cmd.Flags().BoolVar(&printSchema, "print-schema", false, "Print the JSON schema of the versioned watches file format and exit")
+	return cmd
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/operator-framework/helm-operator-plugins/internal/cmd/helm-operator/run"
+	"github.com/operator-framework/helm-operator-plugins/internal/cmd/helm-operator/storage"
+	"github.com/operator-framework/helm-operator-plugins/internal/cmd/helm-operator/validate"
 	"github.com/operator-framework/helm-operator-plugins/internal/version"
 
 	"github.com/spf13/cobra"
@@ -19,6 +21,8 @@ func rootCmd() *cobra.Command {
 	}
 
 	rootCmd.AddCommand(run.NewCmd())
+	rootCmd.AddCommand(validate.NewCmd())
+	rootCmd.AddCommand(storage.NewCmd())
 
 	return rootCmd
 }
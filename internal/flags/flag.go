@@ -16,27 +16,44 @@ package flags
 
 import (
 	"crypto/tls"
+	"fmt"
+	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler"
 )
 
+// SQLConnectionStringEnvVar is the environment variable that Helm reads the
+// connection string of the sql storage driver from.
+const SQLConnectionStringEnvVar = "HELM_DRIVER_SQL_CONNECTION_STRING"
+
 // Flags - Options to be used by a helm operator
 type Flags struct {
-	ReconcilePeriod         time.Duration
-	WatchesFile             string
-	MetricsBindAddress      string
-	LeaderElection          bool
-	LeaderElectionID        string
-	LeaderElectionNamespace string
-	MaxConcurrentReconciles int
-	ProbeAddr               string
-	EnableHTTP2             bool
-	SecureMetrics           bool
+	ReconcilePeriod          time.Duration
+	WatchesFile              string
+	MetricsBindAddress       string
+	LeaderElection           bool
+	LeaderElectionID         string
+	LeaderElectionNamespace  string
+	MaxConcurrentReconciles  int
+	ProbeAddr                string
+	EnableHTTP2              bool
+	SecureMetrics            bool
+	StorageDriver            string
+	SQLConnectionString      string
+	StorageEncryptionKeyFile string
+	StorageChunkCacheSize    int
+	StorageCompression       string
+	PendingReleaseTimeout    time.Duration
+	PendingReleaseRecovery   string
 
 	// If not nil, used to deduce which flags were set in the CLI.
 	flagSet *pflag.FlagSet
@@ -53,6 +70,49 @@ func (f *Flags) AddTo(flagSet *pflag.FlagSet) {
 		"./watches.yaml",
 		"Path to the watches file to use",
 	)
+	flagSet.StringVar(&f.StorageDriver,
+		"storage-driver",
+		helmclient.StorageDriverSecrets,
+		fmt.Sprintf("Default release storage driver, one of %s. It can be overridden per watch in the watches file", strings.Join(helmclient.StorageDrivers, ", ")),
+	)
+	flagSet.StringVar(&f.SQLConnectionString,
+		"storage-sql-connection-string",
+		os.Getenv(SQLConnectionStringEnvVar),
+		"Connection string of the PostgreSQL database used by the sql storage driver. Defaults to the value of the "+SQLConnectionStringEnvVar+" environment variable",
+	)
+	flagSet.StringVar(&f.StorageEncryptionKeyFile,
+		"storage-encryption-key-file",
+		"",
+		"Path to a file with AES keys used to encrypt releases stored by the chunked-secrets storage driver. "+
+			"Each line contains a key ID and a base64 encoded key, separated by '='. The first key is used for encryption",
+	)
+	flagSet.IntVar(&f.StorageChunkCacheSize,
+		"storage-chunk-cache-size",
+		0,
+		"Number of chunk secrets of the chunked-secrets storage driver to cache in memory. Chunks never change, "+
+			"so caching them avoids fetching them again when the release history is read. Each chunk takes up to 1MB. "+
+			"Caching is disabled if 0",
+	)
+	flagSet.StringVar(&f.StorageCompression,
+		"storage-compression",
+		"gzip:9",
+		"Compression codec of releases written by the chunked-secrets storage driver, either gzip[:<1-9>] or "+
+			"zstd[:fastest|default|better|best]. Releases written with any codec can be read",
+	)
+	flagSet.DurationVar(&f.PendingReleaseTimeout,
+		"pending-release-timeout",
+		0,
+		"Time after which a release that is stuck in a pending state, e.g. because the operator was stopped during "+
+			"an install or upgrade, is recovered according to --pending-release-recovery. Recovery is disabled if 0. "+
+			"It can be overridden per watch in the watches file",
+	)
+	flagSet.StringVar(&f.PendingReleaseRecovery,
+		"pending-release-recovery",
+		string(reconciler.PendingReleaseRollback),
+		fmt.Sprintf("How releases that are stuck in a pending state are recovered, either %s to roll back to the last deployed revision "+
+			"or %s to mark the pending revision as failed and upgrade it again. Releases that were never deployed are uninstalled. "+
+			"It can be overridden per watch in the watches file", reconciler.PendingReleaseRollback, reconciler.PendingReleaseMarkFailed),
+	)
 	// Controller flags.
 	flagSet.DurationVar(&f.ReconcilePeriod,
 		"reconcile-period",
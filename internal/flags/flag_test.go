@@ -24,6 +24,38 @@ import (
 )
 
 var _ = Describe("Flags", func() {
+	Describe("AddTo", func() {
+		It("defaults the storage driver to secrets", func() {
+			f := &flags.Flags{}
+			flagSet := pflag.NewFlagSet("test", pflag.ExitOnError)
+			f.AddTo(flagSet)
+			parseArgs(flagSet)
+			Expect(f.StorageDriver).To(Equal("secrets"))
+		})
+		It("defaults the sql connection string to the environment variable", func() {
+			GinkgoT().Setenv(flags.SQLConnectionStringEnvVar, "postgres://localhost/helm")
+			f := &flags.Flags{}
+			flagSet := pflag.NewFlagSet("test", pflag.ExitOnError)
+			f.AddTo(flagSet)
+			parseArgs(flagSet)
+			Expect(f.SQLConnectionString).To(Equal("postgres://localhost/helm"))
+		})
+		It("sets the storage encryption key file", func() {
+			f := &flags.Flags{}
+			flagSet := pflag.NewFlagSet("test", pflag.ExitOnError)
+			f.AddTo(flagSet)
+			parseArgs(flagSet, "--storage-encryption-key-file", "/etc/helm-operator/keys")
+			Expect(f.StorageEncryptionKeyFile).To(Equal("/etc/helm-operator/keys"))
+		})
+		It("defaults the storage compression to gzip", func() {
+			f := &flags.Flags{}
+			flagSet := pflag.NewFlagSet("test", pflag.ExitOnError)
+			f.AddTo(flagSet)
+			parseArgs(flagSet)
+			Expect(f.StorageCompression).To(Equal("gzip:9"))
+		})
+	})
+
 	Describe("ToManagerOptions", func() {
 		var (
 			f       *flags.Flags
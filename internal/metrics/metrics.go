@@ -16,6 +16,7 @@ package metrics
 
 import (
 	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	helmVersion "github.com/operator-framework/helm-operator-plugins/internal/version"
 )
@@ -36,6 +37,51 @@ var (
 			},
 		},
 	)
+
+	releaseStorageDriverInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "release_storage_driver_info",
+			Help:      "Release storage driver used for the releases of a watched custom resource kind",
+		},
+		[]string{"group", "version", "kind", "driver"},
+	)
+
+	rolloutWave = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "rollout_wave",
+			Help:      "Current wave of the chart rollout of a watched custom resource kind, starting at 1. Exceeds helm_operator_rollout_waves once the rollout is complete.",
+		},
+		[]string{"group", "version", "kind"},
+	)
+
+	rolloutWaves = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "rollout_waves",
+			Help:      "Number of waves of the chart rollout of a watched custom resource kind",
+		},
+		[]string{"group", "version", "kind"},
+	)
+
+	rolloutWaveResources = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "rollout_wave_resources",
+			Help:      "Number of custom resources of the current wave of the chart rollout of a watched custom resource kind by status",
+		},
+		[]string{"group", "version", "kind", "status"},
+	)
+
+	rolloutHalted = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "rollout_halted",
+			Help:      "Whether the chart rollout of a watched custom resource kind is halted because too many upgrades failed",
+		},
+		[]string{"group", "version", "kind"},
+	)
 )
 
 // RegisterBuildInfo registers buildInfo Collector to be included in metrics collection
@@ -43,3 +89,35 @@ func RegisterBuildInfo(r prometheus.Registerer) {
 	buildInfo.Set(1)
 	r.MustRegister(buildInfo)
 }
+
+// RegisterReleaseStorageDriverInfo registers releaseStorageDriverInfo Collector to be included in metrics collection
+func RegisterReleaseStorageDriverInfo(r prometheus.Registerer) {
+	r.MustRegister(releaseStorageDriverInfo)
+}
+
+// RegisterRolloutMetrics registers the chart rollout Collectors to be included in metrics collection
+func RegisterRolloutMetrics(r prometheus.Registerer) {
+	r.MustRegister(rolloutWave, rolloutWaves, rolloutWaveResources, rolloutHalted)
+}
+
+// SetRolloutProgress records the progress of the chart rollout of custom
+// resources of kind gvk. wave is the index of the current wave, starting at 0,
+// and done, failed and pending count the custom resources of that wave.
+func SetRolloutProgress(gvk schema.GroupVersionKind, wave, waves, done, failed, pending int, halted bool) {
+	rolloutWave.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Set(float64(wave + 1))
+	rolloutWaves.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Set(float64(waves))
+	rolloutWaveResources.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, "done").Set(float64(done))
+	rolloutWaveResources.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, "failed").Set(float64(failed))
+	rolloutWaveResources.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, "pending").Set(float64(pending))
+	v := 0.0
+	if halted {
+		v = 1
+	}
+	rolloutHalted.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Set(v)
+}
+
+// SetReleaseStorageDriver records that the releases of custom resources of
+// kind gvk are stored with the named storage driver.
+func SetReleaseStorageDriver(gvk schema.GroupVersionKind, driver string) {
+	releaseStorageDriverInfo.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, driver).Set(1)
+}
@@ -40,7 +40,11 @@ limitations under the License.
 package annotation
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"strconv"
+	"time"
 
 	"helm.sh/helm/v3/pkg/action"
 
@@ -71,6 +75,50 @@ type Uninstall interface {
 	UninstallOption(string) helmclient.UninstallOption
 }
 
+// Validator is optionally implemented by Install, Upgrade and Uninstall
+// annotations to validate their value. The reconciler validates the enabled
+// annotations of a custom resource before using them and reports invalid
+// values instead of reconciling the custom resource.
+type Validator interface {
+	Validate(string) error
+}
+
+// InstallByName returns the default and optional Install annotations with the
+// given names. An error is returned if a name does not match any of them.
+func InstallByName(names ...string) ([]Install, error) {
+	return byName(names, DefaultInstallAnnotations, OptionalInstallAnnotations)
+}
+
+// UpgradeByName returns the default and optional Upgrade annotations with the
+// given names. An error is returned if a name does not match any of them.
+func UpgradeByName(names ...string) ([]Upgrade, error) {
+	return byName(names, DefaultUpgradeAnnotations, OptionalUpgradeAnnotations)
+}
+
+// UninstallByName returns the default and optional Uninstall annotations with
+// the given names. An error is returned if a name does not match any of them.
+func UninstallByName(names ...string) ([]Uninstall, error) {
+	return byName(names, DefaultUninstallAnnotations, OptionalUninstallAnnotations)
+}
+
+func byName[T interface{ Name() string }](names []string, defaults, optional []T) ([]T, error) {
+	out := make([]T, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, a := range slices.Concat(defaults, optional) {
+			if a.Name() == name {
+				out = append(out, a)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown annotation %q", name)
+		}
+	}
+	return out, nil
+}
+
 const (
 	defaultDomain                    = "helm.sdk.operatorframework.io"
 	defaultInstallDisableHooksName   = defaultDomain + "/install-disable-hooks"
@@ -82,6 +130,55 @@ const (
 	defaultInstallDescriptionName   = defaultDomain + "/install-description"
 	defaultUpgradeDescriptionName   = defaultDomain + "/upgrade-description"
 	defaultUninstallDescriptionName = defaultDomain + "/uninstall-description"
+
+	defaultInstallWaitName                     = defaultDomain + "/install-wait"
+	defaultInstallWaitForJobsName              = defaultDomain + "/install-wait-for-jobs"
+	defaultInstallTimeoutName                  = defaultDomain + "/install-timeout"
+	defaultInstallAtomicName                   = defaultDomain + "/install-atomic"
+	defaultInstallSkipCRDsName                 = defaultDomain + "/install-skip-crds"
+	defaultInstallDisableOpenAPIValidationName = defaultDomain + "/install-disable-openapi-validation"
+
+	defaultUpgradeWaitName                     = defaultDomain + "/upgrade-wait"
+	defaultUpgradeWaitForJobsName              = defaultDomain + "/upgrade-wait-for-jobs"
+	defaultUpgradeTimeoutName                  = defaultDomain + "/upgrade-timeout"
+	defaultUpgradeAtomicName                   = defaultDomain + "/upgrade-atomic"
+	defaultUpgradeCleanupOnFailName            = defaultDomain + "/upgrade-cleanup-on-fail"
+	defaultUpgradeSkipCRDsName                 = defaultDomain + "/upgrade-skip-crds"
+	defaultUpgradeDisableOpenAPIValidationName = defaultDomain + "/upgrade-disable-openapi-validation"
+	defaultUpgradeResetValuesName              = defaultDomain + "/upgrade-reset-values"
+	defaultUpgradeReuseValuesName              = defaultDomain + "/upgrade-reuse-values"
+
+	defaultUninstallWaitName        = defaultDomain + "/uninstall-wait"
+	defaultUninstallTimeoutName     = defaultDomain + "/uninstall-timeout"
+	defaultUninstallKeepHistoryName = defaultDomain + "/uninstall-keep-history"
+	defaultUninstallCascadeName     = defaultDomain + "/uninstall-cascade"
+)
+
+const (
+	// Paused is the name of the annotation that pauses the reconciliation of
+	// a custom resource when set to "true". While paused, the release is
+	// neither installed, upgraded nor reconciled, but the status of the
+	// custom resource is still updated. Deleting a paused custom resource
+	// still uninstalls its release.
+	Paused = defaultDomain + "/paused"
+
+	// ApprovedRevisionHash is the name of the annotation that approves a
+	// pending upgrade, if the reconciler requires approval for upgrades. Its
+	// value must match the revision hash of the pending upgrade in the status
+	// of the custom resource.
+	ApprovedRevisionHash = defaultDomain + "/approved-revision-hash"
+
+	// MaintenanceWindows is the name of the annotation that overrides the
+	// maintenance windows of a custom resource. Its value is a
+	// semicolon-separated list of windows, see
+	// reconciler.ParseMaintenanceWindow.
+	MaintenanceWindows = defaultDomain + "/maintenance-windows"
+
+	// UpgradeStrategy is the name of the annotation that selects the upgrade
+	// strategy of a custom resource, either "InPlace" or "Canary". "Canary"
+	// is only valid if canary upgrades are enabled, see
+	// reconciler.WithCanaryUpgrades.
+	UpgradeStrategy = defaultDomain + "/upgrade-strategy"
 )
 
 type InstallDisableHooks struct {
@@ -89,6 +186,7 @@ type InstallDisableHooks struct {
 }
 
 var _ Install = &InstallDisableHooks{}
+var _ Validator = &InstallDisableHooks{}
 
 func (i InstallDisableHooks) Name() string {
 	if i.CustomName != "" {
@@ -97,6 +195,10 @@ func (i InstallDisableHooks) Name() string {
 	return defaultInstallDisableHooksName
 }
 
+func (i InstallDisableHooks) Validate(val string) error {
+	return validateBool(val)
+}
+
 func (i InstallDisableHooks) InstallOption(val string) helmclient.InstallOption {
 	disableHooks := false
 	if v, err := strconv.ParseBool(val); err == nil {
@@ -113,6 +215,7 @@ type UpgradeDisableHooks struct {
 }
 
 var _ Upgrade = &UpgradeDisableHooks{}
+var _ Validator = &UpgradeDisableHooks{}
 
 func (u UpgradeDisableHooks) Name() string {
 	if u.CustomName != "" {
@@ -121,6 +224,10 @@ func (u UpgradeDisableHooks) Name() string {
 	return defaultUpgradeDisableHooksName
 }
 
+func (u UpgradeDisableHooks) Validate(val string) error {
+	return validateBool(val)
+}
+
 func (u UpgradeDisableHooks) UpgradeOption(val string) helmclient.UpgradeOption {
 	disableHooks := false
 	if v, err := strconv.ParseBool(val); err == nil {
@@ -137,6 +244,7 @@ type UpgradeForce struct {
 }
 
 var _ Upgrade = &UpgradeForce{}
+var _ Validator = &UpgradeForce{}
 
 func (u UpgradeForce) Name() string {
 	if u.CustomName != "" {
@@ -145,6 +253,10 @@ func (u UpgradeForce) Name() string {
 	return defaultUpgradeForceName
 }
 
+func (u UpgradeForce) Validate(val string) error {
+	return validateBool(val)
+}
+
 func (u UpgradeForce) UpgradeOption(val string) helmclient.UpgradeOption {
 	force := false
 	if v, err := strconv.ParseBool(val); err == nil {
@@ -161,6 +273,7 @@ type UninstallDisableHooks struct {
 }
 
 var _ Uninstall = &UninstallDisableHooks{}
+var _ Validator = &UninstallDisableHooks{}
 
 func (u UninstallDisableHooks) Name() string {
 	if u.CustomName != "" {
@@ -169,6 +282,10 @@ func (u UninstallDisableHooks) Name() string {
 	return defaultUninstallDisableHooksName
 }
 
+func (u UninstallDisableHooks) Validate(val string) error {
+	return validateBool(val)
+}
+
 func (u UninstallDisableHooks) UninstallOption(val string) helmclient.UninstallOption {
 	disableHooks := false
 	if v, err := strconv.ParseBool(val); err == nil {
@@ -236,3 +353,179 @@ func (u UninstallDescription) UninstallOption(v string) helmclient.UninstallOpti
 		return nil
 	}
 }
+
+// OptionalInstallAnnotations are the install annotations that are not enabled
+// by default. They set the corresponding options of the Helm install action:
+//
+//   - "helm.sdk.operatorframework.io/install-wait" (boolean)
+//   - "helm.sdk.operatorframework.io/install-wait-for-jobs" (boolean)
+//   - "helm.sdk.operatorframework.io/install-timeout" (duration, e.g. "5m")
+//   - "helm.sdk.operatorframework.io/install-atomic" (boolean)
+//   - "helm.sdk.operatorframework.io/install-skip-crds" (boolean)
+//   - "helm.sdk.operatorframework.io/install-disable-openapi-validation" (boolean)
+//
+// Invalid values fail the install.
+var OptionalInstallAnnotations = []Install{
+	installAnnotation{boolAnnotation(defaultInstallWaitName, func(i *action.Install, v bool) { i.Wait = v })},
+	installAnnotation{boolAnnotation(defaultInstallWaitForJobsName, func(i *action.Install, v bool) { i.WaitForJobs = v })},
+	installAnnotation{durationAnnotation(defaultInstallTimeoutName, func(i *action.Install, v time.Duration) { i.Timeout = v })},
+	installAnnotation{boolAnnotation(defaultInstallAtomicName, func(i *action.Install, v bool) { i.Atomic = v })},
+	installAnnotation{boolAnnotation(defaultInstallSkipCRDsName, func(i *action.Install, v bool) { i.SkipCRDs = v })},
+	installAnnotation{boolAnnotation(defaultInstallDisableOpenAPIValidationName, func(i *action.Install, v bool) { i.DisableOpenAPIValidation = v })},
+}
+
+// OptionalUpgradeAnnotations are the upgrade annotations that are not enabled
+// by default. They set the corresponding options of the Helm upgrade action:
+//
+//   - "helm.sdk.operatorframework.io/upgrade-wait" (boolean)
+//   - "helm.sdk.operatorframework.io/upgrade-wait-for-jobs" (boolean)
+//   - "helm.sdk.operatorframework.io/upgrade-timeout" (duration, e.g. "5m")
+//   - "helm.sdk.operatorframework.io/upgrade-atomic" (boolean)
+//   - "helm.sdk.operatorframework.io/upgrade-cleanup-on-fail" (boolean)
+//   - "helm.sdk.operatorframework.io/upgrade-skip-crds" (boolean)
+//   - "helm.sdk.operatorframework.io/upgrade-disable-openapi-validation" (boolean)
+//   - "helm.sdk.operatorframework.io/upgrade-reset-values" (boolean)
+//   - "helm.sdk.operatorframework.io/upgrade-reuse-values" (boolean)
+//
+// Invalid values fail the upgrade.
+var OptionalUpgradeAnnotations = []Upgrade{
+	upgradeAnnotation{boolAnnotation(defaultUpgradeWaitName, func(u *action.Upgrade, v bool) { u.Wait = v })},
+	upgradeAnnotation{boolAnnotation(defaultUpgradeWaitForJobsName, func(u *action.Upgrade, v bool) { u.WaitForJobs = v })},
+	upgradeAnnotation{durationAnnotation(defaultUpgradeTimeoutName, func(u *action.Upgrade, v time.Duration) { u.Timeout = v })},
+	upgradeAnnotation{boolAnnotation(defaultUpgradeAtomicName, func(u *action.Upgrade, v bool) { u.Atomic = v })},
+	upgradeAnnotation{boolAnnotation(defaultUpgradeCleanupOnFailName, func(u *action.Upgrade, v bool) { u.CleanupOnFail = v })},
+	upgradeAnnotation{boolAnnotation(defaultUpgradeSkipCRDsName, func(u *action.Upgrade, v bool) { u.SkipCRDs = v })},
+	upgradeAnnotation{boolAnnotation(defaultUpgradeDisableOpenAPIValidationName, func(u *action.Upgrade, v bool) { u.DisableOpenAPIValidation = v })},
+	upgradeAnnotation{boolAnnotation(defaultUpgradeResetValuesName, func(u *action.Upgrade, v bool) { u.ResetValues = v })},
+	upgradeAnnotation{boolAnnotation(defaultUpgradeReuseValuesName, func(u *action.Upgrade, v bool) { u.ReuseValues = v })},
+}
+
+// OptionalUninstallAnnotations are the uninstall annotations that are not
+// enabled by default. They set the corresponding options of the Helm
+// uninstall action:
+//
+//   - "helm.sdk.operatorframework.io/uninstall-wait" (boolean)
+//   - "helm.sdk.operatorframework.io/uninstall-timeout" (duration, e.g. "5m")
+//   - "helm.sdk.operatorframework.io/uninstall-keep-history" (boolean)
+//   - "helm.sdk.operatorframework.io/uninstall-cascade" (one of "background",
+//     "foreground" and "orphan")
+//
+// Invalid values fail the uninstall.
+var OptionalUninstallAnnotations = []Uninstall{
+	uninstallAnnotation{boolAnnotation(defaultUninstallWaitName, func(u *action.Uninstall, v bool) { u.Wait = v })},
+	uninstallAnnotation{durationAnnotation(defaultUninstallTimeoutName, func(u *action.Uninstall, v time.Duration) { u.Timeout = v })},
+	uninstallAnnotation{boolAnnotation(defaultUninstallKeepHistoryName, func(u *action.Uninstall, v bool) { u.KeepHistory = v })},
+	uninstallAnnotation{valueAnnotation[action.Uninstall]{name: defaultUninstallCascadeName, parse: func(val string) (func(*action.Uninstall), error) {
+		if err := validateCascade(val); err != nil {
+			return nil, err
+		}
+		return func(u *action.Uninstall) { u.DeletionPropagation = val }, nil
+	}}},
+}
+
+// valueAnnotation is an annotation whose value is parsed into a change of the
+// options of an action of type T.
+type valueAnnotation[T any] struct {
+	name  string
+	parse func(string) (func(*T), error)
+}
+
+func (a valueAnnotation[T]) Name() string {
+	return a.name
+}
+
+func (a valueAnnotation[T]) Validate(val string) error {
+	_, err := a.parse(val)
+	return err
+}
+
+func (a valueAnnotation[T]) option(val string) func(*T) error {
+	apply, err := a.parse(val)
+	return func(opts *T) error {
+		if err != nil {
+			return invalidValue(a.name, val, err)
+		}
+		apply(opts)
+		return nil
+	}
+}
+
+// boolAnnotation returns an annotation named name that applies its boolean
+// value with apply.
+func boolAnnotation[T any](name string, apply func(*T, bool)) valueAnnotation[T] {
+	return valueAnnotation[T]{name: name, parse: func(val string) (func(*T), error) {
+		if err := validateBool(val); err != nil {
+			return nil, err
+		}
+		v, _ := strconv.ParseBool(val)
+		return func(opts *T) { apply(opts, v) }, nil
+	}}
+}
+
+// durationAnnotation returns an annotation named name that applies its
+// non-negative duration value with apply.
+func durationAnnotation[T any](name string, apply func(*T, time.Duration)) valueAnnotation[T] {
+	return valueAnnotation[T]{name: name, parse: func(val string) (func(*T), error) {
+		if err := validateTimeout(val); err != nil {
+			return nil, err
+		}
+		v, _ := time.ParseDuration(val)
+		return func(opts *T) { apply(opts, v) }, nil
+	}}
+}
+
+type installAnnotation struct {
+	valueAnnotation[action.Install]
+}
+
+func (a installAnnotation) InstallOption(val string) helmclient.InstallOption {
+	return a.option(val)
+}
+
+type upgradeAnnotation struct {
+	valueAnnotation[action.Upgrade]
+}
+
+func (a upgradeAnnotation) UpgradeOption(val string) helmclient.UpgradeOption {
+	return a.option(val)
+}
+
+type uninstallAnnotation struct {
+	valueAnnotation[action.Uninstall]
+}
+
+func (a uninstallAnnotation) UninstallOption(val string) helmclient.UninstallOption {
+	return a.option(val)
+}
+
+var (
+	errNotBool    = errors.New("must be a boolean")
+	errNotTimeout = errors.New("must be a non-negative duration")
+	errNotCascade = errors.New("must be one of background, foreground, orphan")
+)
+
+func validateBool(val string) error {
+	if _, err := strconv.ParseBool(val); err != nil {
+		return errNotBool
+	}
+	return nil
+}
+
+func validateTimeout(val string) error {
+	if v, err := time.ParseDuration(val); err != nil || v < 0 {
+		return errNotTimeout
+	}
+	return nil
+}
+
+func validateCascade(val string) error {
+	switch val {
+	case "background", "foreground", "orphan":
+		return nil
+	}
+	return errNotCascade
+}
+
+func invalidValue(name, val string, err error) error {
+	return fmt.Errorf("invalid value %q for annotation %q: %w", val, name, err)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package annotation
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"helm.sh/helm/v3/pkg/action"
@@ -255,4 +257,176 @@ var _ = Describe("Annotation", func() {
 			})
 		})
 	})
+
+	Describe("Release options", func() {
+		DescribeTable("should set install options",
+			func(a Install, val string, expected *action.Install) {
+				install := &action.Install{}
+				Expect(a.InstallOption(val)(install)).To(Succeed())
+				Expect(install).To(Equal(expected))
+			},
+			Entry("wait", optionalInstall(defaultInstallWaitName), "true", &action.Install{Wait: true}),
+			Entry("wait for jobs", optionalInstall(defaultInstallWaitForJobsName), "true", &action.Install{WaitForJobs: true}),
+			Entry("timeout", optionalInstall(defaultInstallTimeoutName), "5m", &action.Install{Timeout: 5 * time.Minute}),
+			Entry("atomic", optionalInstall(defaultInstallAtomicName), "true", &action.Install{Atomic: true}),
+			Entry("skip CRDs", optionalInstall(defaultInstallSkipCRDsName), "true", &action.Install{SkipCRDs: true}),
+			Entry("disable OpenAPI validation", optionalInstall(defaultInstallDisableOpenAPIValidationName), "true", &action.Install{DisableOpenAPIValidation: true}),
+		)
+
+		DescribeTable("should set upgrade options",
+			func(a Upgrade, val string, expected *action.Upgrade) {
+				upgrade := &action.Upgrade{}
+				Expect(a.UpgradeOption(val)(upgrade)).To(Succeed())
+				Expect(upgrade).To(Equal(expected))
+			},
+			Entry("wait", optionalUpgrade(defaultUpgradeWaitName), "true", &action.Upgrade{Wait: true}),
+			Entry("wait for jobs", optionalUpgrade(defaultUpgradeWaitForJobsName), "true", &action.Upgrade{WaitForJobs: true}),
+			Entry("timeout", optionalUpgrade(defaultUpgradeTimeoutName), "90s", &action.Upgrade{Timeout: 90 * time.Second}),
+			Entry("atomic", optionalUpgrade(defaultUpgradeAtomicName), "true", &action.Upgrade{Atomic: true}),
+			Entry("cleanup on fail", optionalUpgrade(defaultUpgradeCleanupOnFailName), "true", &action.Upgrade{CleanupOnFail: true}),
+			Entry("skip CRDs", optionalUpgrade(defaultUpgradeSkipCRDsName), "true", &action.Upgrade{SkipCRDs: true}),
+			Entry("disable OpenAPI validation", optionalUpgrade(defaultUpgradeDisableOpenAPIValidationName), "true", &action.Upgrade{DisableOpenAPIValidation: true}),
+			Entry("reset values", optionalUpgrade(defaultUpgradeResetValuesName), "true", &action.Upgrade{ResetValues: true}),
+			Entry("reuse values", optionalUpgrade(defaultUpgradeReuseValuesName), "true", &action.Upgrade{ReuseValues: true}),
+		)
+
+		DescribeTable("should set uninstall options",
+			func(a Uninstall, val string, expected *action.Uninstall) {
+				uninstall := &action.Uninstall{}
+				Expect(a.UninstallOption(val)(uninstall)).To(Succeed())
+				Expect(uninstall).To(Equal(expected))
+			},
+			Entry("wait", optionalUninstall(defaultUninstallWaitName), "true", &action.Uninstall{Wait: true}),
+			Entry("timeout", optionalUninstall(defaultUninstallTimeoutName), "1h", &action.Uninstall{Timeout: time.Hour}),
+			Entry("keep history", optionalUninstall(defaultUninstallKeepHistoryName), "true", &action.Uninstall{KeepHistory: true}),
+			Entry("cascade", optionalUninstall(defaultUninstallCascadeName), "foreground", &action.Uninstall{DeletionPropagation: "foreground"}),
+		)
+
+		DescribeTable("should fail for invalid values",
+			func(option func() error, expectedErr string) {
+				Expect(option()).To(MatchError(expectedErr))
+			},
+			Entry("boolean",
+				func() error {
+					return optionalInstall(defaultInstallWaitName).InstallOption("yes please")(&action.Install{})
+				},
+				`invalid value "yes please" for annotation "helm.sdk.operatorframework.io/install-wait": must be a boolean`),
+			Entry("timeout",
+				func() error { return optionalUpgrade(defaultUpgradeTimeoutName).UpgradeOption("5")(&action.Upgrade{}) },
+				`invalid value "5" for annotation "helm.sdk.operatorframework.io/upgrade-timeout": must be a non-negative duration`),
+			Entry("negative timeout",
+				func() error {
+					return optionalUninstall(defaultUninstallTimeoutName).UninstallOption("-1m")(&action.Uninstall{})
+				},
+				`invalid value "-1m" for annotation "helm.sdk.operatorframework.io/uninstall-timeout": must be a non-negative duration`),
+			Entry("cascade",
+				func() error {
+					return optionalUninstall(defaultUninstallCascadeName).UninstallOption("later")(&action.Uninstall{})
+				},
+				`invalid value "later" for annotation "helm.sdk.operatorframework.io/uninstall-cascade": must be one of background, foreground, orphan`),
+		)
+	})
+
+	Describe("Validator", func() {
+		DescribeTable("should accept valid values",
+			func(v Validator, val string) {
+				Expect(v.Validate(val)).To(Succeed())
+			},
+			Entry("install disable hooks", InstallDisableHooks{}, "true"),
+			Entry("upgrade force", UpgradeForce{}, "False"),
+			Entry("install timeout", optionalInstall(defaultInstallTimeoutName), "0s"),
+			Entry("uninstall cascade", optionalUninstall(defaultUninstallCascadeName), "orphan"),
+		)
+
+		DescribeTable("should reject invalid values",
+			func(v Validator, val, expectedErr string) {
+				Expect(v.Validate(val)).To(MatchError(expectedErr))
+			},
+			Entry("install disable hooks", InstallDisableHooks{}, "invalid", "must be a boolean"),
+			Entry("upgrade disable hooks", UpgradeDisableHooks{}, "invalid", "must be a boolean"),
+			Entry("upgrade force", UpgradeForce{}, "invalid", "must be a boolean"),
+			Entry("uninstall disable hooks", UninstallDisableHooks{}, "invalid", "must be a boolean"),
+			Entry("upgrade timeout", optionalUpgrade(defaultUpgradeTimeoutName), "-1s", "must be a non-negative duration"),
+			Entry("uninstall cascade", optionalUninstall(defaultUninstallCascadeName), "invalid", "must be one of background, foreground, orphan"),
+		)
+
+		It("should not be implemented by descriptions", func() {
+			var a Install = InstallDescription{}
+			_, ok := a.(Validator)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("Defaults", func() {
+		It("should not enable the optional annotations", func() {
+			Expect(names(DefaultInstallAnnotations)).To(ConsistOf(defaultInstallDescriptionName, defaultInstallDisableHooksName))
+			Expect(names(DefaultUpgradeAnnotations)).To(ConsistOf(defaultUpgradeDescriptionName, defaultUpgradeDisableHooksName, defaultUpgradeForceName))
+			Expect(names(DefaultUninstallAnnotations)).To(ConsistOf(defaultUninstallDescriptionName, defaultUninstallDisableHooksName))
+		})
+	})
+
+	Describe("ByName", func() {
+		It("should return the default install annotations by name", func() {
+			as, err := InstallByName(defaultInstallDisableHooksName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(as).To(Equal([]Install{InstallDisableHooks{}}))
+		})
+
+		It("should return the default upgrade annotations by name", func() {
+			as, err := UpgradeByName(defaultUpgradeForceName, defaultUpgradeDescriptionName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(as).To(Equal([]Upgrade{UpgradeForce{}, UpgradeDescription{}}))
+		})
+
+		It("should return the default uninstall annotations by name", func() {
+			as, err := UninstallByName(defaultUninstallDescriptionName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(as).To(Equal([]Uninstall{UninstallDescription{}}))
+		})
+
+		It("should return the optional annotations by name", func() {
+			as, err := UpgradeByName(defaultUpgradeForceName, defaultUpgradeAtomicName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(as).To(HaveLen(2))
+			Expect(as[0]).To(Equal(UpgradeForce{}))
+			Expect(as[1].Name()).To(Equal(defaultUpgradeAtomicName))
+		})
+
+		It("should return no annotations for no names", func() {
+			as, err := InstallByName()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(as).To(BeEmpty())
+		})
+
+		It("should fail for an unknown name", func() {
+			_, err := UpgradeByName(defaultInstallDisableHooksName)
+			Expect(err).To(MatchError(ContainSubstring("unknown annotation")))
+		})
+	})
 })
+
+func names[T interface{ Name() string }](as []T) []string {
+	out := make([]string, 0, len(as))
+	for _, a := range as {
+		out = append(out, a.Name())
+	}
+	return out
+}
+
+func optionalInstall(name string) Install {
+	as, err := InstallByName(name)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	return as[0]
+}
+
+func optionalUpgrade(name string) Upgrade {
+	as, err := UpgradeByName(name)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	return as[0]
+}
+
+func optionalUninstall(name string) Uninstall {
+	as, err := UninstallByName(name)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	return as[0]
+}
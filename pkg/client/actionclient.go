@@ -26,7 +26,9 @@ import (
 	"gomodules.xyz/jsonpatch/v2"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
 	helmkube "helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/storage/driver"
@@ -39,6 +41,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/cli-runtime/pkg/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type ActionClientGetter interface {
@@ -57,7 +60,10 @@ type ActionInterface interface {
 	Install(name, namespace string, chrt *chart.Chart, vals map[string]interface{}, opts ...InstallOption) (*release.Release, error)
 	Upgrade(name, namespace string, chrt *chart.Chart, vals map[string]interface{}, opts ...UpgradeOption) (*release.Release, error)
 	Uninstall(name string, opts ...UninstallOption) (*release.UninstallReleaseResponse, error)
+	Rollback(name string, opts ...RollbackOption) error
+	MarkFailed(rel *release.Release, description string) error
 	Reconcile(rel *release.Release) error
+	Test(name string, opts ...TestOption) (*release.Release, error)
 }
 
 type GetOption func(*action.Get) error
@@ -66,6 +72,7 @@ type InstallOption func(*action.Install) error
 type UpgradeOption func(*action.Upgrade) error
 type UninstallOption func(*action.Uninstall) error
 type RollbackOption func(*action.Rollback) error
+type TestOption func(*action.ReleaseTesting) error
 
 type ActionClientGetterOption func(*actionClientGetter) error
 
@@ -104,6 +111,13 @@ func AppendUninstallOptions(opts ...UninstallOption) ActionClientGetterOption {
 	}
 }
 
+func AppendTestOptions(opts ...TestOption) ActionClientGetterOption {
+	return func(getter *actionClientGetter) error {
+		getter.defaultTestOpts = append(getter.defaultTestOpts, opts...)
+		return nil
+	}
+}
+
 func AppendInstallFailureUninstallOptions(opts ...UninstallOption) ActionClientGetterOption {
 	return func(getter *actionClientGetter) error {
 		getter.installFailureUninstallOpts = append(getter.installFailureUninstallOpts, opts...)
@@ -119,6 +133,19 @@ func AppendUpgradeFailureRollbackOptions(opts ...RollbackOption) ActionClientGet
 }
 
 func AppendPostRenderers(postRendererFns ...PostRendererProvider) ActionClientGetterOption {
+	return func(getter *actionClientGetter) error {
+		for _, fn := range postRendererFns {
+			getter.postRendererProviders = append(getter.postRendererProviders, ContextPostRendererProviderFor(fn))
+		}
+		return nil
+	}
+}
+
+// AppendContextPostRenderers appends post-renderers that are provided with
+// the context of each install and upgrade, see PostRendererContext. They are
+// chained with the post-renderers of AppendPostRenderers in the order in
+// which they are appended.
+func AppendContextPostRenderers(postRendererFns ...ContextPostRendererProvider) ActionClientGetterOption {
 	return func(getter *actionClientGetter) error {
 		getter.postRendererProviders = append(getter.postRendererProviders, postRendererFns...)
 		return nil
@@ -153,12 +180,13 @@ type actionClientGetter struct {
 	defaultInstallOpts   []InstallOption
 	defaultUpgradeOpts   []UpgradeOption
 	defaultUninstallOpts []UninstallOption
+	defaultTestOpts      []TestOption
 
 	enableFailureRollbacks      bool
 	installFailureUninstallOpts []UninstallOption
 	upgradeFailureRollbackOpts  []RollbackOption
 
-	postRendererProviders []PostRendererProvider
+	postRendererProviders []ContextPostRendererProvider
 }
 
 var _ ActionClientGetter = &actionClientGetter{}
@@ -172,23 +200,25 @@ func (hcg *actionClientGetter) ActionClientFor(ctx context.Context, obj client.O
 	if err != nil {
 		return nil, err
 	}
-	var cpr = chainedPostRenderer{}
-	for _, provider := range hcg.postRendererProviders {
-		cpr = append(cpr, provider(rm, actionConfig.KubeClient, obj))
-	}
-	cpr = append(cpr, DefaultPostRendererFunc(rm, actionConfig.KubeClient, obj))
 
 	return &actionClient{
 		conf: actionConfig,
 
-		// For the install and upgrade options, we put the post renderer first in the list
-		// on purpose because we want user-provided defaults to be able to override the
-		// post-renderer that we automatically configure for the client.
+		postRendererProviders: concat(hcg.postRendererProviders, ContextPostRendererProviderFor(DefaultPostRendererFunc)),
+		postRendererContext: PostRendererContext{
+			Context:    ctx,
+			RESTMapper: rm,
+			KubeClient: actionConfig.KubeClient,
+			Owner:      obj,
+			Log:        log.FromContext(ctx),
+		},
+
 		defaultGetOpts:       hcg.defaultGetOpts,
 		defaultHistoryOpts:   hcg.defaultHistoryOpts,
-		defaultInstallOpts:   append([]InstallOption{WithInstallPostRenderer(cpr)}, hcg.defaultInstallOpts...),
-		defaultUpgradeOpts:   append([]UpgradeOption{WithUpgradePostRenderer(cpr)}, hcg.defaultUpgradeOpts...),
+		defaultInstallOpts:   hcg.defaultInstallOpts,
+		defaultUpgradeOpts:   hcg.defaultUpgradeOpts,
 		defaultUninstallOpts: hcg.defaultUninstallOpts,
+		defaultTestOpts:      hcg.defaultTestOpts,
 
 		enableFailureRollbacks:      hcg.enableFailureRollbacks,
 		installFailureUninstallOpts: hcg.installFailureUninstallOpts,
@@ -199,11 +229,15 @@ func (hcg *actionClientGetter) ActionClientFor(ctx context.Context, obj client.O
 type actionClient struct {
 	conf *action.Configuration
 
+	postRendererProviders []ContextPostRendererProvider
+	postRendererContext   PostRendererContext
+
 	defaultGetOpts       []GetOption
 	defaultHistoryOpts   []HistoryOption
 	defaultInstallOpts   []InstallOption
 	defaultUpgradeOpts   []UpgradeOption
 	defaultUninstallOpts []UninstallOption
+	defaultTestOpts      []TestOption
 
 	enableFailureRollbacks      bool
 	installFailureUninstallOpts []UninstallOption
@@ -241,7 +275,16 @@ func (c *actionClient) History(name string, opts ...HistoryOption) ([]*release.R
 
 func (c *actionClient) Install(name, namespace string, chrt *chart.Chart, vals map[string]interface{}, opts ...InstallOption) (*release.Release, error) {
 	install := action.NewInstall(c.conf)
-	for _, o := range concat(c.defaultInstallOpts, opts...) {
+	postRenderer := c.postRenderer(chrt, vals, func(prc *PostRendererContext) {
+		prc.Action = PostRendererActionInstall
+		prc.DryRun = install.DryRun
+		prc.ReleaseName = install.ReleaseName
+		prc.Namespace = install.Namespace
+	})
+	// The post-renderer is the first option on purpose, so that the
+	// default and user-provided options are able to override it.
+	installOpts := append([]InstallOption{WithInstallPostRenderer(postRenderer)}, c.defaultInstallOpts...)
+	for _, o := range concat(installOpts, opts...) {
 		if err := o(install); err != nil {
 			return nil, err
 		}
@@ -278,7 +321,14 @@ func (c *actionClient) Install(name, namespace string, chrt *chart.Chart, vals m
 
 func (c *actionClient) Upgrade(name, namespace string, chrt *chart.Chart, vals map[string]interface{}, opts ...UpgradeOption) (*release.Release, error) {
 	upgrade := action.NewUpgrade(c.conf)
-	for _, o := range concat(c.defaultUpgradeOpts, opts...) {
+	postRenderer := c.postRenderer(chrt, vals, func(prc *PostRendererContext) {
+		prc.Action = PostRendererActionUpgrade
+		prc.DryRun = upgrade.DryRun
+		prc.ReleaseName = name
+		prc.Namespace = upgrade.Namespace
+	})
+	upgradeOpts := append([]UpgradeOption{WithUpgradePostRenderer(postRenderer)}, c.defaultUpgradeOpts...)
+	for _, o := range concat(upgradeOpts, opts...) {
 		if err := o(upgrade); err != nil {
 			return nil, err
 		}
@@ -308,6 +358,32 @@ func (c *actionClient) Upgrade(name, namespace string, chrt *chart.Chart, vals m
 	return rel, nil
 }
 
+// postRenderer returns the post-renderer of an install or upgrade of chrt
+// with vals. describe completes the context of the action when the
+// post-renderer is run.
+func (c *actionClient) postRenderer(chrt *chart.Chart, vals map[string]interface{}, describe func(*PostRendererContext)) postrender.PostRenderer {
+	return contextPostRenderer{
+		providers: c.postRendererProviders,
+		context: func() (PostRendererContext, error) {
+			prc := c.postRendererContext
+			prc.Chart = chrt.Metadata
+			values, err := chartutil.CoalesceValues(chrt, vals)
+			if err != nil {
+				return prc, fmt.Errorf("failed to coalesce values: %w", err)
+			}
+			prc.Values = values
+			describe(&prc)
+			return prc, nil
+		},
+	}
+}
+
+// Rollback rolls back the release to a previous revision, which is the
+// revision before the latest one unless a version is set in the options.
+func (c *actionClient) Rollback(name string, opts ...RollbackOption) error {
+	return c.rollback(name, opts...)
+}
+
 func (c *actionClient) rollback(name string, opts ...RollbackOption) error {
 	rollback := action.NewRollback(c.conf)
 	for _, o := range opts {
@@ -322,6 +398,19 @@ func (c *actionClient) Uninstall(name string, opts ...UninstallOption) (*release
 	return c.uninstall(name, concat(c.defaultUninstallOpts, opts...)...)
 }
 
+// Test runs the tests of the release with the given name, which are the hooks
+// of the release annotated with "helm.sh/hook: test". The returned release
+// records the outcome of each test in its hooks, even if a test failed.
+func (c *actionClient) Test(name string, opts ...TestOption) (*release.Release, error) {
+	test := action.NewReleaseTesting(c.conf)
+	for _, o := range concat(c.defaultTestOpts, opts...) {
+		if err := o(test); err != nil {
+			return nil, err
+		}
+	}
+	return test.Run(name)
+}
+
 func (c *actionClient) uninstall(name string, opts ...UninstallOption) (*release.UninstallReleaseResponse, error) {
 	uninstall := action.NewUninstall(c.conf)
 	for _, o := range opts {
@@ -332,6 +421,15 @@ func (c *actionClient) uninstall(name string, opts ...UninstallOption) (*release
 	return uninstall.Run(name)
 }
 
+// MarkFailed sets the status of the stored release revision to failed. Helm
+// refuses to upgrade releases whose latest revision is pending, e.g. because
+// the operator was stopped during an install or upgrade. Marking such a
+// revision as failed allows it to be upgraded again.
+func (c *actionClient) MarkFailed(rel *release.Release, description string) error {
+	rel.SetStatus(release.StatusFailed, description)
+	return c.conf.Releases.Update(rel)
+}
+
 func (c *actionClient) Reconcile(rel *release.Release) error {
 	infos, err := c.conf.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
 	if err != nil {
@@ -305,6 +305,46 @@ var _ = Describe("ActionClient", func() {
 				})
 				Expect(err).ToNot(HaveOccurred())
 
+				// Uninstall the chart to cleanup for other tests.
+				_, err = ac.Uninstall(obj.GetName())
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("should pass the context of installs and upgrades to postrenderers", func() {
+				var contexts []PostRendererContext
+				acg, err := NewActionClientGetter(actionConfigGetter, AppendContextPostRenderers(func(prc PostRendererContext) postrender.PostRenderer {
+					contexts = append(contexts, prc)
+					return PostRendererFunc(func(in *bytes.Buffer) (*bytes.Buffer, error) { return in, nil })
+				}))
+				Expect(err).ToNot(HaveOccurred())
+
+				type ctxKey struct{}
+				ctx := context.WithValue(context.Background(), ctxKey{}, "reconcile")
+				ac, err := acg.ActionClientFor(ctx, obj)
+				Expect(err).ToNot(HaveOccurred())
+
+				vals := chartutil.Values{"replicaCount": 2}
+				_, err = ac.Install(obj.GetName(), obj.GetNamespace(), &chrt, vals)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = ac.Upgrade(obj.GetName(), obj.GetNamespace(), &chrt, vals, func(upgrade *action.Upgrade) error {
+					upgrade.DryRun = true
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(contexts).To(HaveLen(2))
+				for _, prc := range contexts {
+					Expect(prc.Context.Value(ctxKey{})).To(Equal("reconcile"))
+					Expect(prc.Owner).To(Equal(obj))
+					Expect(prc.ReleaseName).To(Equal(obj.GetName()))
+					Expect(prc.Namespace).To(Equal(obj.GetNamespace()))
+					Expect(prc.Chart).To(Equal(chrt.Metadata))
+					Expect(prc.Values).To(HaveKeyWithValue("replicaCount", 2))
+				}
+				Expect(contexts[0].Action).To(Equal(PostRendererActionInstall))
+				Expect(contexts[0].DryRun).To(BeFalse())
+				Expect(contexts[1].Action).To(Equal(PostRendererActionUpgrade))
+				Expect(contexts[1].DryRun).To(BeTrue())
+
 				// Uninstall the chart to cleanup for other tests.
 				_, err = ac.Uninstall(obj.GetName())
 				Expect(err).ToNot(HaveOccurred())
@@ -626,6 +666,61 @@ var _ = Describe("ActionClient", func() {
 					})
 				})
 			})
+			var _ = Describe("Rollback", func() {
+				It("should roll back to the given revision", func() {
+					_, err := ac.Upgrade(obj.GetName(), obj.GetNamespace(), &chrt, vals)
+					Expect(err).ToNot(HaveOccurred())
+
+					opt := func(r *action.Rollback) error { r.Version = installedRelease.Version; return nil }
+					Expect(ac.Rollback(obj.GetName(), opt)).To(Succeed())
+
+					rel, err := ac.Get(obj.GetName())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(rel.Version).To(Equal(3))
+					Expect(rel.Info.Status).To(Equal(release.StatusDeployed))
+					Expect(rel.Info.Description).To(Equal("Rollback to 1"))
+				})
+				When("using an option function that returns an error", func() {
+					It("should fail", func() {
+						opt := func(*action.Rollback) error { return errors.New("expect this error") }
+						Expect(ac.Rollback(obj.GetName(), opt)).To(MatchError("expect this error"))
+					})
+				})
+			})
+			var _ = Describe("Test", func() {
+				It("should run the selected tests", func() {
+					opt := func(t *action.ReleaseTesting) error {
+						t.Filters[action.IncludeNameFilter] = []string{"no-such-test"}
+						return nil
+					}
+					rel, err := ac.Test(obj.GetName(), opt)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(rel.Version).To(Equal(installedRelease.Version))
+					Expect(rel.Hooks).NotTo(BeEmpty())
+					for _, h := range rel.Hooks {
+						Expect(h.LastRun.Phase).To(BeEmpty())
+					}
+				})
+				When("using an option function that returns an error", func() {
+					It("should fail", func() {
+						opt := func(*action.ReleaseTesting) error { return errors.New("expect this error") }
+						rel, err := ac.Test(obj.GetName(), opt)
+						Expect(err).To(MatchError("expect this error"))
+						Expect(rel).To(BeNil())
+					})
+				})
+			})
+			var _ = Describe("MarkFailed", func() {
+				It("should store the release as failed", func() {
+					Expect(ac.MarkFailed(installedRelease, "marked as failed")).To(Succeed())
+
+					rel, err := ac.Get(obj.GetName())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(rel.Version).To(Equal(installedRelease.Version))
+					Expect(rel.Info.Status).To(Equal(release.StatusFailed))
+					Expect(rel.Info.Description).To(Equal("marked as failed"))
+				})
+			})
 			var _ = Describe("Reconcile", func() {
 				It("should succeed", func() {
 					By("reconciling the release", func() {
@@ -19,12 +19,14 @@ package client
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/go-logr/logr"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/discovery"
@@ -32,8 +34,37 @@ import (
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmstorage "github.com/operator-framework/helm-operator-plugins/pkg/storage"
+)
+
+const (
+	// StorageDriverSecrets is the name of Helm's default secrets storage driver.
+	StorageDriverSecrets = "secrets"
+	// StorageDriverChunkedSecrets is the name of the chunked secrets storage
+	// driver, which splits large releases across multiple secrets.
+	StorageDriverChunkedSecrets = "chunked-secrets"
+	// StorageDriverConfigMaps is the name of Helm's config maps storage driver.
+	StorageDriverConfigMaps = "configmaps"
+	// StorageDriverSQL is the name of Helm's SQL storage driver, which stores
+	// releases in a PostgreSQL database.
+	StorageDriverSQL = "sql"
+	// StorageDriverMemory is the name of Helm's in-memory storage driver.
+	// Releases stored in memory are lost when the operator restarts, so it
+	// should only be used for testing.
+	StorageDriverMemory = "memory"
 )
 
+// StorageDrivers are the names of the storage drivers that can be selected by
+// name, e.g. in a watches file.
+var StorageDrivers = []string{
+	StorageDriverSecrets,
+	StorageDriverChunkedSecrets,
+	StorageDriverConfigMaps,
+	StorageDriverSQL,
+	StorageDriverMemory,
+}
+
 type ActionConfigGetter interface {
 	ActionConfigFor(ctx context.Context, obj client.Object) (*action.Configuration, error)
 }
@@ -181,8 +212,9 @@ func (acg *actionConfigGetter) ActionConfigFor(ctx context.Context, obj client.O
 		return nil, fmt.Errorf("get storage driver for object: %v", err)
 	}
 
-	// Initialize the storage backend
-	s := storage.Init(d)
+	// Initialize the storage backend. Temporary copies of releases that are
+	// being migrated must not be taken for revisions of their releases.
+	s := storage.Init(helmstorage.HideMigratingCopies(d))
 
 	return &action.Configuration{
 		RESTClientGetter: clientRCG,
@@ -231,3 +263,169 @@ func DefaultSecretsStorageDriver(opts SecretsStorageDriverOpts) ObjectToStorageD
 		return d, nil
 	}
 }
+
+// DefaultChunkedSecretsOwner is the owner label value used for chunked release
+// secrets when ChunkedSecretsStorageDriverOpts.Owner is not set. It must differ
+// from "helm" so that chunked secrets are not mistaken for secrets managed by
+// Helm's native secrets driver.
+const DefaultChunkedSecretsOwner = "helm-operator"
+
+type ChunkedSecretsStorageDriverOpts struct {
+	DisableOwnerRefInjection bool
+	StorageNamespaceMapper   ObjectToStringMapper
+	Owner                    string
+	ChunkedSecretsConfig     helmstorage.ChunkedSecretsConfig
+}
+
+// ChunkedSecretsStorageDriver returns a storage driver mapper that stores
+// releases using the chunked secrets driver from the storage package. When
+// owner reference injection is enabled, only the index secret of a release
+// is owned by the object; chunk secrets are owned by their index secret.
+func ChunkedSecretsStorageDriver(opts ChunkedSecretsStorageDriverOpts) ObjectToStorageDriverMapper {
+	if opts.StorageNamespaceMapper == nil {
+		opts.StorageNamespaceMapper = getObjectNamespace
+	}
+	if opts.Owner == "" {
+		opts.Owner = DefaultChunkedSecretsOwner
+	}
+	return func(ctx context.Context, obj client.Object, restConfig *rest.Config) (driver.Driver, error) {
+		storageNamespace, err := opts.StorageNamespaceMapper(obj)
+		if err != nil {
+			return nil, fmt.Errorf("get storage namespace for object: %v", err)
+		}
+		secretsInterface, err := v1.NewForConfig(restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("create secrets client for storage: %v", err)
+		}
+
+		secretClient := secretsInterface.Secrets(storageNamespace)
+		if !opts.DisableOwnerRefInjection {
+			ownerRef := metav1.NewControllerRef(obj, obj.GetObjectKind().GroupVersionKind())
+			secretClient = NewOwnerRefSecretClient(secretClient, []metav1.OwnerReference{*ownerRef}, func(secret *corev1.Secret) bool {
+				return secret.Type == helmstorage.SecretTypeChunkedIndex
+			})
+		}
+		config := opts.ChunkedSecretsConfig
+		if config.Log == nil {
+			config.Log = getDebugLogger(ctx)
+		}
+		return helmstorage.NewChunkedSecrets(secretClient, opts.Owner, config), nil
+	}
+}
+
+type ConfigMapsStorageDriverOpts struct {
+	DisableOwnerRefInjection bool
+	StorageNamespaceMapper   ObjectToStringMapper
+}
+
+// ConfigMapsStorageDriver returns a storage driver mapper that stores releases
+// in config maps using Helm's config maps driver.
+func ConfigMapsStorageDriver(opts ConfigMapsStorageDriverOpts) ObjectToStorageDriverMapper {
+	if opts.StorageNamespaceMapper == nil {
+		opts.StorageNamespaceMapper = getObjectNamespace
+	}
+	return func(ctx context.Context, obj client.Object, restConfig *rest.Config) (driver.Driver, error) {
+		storageNamespace, err := opts.StorageNamespaceMapper(obj)
+		if err != nil {
+			return nil, fmt.Errorf("get storage namespace for object: %v", err)
+		}
+		configMapsInterface, err := v1.NewForConfig(restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("create config maps client for storage: %v", err)
+		}
+
+		configMapClient := configMapsInterface.ConfigMaps(storageNamespace)
+		if !opts.DisableOwnerRefInjection {
+			ownerRef := metav1.NewControllerRef(obj, obj.GetObjectKind().GroupVersionKind())
+			configMapClient = NewOwnerRefConfigMapClient(configMapClient, []metav1.OwnerReference{*ownerRef}, MatchAllConfigMaps)
+		}
+		d := driver.NewConfigMaps(configMapClient)
+		d.Log = getDebugLogger(ctx)
+		return d, nil
+	}
+}
+
+type SQLStorageDriverOpts struct {
+	// ConnectionString is the connection string of the PostgreSQL database.
+	ConnectionString       string
+	StorageNamespaceMapper ObjectToStringMapper
+
+	// NewDriver creates the driver for a storage namespace. It defaults to
+	// Helm's SQL driver and can be replaced, e.g. by a stand-in for tests.
+	NewDriver func(connectionString string, log func(string, ...interface{}), namespace string) (driver.Driver, error)
+}
+
+// SQLStorageDriver returns a storage driver mapper that stores releases in a
+// PostgreSQL database using Helm's SQL driver. One driver, and with it one
+// connection pool, is created per storage namespace and reused for all
+// objects whose releases are stored in that namespace.
+func SQLStorageDriver(opts SQLStorageDriverOpts) ObjectToStorageDriverMapper {
+	if opts.StorageNamespaceMapper == nil {
+		opts.StorageNamespaceMapper = getObjectNamespace
+	}
+	if opts.NewDriver == nil {
+		opts.NewDriver = func(connectionString string, log func(string, ...interface{}), namespace string) (driver.Driver, error) {
+			return driver.NewSQL(connectionString, log, namespace)
+		}
+	}
+	drivers := &namespacedDrivers{drivers: map[string]driver.Driver{}}
+	return func(ctx context.Context, obj client.Object, _ *rest.Config) (driver.Driver, error) {
+		storageNamespace, err := opts.StorageNamespaceMapper(obj)
+		if err != nil {
+			return nil, fmt.Errorf("get storage namespace for object: %v", err)
+		}
+		return drivers.get(storageNamespace, func() (driver.Driver, error) {
+			d, err := opts.NewDriver(opts.ConnectionString, getDebugLogger(ctx), storageNamespace)
+			if err != nil {
+				return nil, fmt.Errorf("create sql storage driver: %v", err)
+			}
+			return d, nil
+		})
+	}
+}
+
+type MemoryStorageDriverOpts struct {
+	StorageNamespaceMapper ObjectToStringMapper
+}
+
+// MemoryStorageDriver returns a storage driver mapper that keeps releases in
+// memory using Helm's memory driver. Releases are lost when the operator
+// restarts, so it should only be used for testing.
+func MemoryStorageDriver(opts MemoryStorageDriverOpts) ObjectToStorageDriverMapper {
+	if opts.StorageNamespaceMapper == nil {
+		opts.StorageNamespaceMapper = getObjectNamespace
+	}
+	drivers := &namespacedDrivers{drivers: map[string]driver.Driver{}}
+	return func(_ context.Context, obj client.Object, _ *rest.Config) (driver.Driver, error) {
+		storageNamespace, err := opts.StorageNamespaceMapper(obj)
+		if err != nil {
+			return nil, fmt.Errorf("get storage namespace for object: %v", err)
+		}
+		return drivers.get(storageNamespace, func() (driver.Driver, error) {
+			d := driver.NewMemory()
+			d.SetNamespace(storageNamespace)
+			return d, nil
+		})
+	}
+}
+
+// namespacedDrivers lazily creates and caches one driver per storage
+// namespace, for drivers that keep state across calls.
+type namespacedDrivers struct {
+	mu      sync.Mutex
+	drivers map[string]driver.Driver
+}
+
+func (n *namespacedDrivers) get(namespace string, create func() (driver.Driver, error)) (driver.Driver, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if d, ok := n.drivers[namespace]; ok {
+		return d, nil
+	}
+	d, err := create()
+	if err != nil {
+		return nil, err
+	}
+	n.drivers[namespace] = d
+	return d, nil
+}
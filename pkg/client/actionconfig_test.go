@@ -41,6 +41,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
 	"github.com/operator-framework/helm-operator-plugins/pkg/internal/testutil"
+	helmstorage "github.com/operator-framework/helm-operator-plugins/pkg/storage"
 )
 
 var _ = Describe("ActionConfig", func() {
@@ -165,6 +166,103 @@ metadata:
 				Expect(err).ToNot(HaveOccurred())
 			})
 
+			It("should use the chunked secrets storage driver", func() {
+				acg, err := NewActionConfigGetter(cfg, rm, StorageDriverMapper(ChunkedSecretsStorageDriver(ChunkedSecretsStorageDriverOpts{})))
+				Expect(err).ToNot(HaveOccurred())
+
+				ac, err := acg.ActionConfigFor(context.Background(), obj)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Installing a release")
+				i := action.NewInstall(ac)
+				i.ReleaseName = fmt.Sprintf("release-name-%s", rand.String(8))
+				i.Namespace = obj.GetNamespace()
+				rel, err := i.Run(&chrt, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rel.Namespace).To(Equal(obj.GetNamespace()))
+
+				By("Verifying the index secret is owned by the object")
+				secretKey := types.NamespacedName{
+					Namespace: obj.GetNamespace(),
+					Name:      fmt.Sprintf("sh.helm.release.v1.%s.v1", i.ReleaseName),
+				}
+				secret := &corev1.Secret{}
+				Expect(cl.Get(context.Background(), secretKey, secret)).To(Succeed())
+				Expect(secret.Type).To(Equal(helmstorage.SecretTypeChunkedIndex))
+				Expect(secret.Labels).To(HaveKeyWithValue("owner", DefaultChunkedSecretsOwner))
+				Expect(secret.OwnerReferences).To(HaveLen(1))
+
+				By("Uninstalling the release")
+				_, err = action.NewUninstall(ac).Run(i.ReleaseName)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should use the config maps storage driver", func() {
+				acg, err := NewActionConfigGetter(cfg, rm, StorageDriverMapper(ConfigMapsStorageDriver(ConfigMapsStorageDriverOpts{})))
+				Expect(err).ToNot(HaveOccurred())
+
+				ac, err := acg.ActionConfigFor(context.Background(), obj)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Installing a release")
+				i := action.NewInstall(ac)
+				i.ReleaseName = fmt.Sprintf("release-name-%s", rand.String(8))
+				i.Namespace = obj.GetNamespace()
+				_, err = i.Run(&chrt, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("Verifying the release config map is owned by the object")
+				configMapKey := types.NamespacedName{
+					Namespace: obj.GetNamespace(),
+					Name:      fmt.Sprintf("sh.helm.release.v1.%s.v1", i.ReleaseName),
+				}
+				configMap := &corev1.ConfigMap{}
+				Expect(cl.Get(context.Background(), configMapKey, configMap)).To(Succeed())
+				Expect(configMap.OwnerReferences).To(HaveLen(1))
+
+				By("Uninstalling the release")
+				_, err = action.NewUninstall(ac).Run(i.ReleaseName)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should reuse the memory storage driver per storage namespace", func() {
+				mapper := MemoryStorageDriver(MemoryStorageDriverOpts{})
+
+				obj2 := testutil.BuildTestCR(gvk)
+				obj2.SetName("other")
+				d1, err := mapper(context.Background(), obj, cfg)
+				Expect(err).ToNot(HaveOccurred())
+				d2, err := mapper(context.Background(), obj2, cfg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(d1).To(BeIdenticalTo(d2))
+				Expect(d1.Name()).To(Equal(driver.MemoryDriverName))
+
+				obj2.SetNamespace("other")
+				d3, err := mapper(context.Background(), obj2, cfg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(d3).NotTo(BeIdenticalTo(d1))
+			})
+
+			It("should create one sql storage driver per storage namespace", func() {
+				var created []string
+				mapper := SQLStorageDriver(SQLStorageDriverOpts{
+					ConnectionString: "postgres://localhost/helm",
+					NewDriver: func(connectionString string, _ func(string, ...interface{}), namespace string) (driver.Driver, error) {
+						Expect(connectionString).To(Equal("postgres://localhost/helm"))
+						created = append(created, namespace)
+						d := driver.NewMemory()
+						d.SetNamespace(namespace)
+						return d, nil
+					},
+				})
+
+				for i := 0; i < 2; i++ {
+					_, err := mapper(context.Background(), obj, cfg)
+					Expect(err).ToNot(HaveOccurred())
+				}
+				Expect(created).To(Equal([]string{obj.GetNamespace()}))
+			})
+
 			It("should use a custom rest config mapping", func() {
 				restConfigMapper := func(_ context.Context, obj client.Object, _ *rest.Config) (*rest.Config, error) {
 					return &rest.Config{
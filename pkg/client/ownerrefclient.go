@@ -63,3 +63,59 @@ func (c *ownerRefSecretClient) Update(ctx context.Context, in *corev1.Secret, op
 	}
 	return c.SecretInterface.Update(ctx, in, opts)
 }
+
+var _ clientcorev1.ConfigMapInterface = &ownerRefConfigMapClient{}
+
+// NewOwnerRefConfigMapClient returns a ConfigMapInterface that injects the provided owner references
+// to all created or updated config maps that match the provided match function. If match is nil, all
+// config maps are matched.
+func NewOwnerRefConfigMapClient(client clientcorev1.ConfigMapInterface, refs []metav1.OwnerReference, match func(*corev1.ConfigMap) bool) clientcorev1.ConfigMapInterface {
+	if match == nil {
+		match = MatchAllConfigMaps
+	}
+	return &ownerRefConfigMapClient{
+		ConfigMapInterface: client,
+		match:              match,
+		refs:               refs,
+	}
+}
+
+func MatchAllConfigMaps(_ *corev1.ConfigMap) bool {
+	return true
+}
+
+type ownerRefConfigMapClient struct {
+	clientcorev1.ConfigMapInterface
+	match func(configMap *corev1.ConfigMap) bool
+	refs  []metav1.OwnerReference
+}
+
+func (c *ownerRefConfigMapClient) appendMissingOwnerRefs(configMap *corev1.ConfigMap) {
+	hasOwnerRef := func(configMap *corev1.ConfigMap, ref metav1.OwnerReference) bool {
+		for _, r := range configMap.OwnerReferences {
+			if r.UID == ref.UID {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range c.refs {
+		if !hasOwnerRef(configMap, c.refs[i]) {
+			configMap.OwnerReferences = append(configMap.OwnerReferences, c.refs[i])
+		}
+	}
+}
+
+func (c *ownerRefConfigMapClient) Create(ctx context.Context, in *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error) {
+	if c.match == nil || c.match(in) {
+		c.appendMissingOwnerRefs(in)
+	}
+	return c.ConfigMapInterface.Create(ctx, in, opts)
+}
+
+func (c *ownerRefConfigMapClient) Update(ctx context.Context, in *corev1.ConfigMap, opts metav1.UpdateOptions) (*corev1.ConfigMap, error) {
+	if c.match == nil || c.match(in) {
+		c.appendMissingOwnerRefs(in)
+	}
+	return c.ConfigMapInterface.Update(ctx, in, opts)
+}
@@ -2,10 +2,14 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 
+	"github.com/go-logr/logr"
 	sdkhandler "github.com/operator-framework/operator-lib/handler"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/postrender"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -24,6 +28,58 @@ import (
 // obj represents the custom resource that is being reconciled.
 type PostRendererProvider func(rm meta.RESTMapper, kubeClient kube.Interface, obj client.Object) postrender.PostRenderer
 
+// PostRendererAction is the action whose manifests are post-rendered.
+type PostRendererAction string
+
+const (
+	PostRendererActionInstall PostRendererAction = "Install"
+	PostRendererActionUpgrade PostRendererAction = "Upgrade"
+)
+
+// PostRendererContext is the context of the post-rendering of the manifests
+// of an install or upgrade.
+type PostRendererContext struct {
+	// Context is the context of the reconciliation, which post-renderers
+	// should use for requests, since Helm does not pass one to them.
+	Context context.Context
+
+	RESTMapper meta.RESTMapper
+	KubeClient kube.Interface
+
+	// Owner is the custom resource that is being reconciled.
+	Owner client.Object
+
+	// Action is the action whose manifests are post-rendered. DryRun is true
+	// if the action is a dry run, e.g. the dry-run upgrade with which the
+	// reconciler determines whether a release needs to be upgraded. Dry runs
+	// should be post-rendered like the actual install or upgrade, but should
+	// avoid side effects.
+	Action PostRendererAction
+	DryRun bool
+
+	ReleaseName string
+	Namespace   string
+	Chart       *chart.Metadata
+
+	// Values are the values of the release coalesced with the values of the
+	// chart.
+	Values chartutil.Values
+
+	Log logr.Logger
+}
+
+// ContextPostRendererProvider is a function that returns a post-renderer
+// for the install or upgrade described by prc.
+type ContextPostRendererProvider func(prc PostRendererContext) postrender.PostRenderer
+
+// ContextPostRendererProviderFor adapts a PostRendererProvider to a
+// ContextPostRendererProvider.
+func ContextPostRendererProviderFor(provider PostRendererProvider) ContextPostRendererProvider {
+	return func(prc PostRendererContext) postrender.PostRenderer {
+		return provider(prc.RESTMapper, prc.KubeClient, prc.Owner)
+	}
+}
+
 // WithInstallPostRenderer sets the post-renderer to use for the install.
 // It overrides any post-renderer that may already be configured or set
 // as a default.
@@ -92,6 +148,26 @@ var DefaultPostRendererFunc = func(rm meta.RESTMapper, kubeClient kube.Interface
 	return &ownerPostRenderer{rm, kubeClient, owner}
 }
 
+// contextPostRenderer runs the post-renderers of providers for the context
+// returned by context. The post-renderers are created when it is run, i.e.
+// after all options of the action are applied.
+type contextPostRenderer struct {
+	providers []ContextPostRendererProvider
+	context   func() (PostRendererContext, error)
+}
+
+func (pr contextPostRenderer) Run(in *bytes.Buffer) (*bytes.Buffer, error) {
+	prc, err := pr.context()
+	if err != nil {
+		return nil, err
+	}
+	cpr := make(chainedPostRenderer, 0, len(pr.providers))
+	for _, provider := range pr.providers {
+		cpr = append(cpr, provider(prc))
+	}
+	return cpr.Run(in)
+}
+
 type chainedPostRenderer []postrender.PostRenderer
 
 func (prs chainedPostRenderer) Run(in *bytes.Buffer) (*bytes.Buffer, error) {
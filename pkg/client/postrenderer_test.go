@@ -9,6 +9,7 @@ import (
 	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/postrender"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/rest"
@@ -70,6 +71,46 @@ var _ = Describe("chainedPostRenderer", func() {
 	})
 })
 
+var _ = Describe("contextPostRenderer", func() {
+	It("creates the postrenderers with the context when it is run", func() {
+		var (
+			act      = PostRendererActionInstall
+			contexts []PostRendererContext
+		)
+		provider := func(prc PostRendererContext) postrender.PostRenderer {
+			contexts = append(contexts, prc)
+			return PostRendererFunc(func(in *bytes.Buffer) (*bytes.Buffer, error) {
+				in.WriteString(string(prc.Action) + "\n")
+				return in, nil
+			})
+		}
+		pr := contextPostRenderer{
+			providers: []ContextPostRendererProvider{provider, provider},
+			context: func() (PostRendererContext, error) {
+				return PostRendererContext{Action: act}, nil
+			},
+		}
+		Expect(contexts).To(BeEmpty())
+
+		act = PostRendererActionUpgrade
+		out, err := pr.Run(bytes.NewBufferString("original\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out.String()).To(Equal("original\nUpgrade\nUpgrade\n"))
+		Expect(contexts).To(HaveLen(2))
+	})
+
+	It("adapts postrenderer providers", func() {
+		owner := &corev1.ConfigMap{}
+		var actualOwner client.Object
+		provider := ContextPostRendererProviderFor(func(_ meta.RESTMapper, _ kube.Interface, obj client.Object) postrender.PostRenderer {
+			actualOwner = obj
+			return nil
+		})
+		provider(PostRendererContext{Owner: owner})
+		Expect(actualOwner).To(BeIdenticalTo(owner))
+	})
+})
+
 var _ = Describe("PostRender install options", func() {
 	var (
 		install *action.Install
@@ -20,6 +20,8 @@ import (
 	"reflect"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	crtpredicate "sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -79,3 +81,13 @@ func DependentPredicateFuncs() crtpredicate.TypedFuncs[*unstructured.Unstructure
 
 	return dependentPredicate
 }
+
+// NamespacePredicate returns a predicate that only accepts events for
+// objects in one of the given namespaces. Events for cluster-scoped objects
+// are always accepted.
+func NamespacePredicate[T client.Object](namespaces ...string) crtpredicate.TypedPredicate[T] {
+	set := sets.New(namespaces...)
+	return crtpredicate.NewTypedPredicateFuncs(func(o T) bool {
+		return o.GetNamespace() == "" || set.Has(o.GetNamespace())
+	})
+}
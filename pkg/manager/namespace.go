@@ -17,16 +17,21 @@ limitations under the License.
 package manager
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
 	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 const (
-	WatchNamespaceEnvVar = "WATCH_NAMESPACE"
+	WatchNamespaceEnvVar         = "WATCH_NAMESPACE"
+	WatchNamespaceSelectorEnvVar = "WATCH_NAMESPACE_SELECTOR"
 )
 
 func ConfigureWatchNamespaces(options *manager.Options, log logr.Logger) {
@@ -46,6 +51,82 @@ func ConfigureWatchNamespaces(options *manager.Options, log logr.Logger) {
 	options.Cache.DefaultNamespaces = namespaceConfigs
 }
 
+// ConfigureWatchNamespaceSelector configures the manager to watch all
+// namespaces matching the label selector in the WATCH_NAMESPACE_SELECTOR
+// environment variable, e.g. "helm-operator/enabled=true". Namespaces that are
+// created, labeled or unlabeled while the manager is running are picked up
+// without a restart. If the variable is not set, options are left unchanged.
+//
+// WATCH_NAMESPACE_SELECTOR cannot be combined with WATCH_NAMESPACE.
+func ConfigureWatchNamespaceSelector(options *manager.Options, log logr.Logger) error {
+	s := strings.TrimSpace(os.Getenv(WatchNamespaceSelectorEnvVar))
+	if s == "" {
+		return nil
+	}
+	if len(options.Cache.DefaultNamespaces) > 0 {
+		return fmt.Errorf("%s and %s are mutually exclusive", WatchNamespaceEnvVar, WatchNamespaceSelectorEnvVar)
+	}
+
+	selector, err := labels.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", WatchNamespaceSelectorEnvVar, err)
+	}
+
+	log.Info("watching namespaces matching selector", "selector", selector.String())
+	options.NewCache = NewDynamicNamespaceCacheFunc(selector, log)
+	return nil
+}
+
+// ConfigureObjectNamespaces limits the cache for objects of the kind of obj to
+// the given namespaces, overriding the namespaces configured by
+// ConfigureWatchNamespaces for that kind. obj must have its
+// GroupVersionKind set if it is an unstructured object. If namespaces is
+// empty, options are left unchanged.
+func ConfigureObjectNamespaces(options *manager.Options, obj client.Object, namespaces []string) {
+	if len(namespaces) == 0 {
+		return
+	}
+
+	namespaceConfigs := make(map[string]cache.Config, len(namespaces))
+	for _, namespace := range namespaces {
+		namespaceConfigs[namespace] = cache.Config{}
+	}
+
+	if options.Cache.ByObject == nil {
+		options.Cache.ByObject = make(map[client.Object]cache.ByObject)
+	}
+	byObject := options.Cache.ByObject[obj]
+	byObject.Namespaces = namespaceConfigs
+	options.Cache.ByObject[obj] = byObject
+}
+
+// NewNamespaceSelectorCache creates a cache for all namespaces that match
+// selector and adds it to mgr, which starts it. Like the cache configured by
+// ConfigureWatchNamespaceSelector, it follows namespaces as they start or stop
+// matching the selector. The selector may match no namespace at all.
+//
+// It is meant to be used by a single reconciler, which is then restricted to
+// the namespaces matching selector, while the manager's cache is left as is.
+func NewNamespaceSelectorCache(mgr manager.Manager, selector metav1.LabelSelector, log logr.Logger) (cache.Cache, error) {
+	sel, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewDynamicNamespaceCacheFunc(sel, log)(mgr.GetConfig(), cache.Options{
+		HTTPClient: mgr.GetHTTPClient(),
+		Scheme:     mgr.GetScheme(),
+		Mapper:     mgr.GetRESTMapper(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 func splitNamespaces(namespaces string) []string {
 	list := strings.Split(namespaces, ",")
 	var out []string
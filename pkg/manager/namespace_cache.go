@@ -0,0 +1,606 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// NewDynamicNamespaceCacheFunc returns a cache.NewCacheFunc that creates a
+// cache for all namespaces that match selector. Namespaces that start or stop
+// matching the selector while the cache is running are added to or removed
+// from the cache, without restarting it.
+//
+// Namespaced objects are cached per namespace. Event handlers and indexers
+// added to an informer of the cache are also added to the informers of
+// namespaces that are added later. When a namespace is removed, its
+// informers are stopped without sending delete events for the objects in it.
+//
+// Namespaces configured in cache.Options.DefaultNamespaces and
+// cache.Options.ByObject are ignored.
+func NewDynamicNamespaceCacheFunc(selector labels.Selector, log logr.Logger) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		if opts.Scheme == nil || opts.Mapper == nil {
+			return nil, errors.New("dynamic namespace cache requires a scheme and a REST mapper")
+		}
+
+		opts.DefaultNamespaces = nil
+		opts.ByObject = maps.Clone(opts.ByObject)
+		for obj, byObject := range opts.ByObject {
+			byObject.Namespaces = nil
+			opts.ByObject[obj] = byObject
+		}
+
+		clusterOpts := opts
+		clusterOpts.ByObject = maps.Clone(opts.ByObject)
+		if clusterOpts.ByObject == nil {
+			clusterOpts.ByObject = map[client.Object]cache.ByObject{}
+		}
+		clusterOpts.ByObject[&corev1.Namespace{}] = cache.ByObject{Label: selector}
+		clusterCache, err := cache.New(config, clusterOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &dynamicNamespaceCache{
+			config:       config,
+			opts:         opts,
+			log:          log.WithName("dynamic-namespace-cache"),
+			clusterCache: clusterCache,
+			newCache:     cache.New,
+			namespaces:   map[string]*namespacedCache{},
+			informers:    map[informerKey]*dynamicInformer{},
+		}, nil
+	}
+}
+
+type namespacedCache struct {
+	cache.Cache
+	cancel context.CancelFunc
+}
+
+type informerKey struct {
+	gvk     schema.GroupVersionKind
+	objType string
+}
+
+type indexField struct {
+	obj          client.Object
+	field        string
+	extractValue client.IndexerFunc
+}
+
+type dynamicNamespaceCache struct {
+	config       *rest.Config
+	opts         cache.Options
+	log          logr.Logger
+	clusterCache cache.Cache
+	newCache     cache.NewCacheFunc
+
+	mu         sync.RWMutex
+	ctx        context.Context
+	namespaces map[string]*namespacedCache
+	informers  map[informerKey]*dynamicInformer
+	indexes    []indexField
+}
+
+var _ cache.Cache = &dynamicNamespaceCache{}
+
+func (c *dynamicNamespaceCache) Start(ctx context.Context) error {
+	nsInformer, err := c.clusterCache.GetInformer(ctx, &corev1.Namespace{}, cache.BlockUntilSynced(false))
+	if err != nil {
+		return err
+	}
+	if _, err := nsInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				c.addNamespace(ns.Name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				c.removeNamespace(ns.Name)
+			}
+		},
+	}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.ctx = ctx
+	for ns, nsCache := range c.namespaces {
+		c.startNamespacedCache(ns, nsCache)
+	}
+	c.mu.Unlock()
+
+	return c.clusterCache.Start(ctx)
+}
+
+func (c *dynamicNamespaceCache) WaitForCacheSync(ctx context.Context) bool {
+	if !c.clusterCache.WaitForCacheSync(ctx) {
+		return false
+	}
+	c.mu.RLock()
+	caches := make([]cache.Cache, 0, len(c.namespaces))
+	for _, nsCache := range c.namespaces {
+		caches = append(caches, nsCache)
+	}
+	c.mu.RUnlock()
+
+	for _, nsCache := range caches {
+		if !nsCache.WaitForCacheSync(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *dynamicNamespaceCache) addNamespace(ns string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.namespaces[ns]; ok {
+		return
+	}
+
+	opts := c.opts
+	opts.DefaultNamespaces = map[string]cache.Config{ns: {}}
+	newCache, err := c.newCache(c.config, opts)
+	if err != nil {
+		c.log.Error(err, "Failed to create cache for namespace", "namespace", ns)
+		return
+	}
+
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, idx := range c.indexes {
+		if err := newCache.IndexField(ctx, idx.obj, idx.field, idx.extractValue); err != nil {
+			c.log.Error(err, "Failed to add index to cache for namespace", "namespace", ns, "field", idx.field)
+			return
+		}
+	}
+	for _, inf := range c.informers {
+		i, err := newCache.GetInformer(ctx, inf.obj, cache.BlockUntilSynced(false))
+		if err != nil {
+			c.log.Error(err, "Failed to create informer for namespace", "namespace", ns, "gvk", inf.gvk)
+			return
+		}
+		if err := inf.addNamespace(ns, i); err != nil {
+			c.log.Error(err, "Failed to configure informer for namespace", "namespace", ns, "gvk", inf.gvk)
+			return
+		}
+	}
+
+	nsCache := &namespacedCache{Cache: newCache}
+	c.namespaces[ns] = nsCache
+	if c.ctx != nil {
+		c.startNamespacedCache(ns, nsCache)
+	}
+	c.log.Info("Watching namespace", "namespace", ns)
+}
+
+// startNamespacedCache must be called with c.mu held.
+func (c *dynamicNamespaceCache) startNamespacedCache(ns string, nsCache *namespacedCache) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	nsCache.cancel = cancel
+	go func() {
+		if err := nsCache.Start(ctx); err != nil {
+			c.log.Error(err, "Cache for namespace stopped", "namespace", ns)
+		}
+	}()
+}
+
+func (c *dynamicNamespaceCache) removeNamespace(ns string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nsCache, ok := c.namespaces[ns]
+	if !ok {
+		return
+	}
+	delete(c.namespaces, ns)
+	for _, inf := range c.informers {
+		inf.removeNamespace(ns)
+	}
+	if nsCache.cancel != nil {
+		nsCache.cancel()
+	}
+	c.log.Info("Stopped watching namespace", "namespace", ns)
+}
+
+func (c *dynamicNamespaceCache) isNamespaced(obj runtime.Object) (bool, error) {
+	return apiutil.IsObjectNamespaced(obj, c.opts.Scheme, c.opts.Mapper)
+}
+
+func (c *dynamicNamespaceCache) GetInformer(ctx context.Context, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	isNamespaced, err := c.isNamespaced(obj)
+	if err != nil {
+		return nil, err
+	}
+	if !isNamespaced {
+		return c.clusterCache.GetInformer(ctx, obj, opts...)
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, c.opts.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	key := informerKey{gvk: gvk, objType: fmt.Sprintf("%T", obj)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if inf, ok := c.informers[key]; ok {
+		return inf, nil
+	}
+
+	inf := &dynamicInformer{
+		gvk:       gvk,
+		obj:       obj.DeepCopyObject().(client.Object),
+		informers: map[string]cache.Informer{},
+	}
+	for ns, nsCache := range c.namespaces {
+		i, err := nsCache.GetInformer(ctx, obj, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := inf.addNamespace(ns, i); err != nil {
+			return nil, err
+		}
+	}
+	c.informers[key] = inf
+	return inf, nil
+}
+
+func (c *dynamicNamespaceCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	obj, err := c.objectForKind(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetInformer(ctx, obj, opts...)
+}
+
+func (c *dynamicNamespaceCache) RemoveInformer(ctx context.Context, obj client.Object) error {
+	isNamespaced, err := c.isNamespaced(obj)
+	if err != nil {
+		return err
+	}
+	if !isNamespaced {
+		return c.clusterCache.RemoveInformer(ctx, obj)
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, c.opts.Scheme)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.informers, informerKey{gvk: gvk, objType: fmt.Sprintf("%T", obj)})
+	for _, nsCache := range c.namespaces {
+		if err := nsCache.RemoveInformer(ctx, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *dynamicNamespaceCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	isNamespaced, err := c.isNamespaced(obj)
+	if err != nil {
+		return err
+	}
+	if !isNamespaced {
+		return c.clusterCache.IndexField(ctx, obj, field, extractValue)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, nsCache := range c.namespaces {
+		if err := nsCache.IndexField(ctx, obj, field, extractValue); err != nil {
+			return err
+		}
+	}
+	c.indexes = append(c.indexes, indexField{obj: obj, field: field, extractValue: extractValue})
+	return nil
+}
+
+func (c *dynamicNamespaceCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	isNamespaced, err := c.isNamespaced(obj)
+	if err != nil {
+		return err
+	}
+	if !isNamespaced {
+		return c.clusterCache.Get(ctx, key, obj, opts...)
+	}
+
+	c.mu.RLock()
+	nsCache, ok := c.namespaces[key.Namespace]
+	c.mu.RUnlock()
+	if !ok {
+		// Objects in namespaces that are not watched (anymore) are treated
+		// as not found, so that pending reconciliations for them end.
+		gvk, err := apiutil.GVKForObject(obj, c.opts.Scheme)
+		if err != nil {
+			return err
+		}
+		mapping, err := c.opts.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return err
+		}
+		return apierrors.NewNotFound(mapping.Resource.GroupResource(), key.Name)
+	}
+	return nsCache.Get(ctx, key, obj, opts...)
+}
+
+func (c *dynamicNamespaceCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	isNamespaced, err := c.isNamespaced(list)
+	if err != nil {
+		return err
+	}
+	if !isNamespaced {
+		return c.clusterCache.List(ctx, list, opts...)
+	}
+
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	c.mu.RLock()
+	var caches []cache.Cache
+	if listOpts.Namespace != corev1.NamespaceAll {
+		if nsCache, ok := c.namespaces[listOpts.Namespace]; ok {
+			caches = append(caches, nsCache)
+		}
+	} else {
+		for _, nsCache := range c.namespaces {
+			caches = append(caches, nsCache)
+		}
+	}
+	c.mu.RUnlock()
+
+	listAccessor, err := apimeta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+
+	var (
+		allItems        []runtime.Object
+		resourceVersion string
+	)
+	for _, nsCache := range caches {
+		listObj := list.DeepCopyObject().(client.ObjectList)
+		if err := nsCache.List(ctx, listObj, &listOpts); err != nil {
+			return err
+		}
+		items, err := apimeta.ExtractList(listObj)
+		if err != nil {
+			return err
+		}
+		accessor, err := apimeta.ListAccessor(listObj)
+		if err != nil {
+			return fmt.Errorf("object: %T must be a list type", list)
+		}
+		allItems = append(allItems, items...)
+		resourceVersion = accessor.GetResourceVersion()
+
+		if listOpts.Limit > 0 {
+			listOpts.Limit -= int64(len(items))
+			if listOpts.Limit <= 0 {
+				break
+			}
+		}
+	}
+	listAccessor.SetResourceVersion(resourceVersion)
+	return apimeta.SetList(list, allItems)
+}
+
+func (c *dynamicNamespaceCache) objectForKind(gvk schema.GroupVersionKind) (client.Object, error) {
+	if c.opts.Scheme.Recognizes(gvk) {
+		obj, err := c.opts.Scheme.New(gvk)
+		if err != nil {
+			return nil, err
+		}
+		if cObj, ok := obj.(client.Object); ok {
+			return cObj, nil
+		}
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj, nil
+}
+
+// dynamicInformer fans out to the informers of all cached namespaces. Event
+// handlers and indexers are recorded, so that they can be added to the
+// informers of namespaces that are added later.
+type dynamicInformer struct {
+	gvk schema.GroupVersionKind
+	obj client.Object
+
+	mu            sync.Mutex
+	informers     map[string]cache.Informer
+	registrations []*dynamicRegistration
+	indexers      []toolscache.Indexers
+}
+
+var _ cache.Informer = &dynamicInformer{}
+
+type dynamicRegistration struct {
+	handler      toolscache.ResourceEventHandler
+	resyncPeriod *time.Duration
+
+	mu      sync.Mutex
+	handles map[string]toolscache.ResourceEventHandlerRegistration
+}
+
+func (r *dynamicRegistration) HasSynced() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, h := range r.handles {
+		if !h.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *dynamicRegistration) add(ns string, informer cache.Informer) error {
+	var (
+		h   toolscache.ResourceEventHandlerRegistration
+		err error
+	)
+	if r.resyncPeriod != nil {
+		h, err = informer.AddEventHandlerWithResyncPeriod(r.handler, *r.resyncPeriod)
+	} else {
+		h, err = informer.AddEventHandler(r.handler)
+	}
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.handles[ns] = h
+	r.mu.Unlock()
+	return nil
+}
+
+func (i *dynamicInformer) addNamespace(ns string, informer cache.Informer) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, indexers := range i.indexers {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	for _, r := range i.registrations {
+		if err := r.add(ns, informer); err != nil {
+			return err
+		}
+	}
+	i.informers[ns] = informer
+	return nil
+}
+
+func (i *dynamicInformer) removeNamespace(ns string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.informers, ns)
+	for _, r := range i.registrations {
+		r.mu.Lock()
+		delete(r.handles, ns)
+		r.mu.Unlock()
+	}
+}
+
+func (i *dynamicInformer) addEventHandler(handler toolscache.ResourceEventHandler, resyncPeriod *time.Duration) (toolscache.ResourceEventHandlerRegistration, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	r := &dynamicRegistration{
+		handler:      handler,
+		resyncPeriod: resyncPeriod,
+		handles:      make(map[string]toolscache.ResourceEventHandlerRegistration, len(i.informers)),
+	}
+	for ns, informer := range i.informers {
+		if err := r.add(ns, informer); err != nil {
+			return nil, err
+		}
+	}
+	i.registrations = append(i.registrations, r)
+	return r, nil
+}
+
+func (i *dynamicInformer) AddEventHandler(handler toolscache.ResourceEventHandler) (toolscache.ResourceEventHandlerRegistration, error) {
+	return i.addEventHandler(handler, nil)
+}
+
+func (i *dynamicInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) (toolscache.ResourceEventHandlerRegistration, error) {
+	return i.addEventHandler(handler, &resyncPeriod)
+}
+
+func (i *dynamicInformer) RemoveEventHandler(handle toolscache.ResourceEventHandlerRegistration) error {
+	r, ok := handle.(*dynamicRegistration)
+	if !ok {
+		return errors.New("registration is not a registration returned by the dynamic namespace cache")
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for idx, reg := range i.registrations {
+		if reg == r {
+			i.registrations = append(i.registrations[:idx], i.registrations[idx+1:]...)
+			break
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ns, h := range r.handles {
+		if informer, ok := i.informers[ns]; ok {
+			if err := informer.RemoveEventHandler(h); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (i *dynamicInformer) AddIndexers(indexers toolscache.Indexers) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, informer := range i.informers {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	i.indexers = append(i.indexers, indexers)
+	return nil
+}
+
+func (i *dynamicInformer) HasSynced() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, informer := range i.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsStopped always returns false, since informers for new namespaces may be
+// added as long as the cache is running.
+func (i *dynamicInformer) IsStopped() bool {
+	return false
+}
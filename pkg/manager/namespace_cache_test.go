@@ -0,0 +1,119 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+var _ = Describe("dynamicInformer", func() {
+	var (
+		inf     *dynamicInformer
+		handler toolscache.ResourceEventHandlerFuncs
+	)
+
+	BeforeEach(func() {
+		inf = &dynamicInformer{informers: map[string]cache.Informer{}}
+		handler = toolscache.ResourceEventHandlerFuncs{}
+	})
+
+	It("should add recorded handlers and indexers to informers of new namespaces", func() {
+		existing := &fakeInformer{}
+		Expect(inf.addNamespace("ns1", existing)).To(Succeed())
+
+		Expect(inf.AddIndexers(toolscache.Indexers{"idx": nil})).To(Succeed())
+		reg, err := inf.AddEventHandler(handler)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(existing.handlers).To(Equal(1))
+		Expect(existing.indexers).To(Equal(1))
+
+		added := &fakeInformer{}
+		Expect(inf.addNamespace("ns2", added)).To(Succeed())
+		Expect(added.handlers).To(Equal(1))
+		Expect(added.indexers).To(Equal(1))
+
+		By("reporting the sync state of all namespaces")
+		Expect(reg.HasSynced()).To(BeFalse())
+		Expect(inf.HasSynced()).To(BeFalse())
+		existing.synced, added.synced = true, true
+		Expect(reg.HasSynced()).To(BeTrue())
+		Expect(inf.HasSynced()).To(BeTrue())
+
+		By("removing the handler from all namespaces")
+		Expect(inf.RemoveEventHandler(reg)).To(Succeed())
+		Expect(existing.handlers).To(Equal(0))
+		Expect(added.handlers).To(Equal(0))
+
+		By("not adding removed handlers to new namespaces")
+		other := &fakeInformer{}
+		Expect(inf.addNamespace("ns3", other)).To(Succeed())
+		Expect(other.handlers).To(Equal(0))
+	})
+
+	It("should forget removed namespaces", func() {
+		removed := &fakeInformer{}
+		Expect(inf.addNamespace("ns1", removed)).To(Succeed())
+		reg, err := inf.AddEventHandlerWithResyncPeriod(handler, time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+
+		inf.removeNamespace("ns1")
+		Expect(inf.HasSynced()).To(BeTrue())
+		Expect(reg.HasSynced()).To(BeTrue())
+		Expect(inf.RemoveEventHandler(reg)).To(Succeed())
+		Expect(removed.handlers).To(Equal(1))
+	})
+})
+
+type fakeInformer struct {
+	handlers int
+	indexers int
+	synced   bool
+}
+
+type fakeRegistration struct {
+	informer *fakeInformer
+}
+
+func (r fakeRegistration) HasSynced() bool { return r.informer.synced }
+
+func (f *fakeInformer) AddEventHandler(toolscache.ResourceEventHandler) (toolscache.ResourceEventHandlerRegistration, error) {
+	f.handlers++
+	return fakeRegistration{informer: f}, nil
+}
+
+func (f *fakeInformer) AddEventHandlerWithResyncPeriod(h toolscache.ResourceEventHandler, _ time.Duration) (toolscache.ResourceEventHandlerRegistration, error) {
+	return f.AddEventHandler(h)
+}
+
+func (f *fakeInformer) RemoveEventHandler(toolscache.ResourceEventHandlerRegistration) error {
+	f.handlers--
+	return nil
+}
+
+func (f *fakeInformer) AddIndexers(toolscache.Indexers) error {
+	f.indexers++
+	return nil
+}
+
+func (f *fakeInformer) HasSynced() bool { return f.synced }
+
+func (f *fakeInformer) IsStopped() bool { return false }
@@ -27,9 +27,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
@@ -172,6 +174,193 @@ var _ = Describe("ConfigureWatchNamespaces", func() {
 	})
 })
 
+var _ = Describe("ConfigureWatchNamespaceSelector", func() {
+	var log = logr.Discard()
+
+	AfterEach(func() {
+		Expect(os.Unsetenv(WatchNamespaceSelectorEnvVar)).To(Succeed())
+	})
+
+	It("should leave options unchanged when WATCH_NAMESPACE_SELECTOR is not set", func() {
+		opts := manager.Options{}
+		Expect(ConfigureWatchNamespaceSelector(&opts, log)).To(Succeed())
+		Expect(opts.NewCache).To(BeNil())
+	})
+
+	It("should fail for an invalid selector", func() {
+		Expect(os.Setenv(WatchNamespaceSelectorEnvVar, "a in (")).To(Succeed())
+		opts := manager.Options{}
+		Expect(ConfigureWatchNamespaceSelector(&opts, log)).NotTo(Succeed())
+	})
+
+	It("should fail when combined with WATCH_NAMESPACE", func() {
+		Expect(os.Setenv(WatchNamespaceSelectorEnvVar, "a=b")).To(Succeed())
+		opts := manager.Options{}
+		opts.Cache.DefaultNamespaces = map[string]cache.Config{"ns": {}}
+		Expect(ConfigureWatchNamespaceSelector(&opts, log)).To(MatchError(ContainSubstring("mutually exclusive")))
+	})
+
+	It("should watch namespaces as they start and stop matching the selector", func() {
+		cl, err := client.New(cfg, client.Options{})
+		Expect(err).ToNot(HaveOccurred())
+
+		label := fmt.Sprintf("enabled-%s", rand.String(5))
+		Expect(os.Setenv(WatchNamespaceSelectorEnvVar, label+"=true")).To(Succeed())
+
+		By("creating pods in namespaces")
+		pods, err := createPods(context.TODO(), 2)
+		Expect(err).ToNot(HaveOccurred())
+		watchedPod, unwatchedPod := pods[0], pods[1]
+		setNamespaceLabel(cl, watchedPod.Namespace, label, "true")
+
+		By("creating the manager")
+		opts := manager.Options{}
+		Expect(ConfigureWatchNamespaceSelector(&opts, log)).To(Succeed())
+		mgr, err := manager.New(cfg, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("starting the manager")
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			Expect(mgr.Start(ctx)).To(Succeed())
+			wg.Done()
+		}()
+		c := mgr.GetCache()
+		Expect(c.WaitForCacheSync(ctx)).To(BeTrue())
+
+		By("getting the pod in the matching namespace")
+		Eventually(func() error {
+			return c.Get(ctx, client.ObjectKeyFromObject(&watchedPod), &corev1.Pod{})
+		}).Should(Succeed())
+
+		By("failing to get the pod in the other namespace")
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(&unwatchedPod), &corev1.Pod{})).To(MatchError(apierrors.IsNotFound, "IsNotFound"))
+
+		By("labeling the other namespace")
+		setNamespaceLabel(cl, unwatchedPod.Namespace, label, "true")
+		Eventually(func() error {
+			return c.Get(ctx, client.ObjectKeyFromObject(&unwatchedPod), &corev1.Pod{})
+		}).Should(Succeed())
+
+		By("unlabeling the first namespace")
+		setNamespaceLabel(cl, watchedPod.Namespace, label, "false")
+		Eventually(func() error {
+			return c.Get(ctx, client.ObjectKeyFromObject(&watchedPod), &corev1.Pod{})
+		}).Should(MatchError(apierrors.IsNotFound, "IsNotFound"))
+
+		cancel()
+		wg.Wait()
+	})
+})
+
+var _ = Describe("ConfigureObjectNamespaces", func() {
+	It("should only cache objects of the kind in the given namespaces", func() {
+		By("creating pods in watched namespaces")
+		watchedPods, err := createPods(context.TODO(), 2)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("creating pods in unwatched namespaces")
+		unwatchedPods, err := createPods(context.TODO(), 2)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("configuring the pod namespaces")
+		opts := manager.Options{}
+		ConfigureObjectNamespaces(&opts, &corev1.Pod{}, getNamespaces(watchedPods))
+
+		By("creating the manager")
+		mgr, err := manager.New(cfg, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("starting the manager")
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			Expect(mgr.Start(ctx)).To(Succeed())
+			wg.Done()
+		}()
+
+		By("waiting for the cache to sync")
+		c := mgr.GetCache()
+		Expect(c.WaitForCacheSync(ctx)).To(BeTrue())
+
+		By("successfully getting the watched pods")
+		for i := range watchedPods {
+			p := watchedPods[i]
+			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(&p), &p)).To(Succeed())
+		}
+
+		By("failing to get the unwatched pods")
+		for i := range unwatchedPods {
+			p := unwatchedPods[i]
+			Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(&p), &p)).NotTo(Succeed())
+		}
+		cancel()
+		wg.Wait()
+	})
+
+	It("should leave options unchanged without namespaces", func() {
+		opts := manager.Options{}
+		ConfigureObjectNamespaces(&opts, &corev1.Pod{}, nil)
+		Expect(opts.Cache.ByObject).To(BeNil())
+	})
+})
+
+var _ = Describe("NewNamespaceSelectorCache", func() {
+	var log = logr.Discard()
+
+	It("should fail for an invalid selector", func() {
+		mgr, err := manager.New(cfg, manager.Options{})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = NewNamespaceSelectorCache(mgr, metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "a", Operator: "Unknown"}}}, log)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should cache the namespaces matching the selector while the manager runs", func() {
+		cl, err := client.New(cfg, client.Options{})
+		Expect(err).ToNot(HaveOccurred())
+
+		label := fmt.Sprintf("tenant-%s", rand.String(5))
+
+		By("creating pods in namespaces")
+		pods, err := createPods(context.TODO(), 2)
+		Expect(err).ToNot(HaveOccurred())
+		watchedPod, unwatchedPod := pods[0], pods[1]
+
+		By("creating the cache before any namespace matches the selector")
+		mgr, err := manager.New(cfg, manager.Options{})
+		Expect(err).ToNot(HaveOccurred())
+		c, err := NewNamespaceSelectorCache(mgr, metav1.LabelSelector{MatchLabels: map[string]string{label: "true"}}, log)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("starting the manager")
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			Expect(mgr.Start(ctx)).To(Succeed())
+			wg.Done()
+		}()
+		Expect(c.WaitForCacheSync(ctx)).To(BeTrue())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(&watchedPod), &corev1.Pod{})).To(MatchError(apierrors.IsNotFound, "IsNotFound"))
+
+		By("labeling a namespace")
+		setNamespaceLabel(cl, watchedPod.Namespace, label, "true")
+		Eventually(func() error {
+			return c.Get(ctx, client.ObjectKeyFromObject(&watchedPod), &corev1.Pod{})
+		}).Should(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(&unwatchedPod), &corev1.Pod{})).To(MatchError(apierrors.IsNotFound, "IsNotFound"))
+
+		By("leaving the cache of the manager unrestricted")
+		Expect(mgr.GetCache().Get(ctx, client.ObjectKeyFromObject(&unwatchedPod), &corev1.Pod{})).To(Succeed())
+
+		cancel()
+		wg.Wait()
+	})
+})
+
 func createPods(ctx context.Context, count int) ([]corev1.Pod, error) {
 	cl, err := client.New(cfg, client.Options{})
 	if err != nil {
@@ -206,6 +395,13 @@ func createPods(ctx context.Context, count int) ([]corev1.Pod, error) {
 	return pods, nil
 }
 
+func setNamespaceLabel(cl client.Client, name, key, value string) {
+	ns := &corev1.Namespace{}
+	Expect(cl.Get(context.TODO(), client.ObjectKey{Name: name}, ns)).To(Succeed())
+	ns.Labels[key] = value
+	Expect(cl.Update(context.TODO(), ns)).To(Succeed())
+}
+
 func getNamespaces(objs []corev1.Pod) []string {
 	namespaces := sets.New[string]()
 	for _, obj := range objs {
@@ -0,0 +1,39 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifestutil_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/manifestutil"
+)
+
+var _ = Describe("PodSpecPath", func() {
+	DescribeTable("should return the path of the pod spec",
+		func(kind string, expected []string) {
+			path, ok := manifestutil.PodSpecPath(kind)
+			Expect(ok).To(Equal(expected != nil))
+			Expect(path).To(Equal(expected))
+		},
+		Entry("Pod", "Pod", []string{"spec"}),
+		Entry("Deployment", "Deployment", []string{"spec", "template", "spec"}),
+		Entry("Job", "Job", []string{"spec", "template", "spec"}),
+		Entry("CronJob", "CronJob", []string{"spec", "jobTemplate", "spec", "template", "spec"}),
+		Entry("ConfigMap", "ConfigMap", nil),
+	)
+})
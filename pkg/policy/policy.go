@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates the rendered manifests of releases before they are
+// installed or upgraded.
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Policy is an interface expected by the reconciler.WithPolicy option.
+//
+// Evaluate is called with the custom resource that is being reconciled and
+// the release that a dry run of its install or upgrade resulted in. The
+// manifest and the hooks of the release are rendered and post-rendered.
+type Policy interface {
+	Evaluate(ctx context.Context, obj *unstructured.Unstructured, rel *release.Release) (Decision, error)
+}
+
+// Func is a helper type for passing a function as a Policy.
+type Func func(context.Context, *unstructured.Unstructured, *release.Release) (Decision, error)
+
+func (f Func) Evaluate(ctx context.Context, obj *unstructured.Unstructured, rel *release.Release) (Decision, error) {
+	return f(ctx, obj, rel)
+}
+
+// Decision is the outcome of the evaluation of a policy. A release that is
+// not allowed is not installed or upgraded. The violations of an allowed
+// release are reported as warnings.
+type Decision struct {
+	Allowed    bool
+	Violations []Violation
+}
+
+// Allow returns a Decision that allows a release with the given violations.
+func Allow(violations ...Violation) Decision {
+	return Decision{Allowed: true, Violations: violations}
+}
+
+// Deny returns a Decision that denies a release because of the given
+// violations.
+func Deny(violations ...Violation) Decision {
+	return Decision{Allowed: false, Violations: violations}
+}
+
+// Violation describes an object of a release that violates a rule.
+type Violation struct {
+	// Rule is the name of the violated rule.
+	Rule string
+
+	// Object identifies the violating object, e.g. "Deployment default/web".
+	Object string
+
+	// Message describes the violation.
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Object == "" {
+		return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", v.Rule, v.Object, v.Message)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/distribution/reference"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/manifestutil"
+)
+
+// Names of the rules of Rules.
+const (
+	RuleNoPrivilegedContainers = "no-privileged-containers"
+	RuleResourceLimits         = "resource-limits"
+	RuleAllowedRegistries      = "allowed-registries"
+	RuleNoLatestTag            = "no-latest-tag"
+)
+
+// Rules is a Policy with common rules for the containers of the workloads of
+// a release, including its hooks.
+type Rules struct {
+	// DenyPrivileged denies privileged containers.
+	DenyPrivileged bool
+
+	// RequireResourceLimits requires CPU and memory limits for all
+	// containers.
+	RequireResourceLimits bool
+
+	// AllowedRegistries restricts the images of containers to the listed
+	// registries or repository prefixes, e.g. "registry.example.com" or
+	// "docker.io/library". Images without a registry are on "docker.io".
+	AllowedRegistries []string
+
+	// DenyLatestTag denies images that are neither pinned by a digest nor
+	// tagged with a tag other than "latest".
+	DenyLatestTag bool
+
+	// Audit allows releases with violations, so that they are only reported.
+	Audit bool
+}
+
+var _ Policy = Rules{}
+
+// Evaluate evaluates the rules for the manifest and the hooks of rel.
+func (r Rules) Evaluate(_ context.Context, _ *unstructured.Unstructured, rel *release.Release) (Decision, error) {
+	manifests := []string{rel.Manifest}
+	for _, h := range rel.Hooks {
+		manifests = append(manifests, h.Manifest)
+	}
+
+	var violations []Violation
+	for _, manifest := range manifests {
+		for _, doc := range releaseutil.SplitManifests(manifest) {
+			var obj unstructured.Unstructured
+			if err := yaml.Unmarshal([]byte(doc), &obj.Object); err != nil {
+				return Decision{}, fmt.Errorf("failed to parse manifest: %w", err)
+			}
+			if obj.Object == nil {
+				continue
+			}
+			violations = append(violations, r.evaluateObject(&obj)...)
+		}
+	}
+	if len(violations) > 0 && !r.Audit {
+		return Deny(violations...), nil
+	}
+	return Allow(violations...), nil
+}
+
+func (r Rules) evaluateObject(obj *unstructured.Unstructured) []Violation {
+	podSpec, ok := podSpecOf(obj)
+	if !ok {
+		return nil
+	}
+	object := fmt.Sprintf("%s %s", obj.GetKind(), obj.GetName())
+	if obj.GetNamespace() != "" {
+		object = fmt.Sprintf("%s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	}
+
+	var violations []Violation
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(podSpec, field)
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			violate := func(rule, format string, args ...interface{}) {
+				violations = append(violations, Violation{
+					Rule:    rule,
+					Object:  object,
+					Message: fmt.Sprintf("container %q ", name) + fmt.Sprintf(format, args...),
+				})
+			}
+
+			if r.DenyPrivileged {
+				if privileged, _, _ := unstructured.NestedBool(container, "securityContext", "privileged"); privileged {
+					violate(RuleNoPrivilegedContainers, "is privileged")
+				}
+			}
+			if r.RequireResourceLimits {
+				limits, _, _ := unstructured.NestedMap(container, "resources", "limits")
+				for _, resource := range []string{"cpu", "memory"} {
+					if _, ok := limits[resource]; !ok {
+						violate(RuleResourceLimits, "has no %s limit", resource)
+					}
+				}
+			}
+			if len(r.AllowedRegistries) > 0 || r.DenyLatestTag {
+				image, _, _ := unstructured.NestedString(container, "image")
+				r.evaluateImage(image, violate)
+			}
+		}
+	}
+	return violations
+}
+
+// podSpecOf returns the pod spec of the workload obj.
+func podSpecOf(obj *unstructured.Unstructured) (map[string]interface{}, bool) {
+	path, ok := manifestutil.PodSpecPath(obj.GetKind())
+	if !ok {
+		return nil, false
+	}
+	spec, ok, _ := unstructured.NestedMap(obj.Object, path...)
+	return spec, ok
+}
+
+// evaluateImage reports the image rule violations of image. Images that
+// cannot be parsed violate every enabled image rule.
+func (r Rules) evaluateImage(image string, violate func(rule, format string, args ...interface{})) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		if len(r.AllowedRegistries) > 0 {
+			violate(RuleAllowedRegistries, "uses invalid image %q: %v", image, err)
+		}
+		if r.DenyLatestTag {
+			violate(RuleNoLatestTag, "uses invalid image %q: %v", image, err)
+		}
+		return
+	}
+
+	allowedBy := func(allowed string) bool {
+		allowed = strings.TrimSuffix(allowed, "/")
+		return named.Name() == allowed || strings.HasPrefix(named.Name(), allowed+"/")
+	}
+	if len(r.AllowedRegistries) > 0 && !slices.ContainsFunc(r.AllowedRegistries, allowedBy) {
+		violate(RuleAllowedRegistries, "uses image %q from a registry that is not allowed", image)
+	}
+	if _, digested := named.(reference.Digested); r.DenyLatestTag && !digested {
+		if tagged, ok := reference.TagNameOnly(named).(reference.Tagged); ok && tagged.Tag() == "latest" {
+			violate(RuleNoLatestTag, "uses image %q without a tag other than latest", image)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/release"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/policy"
+)
+
+const deployment = `---
+# Source: test/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox
+      containers:
+      - name: web
+        image: registry.example.com/web:1.0.0
+        securityContext:
+          privileged: true
+        resources:
+          limits:
+            cpu: 100m
+`
+
+const configMap = `---
+# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: busybox
+`
+
+const hook = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: quay.io/example/cleanup@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+            resources:
+              limits:
+                cpu: 100m
+                memory: 64Mi
+`
+
+var _ = Describe("Rules", func() {
+	var rel *release.Release
+
+	BeforeEach(func() {
+		rel = &release.Release{
+			Manifest: deployment + configMap,
+			Hooks:    []*release.Hook{{Manifest: hook}},
+		}
+	})
+
+	It("should allow any release without rules", func() {
+		decision, err := policy.Rules{}.Evaluate(context.Background(), nil, rel)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decision).To(Equal(policy.Allow()))
+	})
+
+	It("should deny privileged containers", func() {
+		decision, err := policy.Rules{DenyPrivileged: true}.Evaluate(context.Background(), nil, rel)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decision).To(Equal(policy.Deny(policy.Violation{
+			Rule:    policy.RuleNoPrivilegedContainers,
+			Object:  "Deployment default/web",
+			Message: `container "web" is privileged`,
+		})))
+	})
+
+	It("should require resource limits", func() {
+		decision, err := policy.Rules{RequireResourceLimits: true}.Evaluate(context.Background(), nil, rel)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decision.Allowed).To(BeFalse())
+		Expect(decision.Violations).To(HaveEach(HaveField("Rule", policy.RuleResourceLimits)))
+		Expect(decision.Violations).To(ConsistOf(
+			HaveField("Message", `container "init" has no cpu limit`),
+			HaveField("Message", `container "init" has no memory limit`),
+			HaveField("Message", `container "web" has no memory limit`),
+		))
+	})
+
+	DescribeTable("should restrict images to allowed registries",
+		func(allowed []string, denied ...string) {
+			decision, err := policy.Rules{AllowedRegistries: allowed}.Evaluate(context.Background(), nil, rel)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decision.Allowed).To(Equal(len(denied) == 0))
+			var messages []string
+			for _, v := range decision.Violations {
+				Expect(v.Rule).To(Equal(policy.RuleAllowedRegistries))
+				messages = append(messages, v.Message)
+			}
+			Expect(messages).To(ConsistOf(denied))
+		},
+		Entry("all registries allowed", []string{"docker.io", "registry.example.com", "quay.io"}),
+		Entry("official images allowed", []string{"docker.io/library", "registry.example.com/", "quay.io/example"}),
+		Entry("docker hub denied", []string{"registry.example.com", "quay.io"},
+			`container "init" uses image "busybox" from a registry that is not allowed`),
+		Entry("registry prefixes are not name prefixes", []string{"docker.io", "registry.example", "quay.io/examples"},
+			`container "web" uses image "registry.example.com/web:1.0.0" from a registry that is not allowed`,
+			`container "cleanup" uses image "quay.io/example/cleanup@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" from a registry that is not allowed`),
+	)
+
+	It("should deny images without a tag other than latest", func() {
+		rel.Manifest += `---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: latest
+spec:
+  containers:
+  - name: latest
+    image: localhost:5000/app:latest
+`
+		decision, err := policy.Rules{DenyLatestTag: true}.Evaluate(context.Background(), nil, rel)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decision.Allowed).To(BeFalse())
+		Expect(decision.Violations).To(ConsistOf(
+			policy.Violation{
+				Rule:    policy.RuleNoLatestTag,
+				Object:  "Deployment default/web",
+				Message: `container "init" uses image "busybox" without a tag other than latest`,
+			},
+			policy.Violation{
+				Rule:    policy.RuleNoLatestTag,
+				Object:  "Pod latest",
+				Message: `container "latest" uses image "localhost:5000/app:latest" without a tag other than latest`,
+			},
+		))
+	})
+
+	It("should deny invalid images", func() {
+		rel.Manifest += `---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: invalid
+spec:
+  containers:
+  - name: invalid
+    image: Registry.example.com/App
+`
+		decision, err := policy.Rules{AllowedRegistries: []string{"docker.io", "registry.example.com", "quay.io"}, DenyLatestTag: true}.Evaluate(context.Background(), nil, rel)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decision.Allowed).To(BeFalse())
+		Expect(decision.Violations).To(ContainElements(
+			And(HaveField("Rule", policy.RuleAllowedRegistries), HaveField("Message", HavePrefix(`container "invalid" uses invalid image "Registry.example.com/App"`))),
+			And(HaveField("Rule", policy.RuleNoLatestTag), HaveField("Message", HavePrefix(`container "invalid" uses invalid image "Registry.example.com/App"`))),
+		))
+	})
+
+	It("should only report violations in audit mode", func() {
+		decision, err := policy.Rules{DenyPrivileged: true, Audit: true}.Evaluate(context.Background(), nil, rel)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decision.Allowed).To(BeTrue())
+		Expect(decision.Violations).To(HaveLen(1))
+	})
+
+	It("should fail for invalid manifests", func() {
+		rel.Manifest = "kind: [Pod"
+		_, err := policy.Rules{DenyPrivileged: true}.Evaluate(context.Background(), nil, rel)
+		Expect(err).To(MatchError(ContainSubstring("failed to parse manifest")))
+	})
+})
+
+var _ = Describe("Violation", func() {
+	It("should format the rule, object and message", func() {
+		Expect(policy.Violation{Rule: "rule", Object: "Pod p", Message: "message"}.String()).To(Equal("rule: Pod p: message"))
+		Expect(policy.Violation{Rule: "rule", Message: "message"}.String()).To(Equal("rule: message"))
+	})
+})
@@ -0,0 +1,200 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrenderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/distribution/reference"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/manifestutil"
+)
+
+// ImageMirror replaces the registry or repository prefix Source of images
+// with Mirror.
+type ImageMirror struct {
+	// Source is matched against the fully qualified repositories of images,
+	// e.g. "docker.io" matches "nginx", which is
+	// "docker.io/library/nginx", and "quay.io/example" matches
+	// "quay.io/example/app", but not "quay.io/examples/app".
+	Source string
+
+	// Mirror replaces Source, e.g. "mirror.example.com/docker.io" rewrites
+	// "nginx:1.27" to "mirror.example.com/docker.io/library/nginx:1.27".
+	Mirror string
+}
+
+// ImageRewriter is a post-renderer that rewrites the images of the containers
+// of pods and of the pod templates of workloads, i.e. of Deployments,
+// StatefulSets, DaemonSets, ReplicaSets, ReplicationControllers, Jobs and
+// CronJobs.
+type ImageRewriter struct {
+	mirrors []ImageMirror
+	digests map[string]string
+}
+
+// NewImageRewriter returns an ImageRewriter that rewrites images according to
+// the mirror with the longest matching Source, and pins them to the digests
+// in digests. The keys of digests are image references, e.g. "nginx:1.27",
+// and the values are digests, e.g. "sha256:...". Images are pinned by their
+// references before they are mirrored, and images without a tag by their
+// "latest" tag. Images that already have a digest are not pinned.
+func NewImageRewriter(mirrors []ImageMirror, digests map[string]string) (*ImageRewriter, error) {
+	r := &ImageRewriter{digests: make(map[string]string, len(digests))}
+	for _, m := range mirrors {
+		m.Source = strings.TrimSuffix(m.Source, "/")
+		m.Mirror = strings.TrimSuffix(m.Mirror, "/")
+		if m.Source == "" || m.Mirror == "" {
+			return nil, errors.New("image mirror source and mirror must not be empty")
+		}
+		if _, err := reference.ParseNormalizedNamed(m.Mirror + "/image"); err != nil {
+			return nil, fmt.Errorf("invalid image mirror %q: %w", m.Mirror, err)
+		}
+		r.mirrors = append(r.mirrors, m)
+	}
+	for image, digest := range digests {
+		named, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image %q: %w", image, err)
+		}
+		if _, err := reference.ParseNormalizedNamed(named.Name() + "@" + digest); err != nil {
+			return nil, fmt.Errorf("invalid digest %q for image %q: %w", digest, image, err)
+		}
+		r.digests[reference.TagNameOnly(named).String()] = digest
+	}
+	return r, nil
+}
+
+// LoadImageDigests loads a mapping of image references to digests for
+// NewImageRewriter from the YAML or JSON file at path.
+func LoadImageDigests(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image digests: %w", err)
+	}
+	var digests map[string]string
+	if err := yaml.Unmarshal(data, &digests); err != nil {
+		return nil, fmt.Errorf("failed to parse image digests %q: %w", path, err)
+	}
+	return digests, nil
+}
+
+// Run rewrites the images of the manifest in.
+func (r *ImageRewriter) Run(in *bytes.Buffer) (*bytes.Buffer, error) {
+	return transformObjects(in, r.rewriteObject)
+}
+
+func (r *ImageRewriter) rewriteObject(obj *unstructured.Unstructured) (bool, error) {
+	path, ok := manifestutil.PodSpecPath(obj.GetKind())
+	if !ok {
+		return false, nil
+	}
+	spec, ok, err := unstructured.NestedMap(obj.Object, path...)
+	if !ok || err != nil {
+		return false, nil
+	}
+
+	modified := false
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, ok, _ := unstructured.NestedSlice(spec, field)
+		if !ok {
+			continue
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			image, ok := container["image"].(string)
+			if !ok || image == "" {
+				continue
+			}
+			rewritten, err := r.rewrite(image)
+			if err != nil {
+				return false, err
+			}
+			if rewritten != image {
+				container["image"] = rewritten
+				modified = true
+			}
+		}
+		spec[field] = containers
+	}
+	if !modified {
+		return false, nil
+	}
+	return true, unstructured.SetNestedMap(obj.Object, spec, path...)
+}
+
+// rewrite returns the mirrored and pinned reference of image.
+func (r *ImageRewriter) rewrite(image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image %q: %w", image, err)
+	}
+
+	name := named.Name()
+	mirror, mirrored := r.mirrorFor(name)
+	if mirrored {
+		name = mirror.Mirror + strings.TrimPrefix(name, mirror.Source)
+	}
+	var tag, digest string
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		digest = digested.Digest().String()
+	} else {
+		digest = r.digests[reference.TagNameOnly(named).String()]
+	}
+	if !mirrored && (digest == "" || strings.Contains(image, "@")) {
+		return image, nil
+	}
+
+	rewritten := name
+	if tag != "" {
+		rewritten += ":" + tag
+	}
+	if digest != "" {
+		rewritten += "@" + digest
+	}
+	if _, err := reference.ParseNormalizedNamed(rewritten); err != nil {
+		return "", fmt.Errorf("invalid rewritten image %q of image %q: %w", rewritten, image, err)
+	}
+	return rewritten, nil
+}
+
+// mirrorFor returns the mirror with the longest Source that matches the
+// repository name.
+func (r *ImageRewriter) mirrorFor(name string) (ImageMirror, bool) {
+	var (
+		match ImageMirror
+		found bool
+	)
+	for _, m := range r.mirrors {
+		if (name == m.Source || strings.HasPrefix(name, m.Source+"/")) && len(m.Source) > len(match.Source) {
+			match, found = m, true
+		}
+	}
+	return match, found
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrenderer_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/postrenderer"
+)
+
+const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+var _ = Describe("ImageRewriter", func() {
+	var mirrors []postrenderer.ImageMirror
+
+	BeforeEach(func() {
+		mirrors = []postrenderer.ImageMirror{
+			{Source: "docker.io", Mirror: "mirror.example.com/docker.io"},
+			{Source: "quay.io/example/", Mirror: "mirror.example.com/example/"},
+			{Source: "quay.io", Mirror: "mirror.example.com/quay.io"},
+		}
+	})
+
+	rewrite := func(r *postrenderer.ImageRewriter, manifest string) string {
+		out, err := r.Run(bytes.NewBufferString(manifest))
+		Expect(err).ToNot(HaveOccurred())
+		return out.String()
+	}
+
+	DescribeTable("should rewrite images according to the mirrors",
+		func(image, expected string) {
+			r, err := postrenderer.NewImageRewriter(mirrors, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rewrite(r, "kind: Pod\nspec:\n  containers:\n  - image: "+image+"\n")).To(Equal("---\nkind: Pod\nspec:\n  containers:\n  - image: " + expected + "\n"))
+		},
+		Entry("official image", "nginx:1.27", "mirror.example.com/docker.io/library/nginx:1.27"),
+		Entry("docker hub image without a tag", "example/app", "mirror.example.com/docker.io/example/app"),
+		Entry("longest matching source", "quay.io/example/app:v1", "mirror.example.com/example/app:v1"),
+		Entry("source at path boundary", "quay.io/examples/app:v1", "mirror.example.com/quay.io/examples/app:v1"),
+		Entry("image with a digest", "quay.io/app@"+digest, "mirror.example.com/quay.io/app@"+digest),
+		Entry("image without a mirror", "registry.example.com/app:v1", "registry.example.com/app:v1"),
+	)
+
+	It("should rewrite the images of all containers of workloads", func() {
+		r, err := postrenderer.NewImageRewriter(mirrors, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rewrite(r, `---
+# Source: test/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - image: busybox:1.36
+            name: main
+          initContainers:
+          - image: registry.example.com/init:v1
+            name: init
+---
+# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: busybox:1.36
+`)).To(Equal(`---
+# Source: test/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - image: mirror.example.com/docker.io/library/busybox:1.36
+            name: main
+          initContainers:
+          - image: registry.example.com/init:v1
+            name: init
+---
+# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: busybox:1.36
+`))
+	})
+
+	It("should pin images to digests before mirroring them", func() {
+		r, err := postrenderer.NewImageRewriter(mirrors, map[string]string{
+			"busybox:1.36":                 digest,
+			"registry.example.com/app":     digest,
+			"docker.io/library/nginx:1.27": digest,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rewrite(r, `kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - image: busybox:1.36
+      - image: registry.example.com/app
+      - image: nginx:1.27@sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210
+      - image: nginx:1.26
+`)).To(Equal(`---
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - image: mirror.example.com/docker.io/library/busybox:1.36@` + digest + `
+      - image: registry.example.com/app@` + digest + `
+      - image: mirror.example.com/docker.io/library/nginx:1.27@sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210
+      - image: mirror.example.com/docker.io/library/nginx:1.26
+`))
+	})
+
+	It("should fail for invalid images", func() {
+		r, err := postrenderer.NewImageRewriter(mirrors, nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = r.Run(bytes.NewBufferString("kind: Pod\nmetadata:\n  name: pod\nspec:\n  containers:\n  - image: Invalid:Image:Ref\n"))
+		Expect(err).To(MatchError(ContainSubstring(`Pod pod: invalid image "Invalid:Image:Ref"`)))
+	})
+
+	It("should fail for invalid mirrors and digests", func() {
+		_, err := postrenderer.NewImageRewriter([]postrenderer.ImageMirror{{Source: "docker.io"}}, nil)
+		Expect(err).To(MatchError(ContainSubstring("must not be empty")))
+		_, err = postrenderer.NewImageRewriter([]postrenderer.ImageMirror{{Source: "docker.io", Mirror: "Mirror.example.com/UPPER"}}, nil)
+		Expect(err).To(MatchError(ContainSubstring("invalid image mirror")))
+		_, err = postrenderer.NewImageRewriter(nil, map[string]string{"nginx": "sha256:short"})
+		Expect(err).To(MatchError(ContainSubstring(`invalid digest "sha256:short" for image "nginx"`)))
+	})
+})
+
+var _ = Describe("LoadImageDigests", func() {
+	It("should load a mapping file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "digests.yaml")
+		Expect(os.WriteFile(path, []byte("nginx:1.27: "+digest+"\n"), 0600)).To(Succeed())
+		Expect(postrenderer.LoadImageDigests(path)).To(Equal(map[string]string{"nginx:1.27": digest}))
+	})
+	It("should fail for a missing file", func() {
+		_, err := postrenderer.LoadImageDigests(filepath.Join(GinkgoT().TempDir(), "missing.yaml"))
+		Expect(err).To(HaveOccurred())
+	})
+})
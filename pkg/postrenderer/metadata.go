@@ -0,0 +1,172 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrenderer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+
+	sprig "github.com/go-task/slim-sprig/v3"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/postrender"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/manifestutil"
+)
+
+// CommonMetadata adds labels and annotations to all objects of a release,
+// e.g. for cost allocation or ownership tooling. The values of the labels and
+// annotations are templates that are rendered with the custom resource of the
+// release as .CR, e.g. "{{ .CR.metadata.labels.team }}" or
+// "{{ .CR.metadata.uid }}". The sprig functions are available, and missing
+// values render as empty strings.
+//
+// The labels and annotations override the labels and annotations of the same
+// name that are set by the chart.
+type CommonMetadata struct {
+	labels       map[string]*template.Template
+	annotations  map[string]*template.Template
+	podTemplates bool
+}
+
+// NewCommonMetadata returns a CommonMetadata that adds labels and
+// annotations. If podTemplates is true, they are also added to the pod
+// templates of workloads, i.e. of Deployments, StatefulSets, DaemonSets,
+// ReplicaSets, ReplicationControllers, Jobs and CronJobs.
+func NewCommonMetadata(labels, annotations map[string]string, podTemplates bool) (*CommonMetadata, error) {
+	if len(labels) == 0 && len(annotations) == 0 {
+		return nil, errors.New("common metadata must have labels or annotations")
+	}
+	m := &CommonMetadata{podTemplates: podTemplates}
+	var err error
+	if m.labels, err = parseMetadataTemplates("label", labels); err != nil {
+		return nil, err
+	}
+	if m.annotations, err = parseMetadataTemplates("annotation", annotations); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func parseMetadataTemplates(kind string, values map[string]string) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template, len(values))
+	for key, value := range values {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s key %q: %s", kind, key, strings.Join(errs, ", "))
+		}
+		tmpl, err := template.New(key).Funcs(sprig.TxtFuncMap()).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", kind, key, err)
+		}
+		templates[key] = tmpl
+	}
+	return templates, nil
+}
+
+// Provider returns a client.PostRendererProvider that provides the
+// post-renderer of m for each custom resource.
+func (m *CommonMetadata) Provider() helmclient.PostRendererProvider {
+	return func(_ meta.RESTMapper, _ kube.Interface, obj client.Object) postrender.PostRenderer {
+		return m.PostRenderer(obj)
+	}
+}
+
+// PostRenderer returns a post-renderer that adds the labels and annotations
+// of m, rendered for the custom resource obj.
+func (m *CommonMetadata) PostRenderer(obj client.Object) postrender.PostRenderer {
+	return helmclient.PostRendererFunc(func(in *bytes.Buffer) (*bytes.Buffer, error) {
+		labels, annotations, err := m.render(obj)
+		if err != nil {
+			return nil, err
+		}
+		return transformObjects(in, func(u *unstructured.Unstructured) (bool, error) {
+			addMetadata(u.Object, []string{"metadata"}, labels, annotations)
+			if path, ok := manifestutil.PodSpecPath(u.GetKind()); ok && m.podTemplates && len(path) > 1 {
+				templatePath := append(path[:len(path)-1:len(path)-1], "metadata")
+				addMetadata(u.Object, templatePath, labels, annotations)
+			}
+			return true, nil
+		})
+	})
+}
+
+// render renders the labels and annotations of m for obj.
+func (m *CommonMetadata) render(obj client.Object) (map[string]string, map[string]string, error) {
+	var cr map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		cr = u.Object
+	} else {
+		var err error
+		if cr, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, nil, err
+		}
+	}
+	data := map[string]interface{}{"CR": cr}
+
+	labels, err := renderMetadataTemplates(m.labels, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render labels: %w", err)
+	}
+	for key, value := range labels {
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, nil, fmt.Errorf("invalid value %q of label %q: %s", value, key, strings.Join(errs, ", "))
+		}
+	}
+	annotations, err := renderMetadataTemplates(m.annotations, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render annotations: %w", err)
+	}
+	return labels, annotations, nil
+}
+
+func renderMetadataTemplates(templates map[string]*template.Template, data interface{}) (map[string]string, error) {
+	values := make(map[string]string, len(templates))
+	for key, tmpl := range templates {
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		// Like Helm, render missing values as empty strings.
+		values[key] = strings.ReplaceAll(buf.String(), "<no value>", "")
+	}
+	return values, nil
+}
+
+// addMetadata adds labels and annotations to the object metadata at path of
+// obj.
+func addMetadata(obj map[string]interface{}, path []string, labels, annotations map[string]string) {
+	for field, values := range map[string]map[string]string{"labels": labels, "annotations": annotations} {
+		if len(values) == 0 {
+			continue
+		}
+		existing, _, _ := unstructured.NestedStringMap(obj, append(path, field)...)
+		if existing == nil {
+			existing = make(map[string]string, len(values))
+		}
+		maps.Copy(existing, values)
+		_ = unstructured.SetNestedStringMap(obj, existing, append(path, field)...)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrenderer_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/postrenderer"
+)
+
+var _ = Describe("CommonMetadata", func() {
+	var cr *unstructured.Unstructured
+
+	BeforeEach(func() {
+		cr = &unstructured.Unstructured{}
+		cr.SetName("test")
+		cr.SetUID(types.UID("0123-4567"))
+		cr.SetLabels(map[string]string{"team": "payments"})
+	})
+
+	const manifest = `---
+# Source: test/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: web
+    team: chart
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - image: nginx
+        name: web
+---
+# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+
+	It("should add templated labels and annotations to all objects", func() {
+		m, err := postrenderer.NewCommonMetadata(
+			map[string]string{"team": "{{ .CR.metadata.labels.team }}", "cost-center": "{{ .CR.metadata.labels.costCenter }}"},
+			map[string]string{"example.com/owner-uid": "{{ .CR.metadata.uid }}"},
+			false,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		out, err := m.PostRenderer(cr).Run(bytes.NewBufferString(manifest))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out.String()).To(Equal(`---
+# Source: test/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    example.com/owner-uid: 0123-4567
+  labels:
+    app: web
+    cost-center: ""
+    team: payments
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - image: nginx
+        name: web
+---
+# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    example.com/owner-uid: 0123-4567
+  labels:
+    cost-center: ""
+    team: payments
+  name: config
+`))
+	})
+
+	It("should add labels to pod templates", func() {
+		m, err := postrenderer.NewCommonMetadata(map[string]string{"team": "{{ .CR.metadata.labels.team }}"}, nil, true)
+		Expect(err).ToNot(HaveOccurred())
+		out, err := m.Provider()(nil, nil, cr).Run(bytes.NewBufferString(manifest))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring(`  template:
+    metadata:
+      labels:
+        app: web
+        team: payments
+`))
+	})
+
+	It("should render typed custom resources", func() {
+		m, err := postrenderer.NewCommonMetadata(map[string]string{"owner": "{{ .CR.metadata.name }}"}, nil, false)
+		Expect(err).ToNot(HaveOccurred())
+		out, err := m.PostRenderer(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "typed"}}).Run(bytes.NewBufferString(manifest))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("owner: typed"))
+	})
+
+	It("should fail for invalid label values", func() {
+		m, err := postrenderer.NewCommonMetadata(map[string]string{"team": "{{ .CR.metadata.name }} team"}, nil, false)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = m.PostRenderer(cr).Run(bytes.NewBufferString(manifest))
+		Expect(err).To(MatchError(ContainSubstring(`invalid value "test team" of label "team"`)))
+	})
+
+	It("should fail for invalid keys and templates", func() {
+		_, err := postrenderer.NewCommonMetadata(nil, nil, true)
+		Expect(err).To(MatchError(ContainSubstring("must have labels or annotations")))
+		_, err = postrenderer.NewCommonMetadata(map[string]string{"invalid key": "value"}, nil, false)
+		Expect(err).To(MatchError(ContainSubstring(`invalid label key "invalid key"`)))
+		_, err = postrenderer.NewCommonMetadata(nil, map[string]string{"owner": "{{ .CR"}, false)
+		Expect(err).To(MatchError(ContainSubstring(`invalid annotation "owner"`)))
+	})
+})
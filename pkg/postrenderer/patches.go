@@ -0,0 +1,348 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrenderer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/postrender"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/values"
+)
+
+// Patch is a patch of the objects of a release. It is either a strategic
+// merge patch or a JSON6902 patch, which is a list of operations. The patch
+// is set inline or read from a key of a ConfigMap in the namespace of the
+// custom resource.
+type Patch struct {
+	// Target selects the objects that are patched. It may be omitted for
+	// strategic merge patches, which then patch the object with the
+	// apiVersion, kind and name of the patch.
+	Target *PatchTarget `json:"target,omitempty"`
+
+	// Patch is the patch in YAML or JSON.
+	Patch string `json:"patch,omitempty"`
+
+	// ConfigMapRef references a ConfigMap key that contains the patch.
+	ConfigMapRef *corev1.ConfigMapKeySelector `json:"configMapRef,omitempty"`
+}
+
+// PatchTarget selects objects by their group, version, kind, name,
+// namespace and labels. Empty fields match all objects.
+type PatchTarget struct {
+	Group         string `json:"group,omitempty"`
+	Version       string `json:"version,omitempty"`
+	Kind          string `json:"kind,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// Patcher is a post-renderer provider that applies the patches that are
+// listed in the `spec.postRenderers.patches` field of custom resources, e.g.
+//
+//	spec:
+//	  postRenderers:
+//	    patches:
+//	    - target:
+//	        kind: Deployment
+//	        name: web
+//	      patch: |
+//	        - op: replace
+//	          path: /spec/replicas
+//	          value: 3
+//	    - configMapRef:
+//	        name: patches
+//	        key: service.yaml
+//
+// Use PatcherValueTranslator, so that the field is not passed to the chart
+// as a value.
+//
+// Strategic merge patches of kinds that are not known to the Kubernetes
+// client scheme are applied as JSON merge patches. Patches only apply to
+// namespaced objects, so that they cannot grant privileges with
+// cluster-scoped objects, e.g. ClusterRoles, and they must not change the
+// apiVersion, kind, name or namespace of an object.
+//
+// Changes of ConfigMaps that contain patches do not trigger a
+// reconciliation. They are applied when the custom resource is reconciled
+// the next time, e.g. when it changes or after the reconcile period.
+type Patcher struct {
+	reader client.Reader
+}
+
+// NewPatcher returns a Patcher that reads ConfigMaps with reader.
+func NewPatcher(reader client.Reader) *Patcher {
+	return &Patcher{reader: reader}
+}
+
+// PatcherValueTranslator is a values.Translator that passes the spec of
+// custom resources to the chart without the spec.postRenderers field, which
+// configures the Patcher.
+var PatcherValueTranslator = values.TranslatorFunc(func(_ context.Context, u *unstructured.Unstructured) (chartutil.Values, error) {
+	spec, _, err := unstructured.NestedMap(u.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		return chartutil.Values{}, nil
+	}
+	delete(spec, "postRenderers")
+	return spec, nil
+})
+
+// Provider returns a client.ContextPostRendererProvider that provides the
+// post-renderer of p for each install and upgrade.
+func (p *Patcher) Provider() helmclient.ContextPostRendererProvider {
+	return func(prc helmclient.PostRendererContext) postrender.PostRenderer {
+		return p.PostRenderer(prc.Context, prc.RESTMapper, prc.Owner)
+	}
+}
+
+// PostRenderer returns a post-renderer that applies the patches of the custom
+// resource obj. It reads ConfigMaps with ctx and determines the scope of the
+// patched objects with mapper.
+func (p *Patcher) PostRenderer(ctx context.Context, mapper meta.RESTMapper, obj client.Object) postrender.PostRenderer {
+	return helmclient.PostRendererFunc(func(in *bytes.Buffer) (*bytes.Buffer, error) {
+		patches, err := p.patchesFor(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		if len(patches) == 0 {
+			return in, nil
+		}
+		return transformObjects(in, func(u *unstructured.Unstructured) (bool, error) {
+			modified := false
+			for i, patch := range patches {
+				if !patch.matches(u, obj.GetNamespace()) {
+					continue
+				}
+				if !modified {
+					if err := checkNamespaced(mapper, u); err != nil {
+						return false, fmt.Errorf("patch %d: %w", i, err)
+					}
+				}
+				if err := patch.apply(u); err != nil {
+					return false, fmt.Errorf("failed to apply patch %d: %w", i, err)
+				}
+				modified = true
+			}
+			return modified, nil
+		})
+	})
+}
+
+// checkNamespaced returns an error if obj is not a namespaced object.
+func checkNamespaced(mapper meta.RESTMapper, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("cannot determine whether %s is namespaced: %w", gvk.Kind, err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return fmt.Errorf("cluster-scoped %s objects must not be patched", gvk.Kind)
+	}
+	return nil
+}
+
+// parsedPatch is a Patch with its parsed target and content.
+type parsedPatch struct {
+	target   PatchTarget
+	selector labels.Selector
+
+	// Either json6902 or strategicMerge is set.
+	json6902       jsonpatch.Patch
+	strategicMerge []byte
+}
+
+// patchesFor returns the patches of obj.
+func (p *Patcher) patchesFor(ctx context.Context, obj client.Object) ([]parsedPatch, error) {
+	var u map[string]interface{}
+	if uobj, ok := obj.(*unstructured.Unstructured); ok {
+		u = uobj.Object
+	} else {
+		var err error
+		if u, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+	items, ok, err := unstructured.NestedSlice(u, "spec", "postRenderers", "patches")
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec.postRenderers.patches: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	patches := make([]parsedPatch, 0, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid spec.postRenderers.patches[%d]: not an object", i)
+		}
+		var patch Patch
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(m, &patch, true); err != nil {
+			return nil, fmt.Errorf("invalid spec.postRenderers.patches[%d]: %w", i, err)
+		}
+		parsed, ok, err := p.parse(ctx, patch, obj.GetNamespace())
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec.postRenderers.patches[%d]: %w", i, err)
+		}
+		if ok {
+			patches = append(patches, parsed)
+		}
+	}
+	return patches, nil
+}
+
+// parse parses patch, reading it from its ConfigMap in namespace if
+// necessary. It returns false if the patch is read from an optional
+// ConfigMap key that does not exist.
+func (p *Patcher) parse(ctx context.Context, patch Patch, namespace string) (parsedPatch, bool, error) {
+	var parsed parsedPatch
+	content := patch.Patch
+	switch ref := patch.ConfigMapRef; {
+	case ref != nil && content != "":
+		return parsed, false, errors.New("patch and configMapRef are mutually exclusive")
+	case ref != nil:
+		var cm corev1.ConfigMap
+		err := p.reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &cm)
+		optional := ref.Optional != nil && *ref.Optional
+		if apierrors.IsNotFound(err) && optional {
+			return parsed, false, nil
+		}
+		if err != nil {
+			return parsed, false, fmt.Errorf("failed to get ConfigMap %s: %w", ref.Name, err)
+		}
+		var found bool
+		if content, found = cm.Data[ref.Key]; !found {
+			if optional {
+				return parsed, false, nil
+			}
+			return parsed, false, fmt.Errorf("ConfigMap %s has no key %q", ref.Name, ref.Key)
+		}
+	case content == "":
+		return parsed, false, errors.New("either patch or configMapRef must be set")
+	}
+
+	var decoded interface{}
+	if err := yaml.Unmarshal([]byte(content), &decoded); err != nil {
+		return parsed, false, fmt.Errorf("failed to parse patch: %w", err)
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return parsed, false, err
+	}
+	switch decoded := decoded.(type) {
+	case []interface{}:
+		if patch.Target == nil {
+			return parsed, false, errors.New("JSON6902 patches must have a target")
+		}
+		if parsed.json6902, err = jsonpatch.DecodePatch(data); err != nil {
+			return parsed, false, fmt.Errorf("invalid JSON6902 patch: %w", err)
+		}
+	case map[string]interface{}:
+		parsed.strategicMerge = data
+		if patch.Target == nil {
+			u := unstructured.Unstructured{Object: decoded}
+			gv, err := schema.ParseGroupVersion(u.GetAPIVersion())
+			if err != nil {
+				return parsed, false, err
+			}
+			if u.GetKind() == "" || u.GetName() == "" {
+				return parsed, false, errors.New("strategic merge patches without a target must have a kind and a name")
+			}
+			patch.Target = &PatchTarget{Group: gv.Group, Version: gv.Version, Kind: u.GetKind(), Name: u.GetName(), Namespace: u.GetNamespace()}
+		}
+	default:
+		return parsed, false, errors.New("patch must be a strategic merge patch or a list of JSON6902 operations")
+	}
+
+	parsed.target = *patch.Target
+	if parsed.selector, err = labels.Parse(parsed.target.LabelSelector); err != nil {
+		return parsed, false, fmt.Errorf("invalid target labelSelector: %w", err)
+	}
+	return parsed, true, nil
+}
+
+// matches returns whether the target of p selects obj. Objects without a
+// namespace are in defaultNamespace.
+func (p parsedPatch) matches(obj *unstructured.Unstructured, defaultNamespace string) bool {
+	gvk := obj.GroupVersionKind()
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	t := p.target
+	return (t.Group == "" || t.Group == gvk.Group) &&
+		(t.Version == "" || t.Version == gvk.Version) &&
+		(t.Kind == "" || t.Kind == gvk.Kind) &&
+		(t.Name == "" || t.Name == obj.GetName()) &&
+		(t.Namespace == "" || t.Namespace == namespace) &&
+		p.selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// apply applies p to obj. It fails if p changes the apiVersion, kind, name or
+// namespace of obj.
+func (p parsedPatch) apply(obj *unstructured.Unstructured) error {
+	original, err := json.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	var patched []byte
+	switch {
+	case p.json6902 != nil:
+		patched, err = p.json6902.Apply(original)
+	default:
+		typed, schemeErr := scheme.Scheme.New(obj.GroupVersionKind())
+		if schemeErr != nil {
+			patched, err = jsonpatch.MergePatch(original, p.strategicMerge)
+		} else {
+			patched, err = strategicpatch.StrategicMergePatch(original, p.strategicMerge, typed)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	patchedObj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(patched, &patchedObj.Object); err != nil {
+		return err
+	}
+	if patchedObj.GroupVersionKind() != obj.GroupVersionKind() || patchedObj.GetName() != obj.GetName() || patchedObj.GetNamespace() != obj.GetNamespace() {
+		return errors.New("patches must not change the apiVersion, kind, name or namespace of an object")
+	}
+	obj.Object = patchedObj.Object
+	return nil
+}
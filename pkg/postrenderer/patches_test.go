@@ -0,0 +1,221 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postrenderer_test
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/postrenderer"
+)
+
+var _ = Describe("Patcher", func() {
+	const manifest = `---
+# Source: test/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: web
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: web
+      - image: envoy
+        name: proxy
+---
+# Source: test/templates/widget.yaml
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: web
+spec:
+  size: small
+  color: red
+`
+
+	var (
+		cr      *unstructured.Unstructured
+		patcher *postrenderer.Patcher
+	)
+
+	BeforeEach(func() {
+		cr = &unstructured.Unstructured{Object: map[string]interface{}{}}
+		cr.SetName("test")
+		cr.SetNamespace("default")
+		patcher = postrenderer.NewPatcher(fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "patches", Namespace: "default"},
+			Data: map[string]string{
+				"widget.yaml": "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: web\nspec:\n  color: null\n",
+			},
+		}).Build())
+	})
+
+	setPatches := func(patches ...interface{}) {
+		Expect(unstructured.SetNestedSlice(cr.Object, patches, "spec", "postRenderers", "patches")).To(Succeed())
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+
+	runManifest := func(manifest string) (string, error) {
+		prc := helmclient.PostRendererContext{Context: context.Background(), RESTMapper: mapper, Owner: cr}
+		out, err := patcher.Provider()(prc).Run(bytes.NewBufferString(manifest))
+		if err != nil {
+			return "", err
+		}
+		return out.String(), nil
+	}
+	run := func() (string, error) {
+		return runManifest(manifest)
+	}
+
+	It("should leave manifests without patches unmodified", func() {
+		Expect(run()).To(Equal(manifest))
+	})
+
+	It("should apply strategic merge patches", func() {
+		setPatches(map[string]interface{}{
+			"patch": `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: proxy
+        image: envoy:v1.31
+`,
+		})
+		Expect(run()).To(ContainSubstring(`      containers:
+      - image: nginx
+        name: web
+      - image: envoy:v1.31
+        name: proxy
+`))
+	})
+
+	It("should apply JSON6902 patches to the selected objects", func() {
+		setPatches(map[string]interface{}{
+			"target": map[string]interface{}{"group": "apps", "kind": "Deployment", "labelSelector": "app=web"},
+			"patch":  `[{"op": "replace", "path": "/spec/replicas", "value": 3}]`,
+		}, map[string]interface{}{
+			"target": map[string]interface{}{"kind": "Deployment", "name": "other"},
+			"patch":  "- op: remove\n  path: /spec/replicas\n",
+		})
+		out, err := run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("  replicas: 3\n"))
+		Expect(out).To(ContainSubstring("# Source: test/templates/deployment.yaml\n"))
+	})
+
+	It("should apply merge patches of custom kinds from ConfigMaps", func() {
+		setPatches(map[string]interface{}{
+			"configMapRef": map[string]interface{}{"name": "patches", "key": "widget.yaml"},
+		})
+		Expect(run()).To(HaveSuffix(`---
+# Source: test/templates/widget.yaml
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: web
+spec:
+  size: small
+`))
+	})
+
+	It("should skip missing optional ConfigMap keys", func() {
+		setPatches(map[string]interface{}{
+			"configMapRef": map[string]interface{}{"name": "patches", "key": "missing.yaml", "optional": true},
+		}, map[string]interface{}{
+			"configMapRef": map[string]interface{}{"name": "missing", "key": "patch.yaml", "optional": true},
+		})
+		Expect(run()).To(Equal(manifest))
+	})
+
+	It("should not patch cluster-scoped objects", func() {
+		setPatches(map[string]interface{}{
+			"target": map[string]interface{}{"kind": "ClusterRole"},
+			"patch":  `[{"op": "add", "path": "/rules/-", "value": {"apiGroups": ["*"], "resources": ["*"], "verbs": ["*"]}}]`,
+		})
+		_, err := runManifest("apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: web\nrules: []\n")
+		Expect(err).To(MatchError(ContainSubstring("cluster-scoped ClusterRole objects must not be patched")))
+	})
+
+	It("should not patch objects of unknown kinds", func() {
+		setPatches(map[string]interface{}{
+			"target": map[string]interface{}{"kind": "Gadget"},
+			"patch":  `[{"op": "add", "path": "/spec", "value": {}}]`,
+		})
+		_, err := runManifest("apiVersion: example.com/v1\nkind: Gadget\nmetadata:\n  name: web\n")
+		Expect(err).To(MatchError(ContainSubstring("cannot determine whether Gadget is namespaced")))
+	})
+
+	It("should pass the spec without the post-renderers to the chart", func() {
+		setPatches(map[string]interface{}{"patch": "kind: Deployment"})
+		Expect(unstructured.SetNestedField(cr.Object, int64(2), "spec", "replicaCount")).To(Succeed())
+		vals, err := postrenderer.PatcherValueTranslator.Translate(context.Background(), cr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vals).To(Equal(chartutil.Values{"replicaCount": int64(2)}))
+		Expect(cr.Object["spec"]).To(HaveKey("postRenderers"))
+	})
+
+	DescribeTable("should fail for invalid patches",
+		func(patch map[string]interface{}, errMsg string) {
+			setPatches(patch)
+			_, err := run()
+			Expect(err).To(MatchError(ContainSubstring(errMsg)))
+		},
+		Entry("missing patch", map[string]interface{}{}, "either patch or configMapRef must be set"),
+		Entry("JSON6902 patch without target", map[string]interface{}{"patch": "[]"}, "JSON6902 patches must have a target"),
+		Entry("strategic merge patch without name", map[string]interface{}{"patch": "kind: Deployment"}, "must have a kind and a name"),
+		Entry("invalid patch", map[string]interface{}{"patch": "just a string"}, "patch must be a strategic merge patch or a list of JSON6902 operations"),
+		Entry("missing ConfigMap", map[string]interface{}{"configMapRef": map[string]interface{}{"name": "missing", "key": "patch.yaml"}}, "failed to get ConfigMap missing"),
+		Entry("missing ConfigMap key", map[string]interface{}{"configMapRef": map[string]interface{}{"name": "patches", "key": "patch.yaml"}}, `ConfigMap patches has no key "patch.yaml"`),
+		Entry("unknown field", map[string]interface{}{"patches": "- op: add"}, `unknown field "patches"`),
+		Entry("failing operation", map[string]interface{}{
+			"target": map[string]interface{}{"kind": "Deployment"},
+			"patch":  "- op: remove\n  path: /spec/missing\n",
+		}, "Deployment web: failed to apply patch 0"),
+		Entry("renaming patch", map[string]interface{}{
+			"target": map[string]interface{}{"kind": "Deployment"},
+			"patch":  "- op: replace\n  path: /metadata/name\n  value: other\n",
+		}, "patches must not change the apiVersion, kind, name or namespace of an object"),
+		Entry("moving patch", map[string]interface{}{
+			"target": map[string]interface{}{"kind": "Widget"},
+			"patch":  "- op: add\n  path: /metadata/namespace\n  value: kube-system\n",
+		}, "patches must not change the apiVersion, kind, name or namespace of an object"),
+	)
+})
@@ -0,0 +1,110 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package postrenderer provides post-renderers that modify the manifests of
+// releases before they are applied, see client.AppendPostRenderers.
+//
+// Note that Helm only post-renders the manifest of a release, but not its
+// hooks.
+package postrenderer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/postrender"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+)
+
+// Static returns a client.PostRendererProvider that provides pr for all
+// custom resources.
+func Static(pr postrender.PostRenderer) helmclient.PostRendererProvider {
+	return func(meta.RESTMapper, kube.Interface, client.Object) postrender.PostRenderer {
+		return pr
+	}
+}
+
+// transformObjects calls transform for each object of the manifest in and
+// returns the resulting manifest. Objects for which transform returns false
+// are left as they are.
+func transformObjects(in *bytes.Buffer, transform func(*unstructured.Unstructured) (bool, error)) (*bytes.Buffer, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+	out := &bytes.Buffer{}
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		// The first document includes a leading separator.
+		doc = bytes.TrimPrefix(doc, []byte("---\n"))
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		var obj unstructured.Unstructured
+		if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if obj.Object != nil {
+			modified, err := transform(&obj)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+			}
+			if modified {
+				data, err := yaml.Marshal(obj.Object)
+				if err != nil {
+					return nil, err
+				}
+				// Keep the leading comments, e.g. the "# Source:" comment
+				// that Helm adds to each object.
+				doc = append(leadingComments(doc), data...)
+			}
+		}
+		out.WriteString("---\n")
+		out.Write(doc)
+		if !bytes.HasSuffix(doc, []byte("\n")) {
+			out.WriteString("\n")
+		}
+	}
+}
+
+// leadingComments returns the comment lines at the start of doc.
+func leadingComments(doc []byte) []byte {
+	var comments []byte
+	for len(doc) > 0 {
+		line, rest, _ := bytes.Cut(doc, []byte("\n"))
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			break
+		}
+		comments = append(comments, line...)
+		comments = append(comments, '\n')
+		doc = rest
+	}
+	return comments
+}
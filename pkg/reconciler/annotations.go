@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+// validateAnnotations validates the values of the annotations of obj that are
+// enabled in annots and implement annotation.Validator.
+func validateAnnotations[T any](obj metav1.Object, annots map[string]T) error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(annots)) {
+		val, ok := obj.GetAnnotations()[name]
+		if !ok {
+			continue
+		}
+		v, ok := any(annots[name]).(annotation.Validator)
+		if !ok {
+			continue
+		}
+		if err := v.Validate(val); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for annotation %q: %w", val, name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reportInvalidAnnotations records the error returned by validateAnnotations
+// as an event and in the Irreconcilable condition.
+func (r *Reconciler) reportInvalidAnnotations(u *updater.Updater, obj *unstructured.Unstructured, err error) {
+	r.eventRecorder.Event(obj, "Warning", string(conditions.ReasonInvalidAnnotation), err.Error())
+	u.UpdateStatus(
+		updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionTrue, conditions.ReasonInvalidAnnotation, err)),
+		updater.EnsureConditionUnknown(conditions.TypeReleaseFailed),
+	)
+}
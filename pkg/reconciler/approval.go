@@ -0,0 +1,78 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+// WithUpgradeApprovalRequired is an Option that configures whether upgrades
+// must be approved before they are applied. If so, the reconciler computes
+// the pending upgrade of a custom resource, records its revision hash in
+// `status.pendingUpgrade.revisionHash` and the UpgradePending condition, and
+// only applies it once the annotation.ApprovedRevisionHash annotation of the
+// custom resource matches that hash. The release is not reconciled while an
+// upgrade is pending. Installs do not require approval.
+//
+// The revision hash covers the current and the desired manifest, so charts
+// that render different manifests on every run, e.g. with random values,
+// cannot be approved.
+func WithUpgradeApprovalRequired(required bool) Option {
+	return func(r *Reconciler) error {
+		r.upgradeApprovalRequired = required
+		return nil
+	}
+}
+
+// revisionHash identifies the upgrade from the current to the desired
+// release.
+func revisionHash(current, desired *release.Release) string {
+	h := sha256.New()
+	h.Write([]byte(current.Manifest))
+	h.Write([]byte{0})
+	h.Write([]byte(desired.Manifest))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// checkUpgradeApproval returns whether the upgrade from current to desired is
+// approved by the annotation.ApprovedRevisionHash annotation of obj. If not,
+// it records the pending upgrade in the status of obj.
+func (r *Reconciler) checkUpgradeApproval(u *updater.Updater, obj *unstructured.Unstructured, current, desired *release.Release, log logr.Logger) bool {
+	hash := revisionHash(current, desired)
+	if obj.GetAnnotations()[annotation.ApprovedRevisionHash] == hash {
+		log.Info("Upgrade is approved", "revisionHash", hash)
+		return true
+	}
+	log.Info("Upgrade is awaiting approval", "revisionHash", hash)
+	u.UpdateStatus(
+		updater.EnsurePendingUpgrade(&updater.PendingUpgrade{RevisionHash: hash}),
+		updater.EnsureCondition(conditions.UpgradePending(corev1.ConditionTrue, conditions.ReasonAwaitingApproval,
+			fmt.Sprintf("upgrade must be approved by setting the %s annotation to %q", annotation.ApprovedRevisionHash, hash))),
+	)
+	return false
+}
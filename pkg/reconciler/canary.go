@@ -0,0 +1,303 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+	"github.com/operator-framework/helm-operator-plugins/pkg/storage"
+)
+
+// UpgradeStrategy selects how the release of a custom resource is upgraded.
+type UpgradeStrategy string
+
+const (
+	// UpgradeStrategyInPlace upgrades the release directly.
+	UpgradeStrategyInPlace UpgradeStrategy = "InPlace"
+	// UpgradeStrategyCanary verifies the upgrade with a canary release first.
+	UpgradeStrategyCanary UpgradeStrategy = "Canary"
+)
+
+// CanaryMapper maps the values of the release of a custom resource to the
+// values of its canary release.
+type CanaryMapper interface {
+	Map(ctx context.Context, obj *unstructured.Unstructured, vals chartutil.Values) (chartutil.Values, error)
+}
+
+// CanaryMapperFunc is a helper type for passing a function as a CanaryMapper.
+type CanaryMapperFunc func(context.Context, *unstructured.Unstructured, chartutil.Values) (chartutil.Values, error)
+
+func (f CanaryMapperFunc) Map(ctx context.Context, obj *unstructured.Unstructured, vals chartutil.Values) (chartutil.Values, error) {
+	return f(ctx, obj, vals)
+}
+
+// CanaryHealthCheck checks whether the canary release of a custom resource
+// is healthy. It returns an error if it is not healthy yet.
+type CanaryHealthCheck interface {
+	Check(ctx context.Context, obj *unstructured.Unstructured, canary *release.Release) error
+}
+
+// CanaryHealthCheckFunc is a helper type for passing a function as a
+// CanaryHealthCheck.
+type CanaryHealthCheckFunc func(context.Context, *unstructured.Unstructured, *release.Release) error
+
+func (f CanaryHealthCheckFunc) Check(ctx context.Context, obj *unstructured.Unstructured, canary *release.Release) error {
+	return f(ctx, obj, canary)
+}
+
+// CanaryStrategy configures canary upgrades, see WithCanaryUpgrades.
+type CanaryStrategy struct {
+	// Mapper maps the values of the release to the values of the canary
+	// release, e.g. to reduce the number of replicas. If it is nil, the
+	// canary release uses the values of the release.
+	Mapper CanaryMapper
+
+	// HealthChecks must all succeed before the canary is promoted. Without
+	// health checks, the canary is promoted as soon as it is deployed.
+	HealthChecks []CanaryHealthCheck
+
+	// Timeout is how long the health checks may fail before the canary is
+	// aborted. It defaults to 10m.
+	Timeout time.Duration
+
+	// CheckInterval is how often the health checks run. It defaults to 15s.
+	CheckInterval time.Duration
+}
+
+// canaryOfLabel is the release label that marks a canary release with the
+// UID of the custom resource whose upgrades it verifies. Releases without it
+// are neither upgraded nor uninstalled as canary releases, since they may be
+// the releases of other custom resources named "<name>-canary".
+const canaryOfLabel = storage.CanaryOfLabel
+
+func (s CanaryStrategy) withDefaults() CanaryStrategy {
+	if s.Timeout == 0 {
+		s.Timeout = 10 * time.Minute
+	}
+	if s.CheckInterval == 0 {
+		s.CheckInterval = 15 * time.Second
+	}
+	return s
+}
+
+// WithCanaryUpgrades is an Option that makes canary upgrades the default
+// upgrade strategy. Before upgrading the release of a custom resource, the
+// reconciler installs the new revision as the shadow release
+// "<name>-canary" with the values of the strategy's mapper, and runs the
+// health checks until they succeed or time out. If they succeed, the canary
+// is promoted by upgrading the release and uninstalling the canary release.
+// Otherwise, the canary is aborted by uninstalling the canary release, and
+// the release is not upgraded until the custom resource changes. The Canary
+// condition reports the progress of the canary.
+//
+// Charts must name their resources after the release, so that the resources
+// of the canary release do not collide with those of the release. This
+// suits stateless workloads. Canary releases are labeled with the UID of
+// their custom resource. If a release named "<name>-canary" exists without
+// that label, e.g. the release of another custom resource, the canary is
+// aborted and that release is left untouched.
+//
+// Custom resources can select their upgrade strategy with the
+// annotation.UpgradeStrategy annotation. Without this option, the annotation
+// can only select in-place upgrades and other values are reported as invalid.
+// Installs are not affected.
+func WithCanaryUpgrades(strategy CanaryStrategy) Option {
+	return func(r *Reconciler) error {
+		if strategy.Timeout < 0 || strategy.CheckInterval < 0 {
+			return errors.New("canary timeout and check interval must not be negative")
+		}
+		strategy = strategy.withDefaults()
+		r.canaryStrategy = &strategy
+		return nil
+	}
+}
+
+// canaryStrategyFor returns the canary strategy of obj, or nil if its release
+// is upgraded in place. The strategy is selected by the
+// annotation.UpgradeStrategy annotation or else by WithCanaryUpgrades. Canary
+// upgrades can only be selected if they are enabled by WithCanaryUpgrades.
+func (r *Reconciler) canaryStrategyFor(obj metav1.Object) (*CanaryStrategy, error) {
+	val, ok := obj.GetAnnotations()[annotation.UpgradeStrategy]
+	if !ok {
+		return r.canaryStrategy, nil
+	}
+	switch UpgradeStrategy(val) {
+	case UpgradeStrategyInPlace:
+		return nil, nil
+	case UpgradeStrategyCanary:
+		if r.canaryStrategy == nil {
+			return nil, fmt.Errorf("invalid value %q for annotation %q: canary upgrades are not enabled", val, annotation.UpgradeStrategy)
+		}
+		return r.canaryStrategy, nil
+	default:
+		return nil, fmt.Errorf("invalid value %q for annotation %q: must be %s or %s", val, annotation.UpgradeStrategy, UpgradeStrategyInPlace, UpgradeStrategyCanary)
+	}
+}
+
+// runCanary verifies the upgrade of rel to desiredRel with a canary release.
+// It returns whether the upgrade may be applied now, and otherwise when obj
+// should be reconciled again.
+func (r *Reconciler) runCanary(ctx context.Context, actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, vals chartutil.Values, rel, desiredRel *release.Release, strategy CanaryStrategy, log logr.Logger) (ctrl.Result, bool, error) {
+	name := obj.GetName() + "-canary"
+	hash := revisionHash(rel, desiredRel)
+	canary := updater.CanaryFor(obj)
+	if canary != nil && canary.RevisionHash == hash {
+		if canary.Aborted {
+			if err := r.uninstallCanary(actionClient, obj, canary.Name, log); err != nil {
+				return ctrl.Result{}, false, err
+			}
+			return ctrl.Result{RequeueAfter: r.reconcilePeriod}, false, nil
+		}
+		canaryRel, err := actionClient.Get(canary.Name)
+		if err == nil && isCanaryOf(canaryRel, obj) && canaryRel.Info != nil && canaryRel.Info.Status == release.StatusDeployed {
+			if err := checkCanaryHealth(ctx, obj, canaryRel, strategy.HealthChecks); err != nil {
+				if time.Since(canary.StartTime.Time) < strategy.Timeout {
+					log.V(1).Info("Canary release is not healthy yet", "name", canary.Name, "error", err.Error())
+					u.UpdateStatus(updater.EnsureCondition(conditions.Canary(corev1.ConditionTrue, conditions.ReasonCanaryProgressing,
+						fmt.Sprintf("canary release %s is not healthy yet: %v", canary.Name, err))))
+					return ctrl.Result{RequeueAfter: strategy.CheckInterval}, false, nil
+				}
+				return r.abortCanary(actionClient, u, obj, *canary,
+					fmt.Errorf("canary release %s did not become healthy within %s: %w", canary.Name, strategy.Timeout, err), log)
+			}
+			log.Info("Promoting canary release", "name", canary.Name)
+			r.eventRecorder.Eventf(obj, "Normal", "CanaryPromoted", "Canary release %s is healthy, upgrading release %s", canary.Name, obj.GetName())
+			return ctrl.Result{}, true, nil
+		}
+		if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+			return ctrl.Result{}, false, err
+		}
+	}
+
+	canary = &updater.Canary{Name: name, RevisionHash: hash, StartTime: metav1.Now()}
+	canaryVals := vals
+	if strategy.Mapper != nil {
+		var err error
+		if canaryVals, err = strategy.Mapper.Map(ctx, obj, vals); err != nil {
+			return r.abortCanary(actionClient, u, obj, *canary, fmt.Errorf("failed to map canary values: %w", err), log)
+		}
+	}
+	canaryRel, err := r.deployCanary(actionClient, obj, name, canaryVals.AsMap())
+	if err != nil {
+		return r.abortCanary(actionClient, u, obj, *canary, fmt.Errorf("failed to deploy canary release %s: %w", name, err), log)
+	}
+	log.Info("Canary release deployed", "name", canaryRel.Name, "version", canaryRel.Version)
+	r.eventRecorder.Eventf(obj, "Normal", "CanaryDeployed", "Canary release %s was deployed", name)
+	u.UpdateStatus(
+		updater.EnsureCanary(canary),
+		updater.EnsureCondition(conditions.Canary(corev1.ConditionTrue, conditions.ReasonCanaryProgressing,
+			fmt.Sprintf("canary release %s was deployed", name))),
+	)
+	return ctrl.Result{RequeueAfter: strategy.CheckInterval}, false, nil
+}
+
+// deployCanary installs or upgrades the canary release of obj. It refuses to
+// upgrade an existing release that is not marked as the canary release of
+// obj.
+func (r *Reconciler) deployCanary(actionClient helmclient.ActionInterface, obj *unstructured.Unstructured, name string, vals map[string]interface{}) (*release.Release, error) {
+	labels := map[string]string{canaryOfLabel: string(obj.GetUID())}
+	existing, err := actionClient.Get(name)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return actionClient.Install(name, obj.GetNamespace(), r.chrt, vals, append(r.installOptions(obj), func(i *action.Install) error {
+			i.Labels = labels
+			return nil
+		})...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isCanaryOf(existing, obj) {
+		return nil, fmt.Errorf("release %s already exists and is not the canary release of %s", name, obj.GetName())
+	}
+	return actionClient.Upgrade(name, obj.GetNamespace(), r.chrt, vals, append(r.upgradeOptions(obj), func(u *action.Upgrade) error {
+		u.Labels = labels
+		return nil
+	})...)
+}
+
+// isCanaryOf returns whether rel is marked as the canary release of obj.
+func isCanaryOf(rel *release.Release, obj metav1.Object) bool {
+	return rel.Labels[canaryOfLabel] == string(obj.GetUID())
+}
+
+// abortCanary uninstalls the canary release of obj and records that the
+// upgrade to the revision of canary was aborted, so that it is not attempted
+// again until the custom resource changes.
+func (r *Reconciler) abortCanary(actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, canary updater.Canary, cause error, log logr.Logger) (ctrl.Result, bool, error) {
+	log.Error(cause, "Aborting canary upgrade")
+	r.eventRecorder.Event(obj, "Warning", "CanaryAborted", cause.Error())
+	canary.Aborted = true
+	u.UpdateStatus(
+		updater.EnsureCanary(&canary),
+		updater.EnsureCondition(conditions.Canary(corev1.ConditionFalse, conditions.ReasonCanaryAborted, cause)),
+	)
+	if err := r.uninstallCanary(actionClient, obj, canary.Name, log); err != nil {
+		return ctrl.Result{}, false, err
+	}
+	return ctrl.Result{RequeueAfter: r.reconcilePeriod}, false, nil
+}
+
+// uninstallCanary uninstalls the canary release of obj with name, if it
+// exists. Releases that are not marked as the canary release of obj are left
+// untouched.
+func (r *Reconciler) uninstallCanary(actionClient helmclient.ActionInterface, obj metav1.Object, name string, log logr.Logger) error {
+	rel, err := actionClient.Get(name)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get canary release %s: %w", name, err)
+	}
+	if !isCanaryOf(rel, obj) {
+		log.Info("Not uninstalling release, it is not the canary release of the custom resource", "name", name)
+		return nil
+	}
+	if _, err := actionClient.Uninstall(name); err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to uninstall canary release %s: %w", name, err)
+	}
+	log.Info("Canary release uninstalled", "name", name)
+	return nil
+}
+
+func checkCanaryHealth(ctx context.Context, obj *unstructured.Unstructured, canary *release.Release, checks []CanaryHealthCheck) error {
+	for _, c := range checks {
+		if err := c.Check(ctx, obj, canary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
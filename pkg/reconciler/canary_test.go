@@ -0,0 +1,63 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
+)
+
+var _ = Describe("canaryStrategyFor", func() {
+	var r *Reconciler
+	var obj *unstructured.Unstructured
+	BeforeEach(func() {
+		r = &Reconciler{}
+		obj = &unstructured.Unstructured{}
+	})
+	It("should default to in-place upgrades", func() {
+		Expect(r.canaryStrategyFor(obj)).To(BeNil())
+	})
+	It("should use the configured strategy", func() {
+		Expect(WithCanaryUpgrades(CanaryStrategy{})(r)).To(Succeed())
+		Expect(r.canaryStrategyFor(obj)).To(Equal(r.canaryStrategy))
+		obj.SetAnnotations(map[string]string{annotation.UpgradeStrategy: "InPlace"})
+		Expect(r.canaryStrategyFor(obj)).To(BeNil())
+	})
+	It("should honor the annotation with a configured strategy", func() {
+		Expect(WithCanaryUpgrades(CanaryStrategy{})(r)).To(Succeed())
+		obj.SetAnnotations(map[string]string{annotation.UpgradeStrategy: "Canary"})
+		Expect(r.canaryStrategyFor(obj)).To(Equal(&CanaryStrategy{Timeout: 10 * time.Minute, CheckInterval: 15 * time.Second}))
+	})
+	It("should reject canary upgrades without a configured strategy", func() {
+		obj.SetAnnotations(map[string]string{annotation.UpgradeStrategy: "Canary"})
+		_, err := r.canaryStrategyFor(obj)
+		Expect(err).To(MatchError(`invalid value "Canary" for annotation "helm.sdk.operatorframework.io/upgrade-strategy": canary upgrades are not enabled`))
+		obj.SetAnnotations(map[string]string{annotation.UpgradeStrategy: "InPlace"})
+		Expect(r.canaryStrategyFor(obj)).To(BeNil())
+	})
+	It("should fail for an invalid annotation", func() {
+		obj.SetAnnotations(map[string]string{annotation.UpgradeStrategy: "BlueGreen"})
+		_, err := r.canaryStrategyFor(obj)
+		Expect(err).To(MatchError(ContainSubstring(`invalid value "BlueGreen"`)))
+	})
+})
@@ -30,6 +30,14 @@ const (
 	TypeReleaseFailed  = "ReleaseFailed"
 	TypeIrreconcilable = "Irreconcilable"
 
+	TypePendingReleaseRecovered = "PendingReleaseRecovered"
+	TypeStalled                 = "Stalled"
+	TypePaused                  = "Paused"
+	TypeUpgradePending          = "UpgradePending"
+	TypeCanary                  = "Canary"
+	TypeTested                  = "Tested"
+	TypePolicyViolation         = "PolicyViolation"
+
 	ReasonInstallSuccessful   = status.ConditionReason("InstallSuccessful")
 	ReasonUpgradeSuccessful   = status.ConditionReason("UpgradeSuccessful")
 	ReasonUninstallSuccessful = status.ConditionReason("UninstallSuccessful")
@@ -41,6 +49,29 @@ const (
 	ReasonUpgradeError             = status.ConditionReason("UpgradeError")
 	ReasonReconcileError           = status.ConditionReason("ReconcileError")
 	ReasonUninstallError           = status.ConditionReason("UninstallError")
+	ReasonPendingRecoveryError     = status.ConditionReason("PendingRecoveryError")
+	ReasonInvalidAnnotation        = status.ConditionReason("InvalidAnnotation")
+	ReasonErrorGettingRolloutState = status.ConditionReason("ErrorGettingRolloutState")
+	ReasonPolicyError              = status.ConditionReason("PolicyError")
+
+	ReasonPendingReleaseRolledBack   = status.ConditionReason("PendingReleaseRolledBack")
+	ReasonPendingReleaseMarkedFailed = status.ConditionReason("PendingReleaseMarkedFailed")
+	ReasonPendingReleaseUninstalled  = status.ConditionReason("PendingReleaseUninstalled")
+
+	ReasonRetriesExhausted         = status.ConditionReason("RetriesExhausted")
+	ReasonPausedByAnnotation       = status.ConditionReason("PausedByAnnotation")
+	ReasonAwaitingApproval         = status.ConditionReason("AwaitingApproval")
+	ReasonOutsideMaintenanceWindow = status.ConditionReason("OutsideMaintenanceWindow")
+	ReasonAwaitingRolloutWave      = status.ConditionReason("AwaitingRolloutWave")
+	ReasonRolloutHalted            = status.ConditionReason("RolloutHalted")
+	ReasonCanaryProgressing        = status.ConditionReason("CanaryProgressing")
+	ReasonCanaryPromoted           = status.ConditionReason("CanaryPromoted")
+	ReasonCanaryAborted            = status.ConditionReason("CanaryAborted")
+	ReasonTestsSucceeded           = status.ConditionReason("TestsSucceeded")
+	ReasonTestsFailed              = status.ConditionReason("TestsFailed")
+	ReasonTestError                = status.ConditionReason("TestError")
+	ReasonPolicyDenied             = status.ConditionReason("PolicyDenied")
+	ReasonPolicyAllowed            = status.ConditionReason("PolicyAllowed")
 )
 
 func Initialized(stat corev1.ConditionStatus, reason status.ConditionReason, message interface{}) status.Condition {
@@ -59,6 +90,34 @@ func Irreconcilable(stat corev1.ConditionStatus, reason status.ConditionReason,
 	return newCondition(TypeIrreconcilable, stat, reason, message)
 }
 
+func PendingReleaseRecovered(stat corev1.ConditionStatus, reason status.ConditionReason, message interface{}) status.Condition {
+	return newCondition(TypePendingReleaseRecovered, stat, reason, message)
+}
+
+func Stalled(stat corev1.ConditionStatus, reason status.ConditionReason, message interface{}) status.Condition {
+	return newCondition(TypeStalled, stat, reason, message)
+}
+
+func Paused(stat corev1.ConditionStatus, reason status.ConditionReason, message interface{}) status.Condition {
+	return newCondition(TypePaused, stat, reason, message)
+}
+
+func UpgradePending(stat corev1.ConditionStatus, reason status.ConditionReason, message interface{}) status.Condition {
+	return newCondition(TypeUpgradePending, stat, reason, message)
+}
+
+func Canary(stat corev1.ConditionStatus, reason status.ConditionReason, message interface{}) status.Condition {
+	return newCondition(TypeCanary, stat, reason, message)
+}
+
+func Tested(stat corev1.ConditionStatus, reason status.ConditionReason, message interface{}) status.Condition {
+	return newCondition(TypeTested, stat, reason, message)
+}
+
+func PolicyViolation(stat corev1.ConditionStatus, reason status.ConditionReason, message interface{}) status.Condition {
+	return newCondition(TypePolicyViolation, stat, reason, message)
+}
+
 func newCondition(t status.ConditionType, s corev1.ConditionStatus, r status.ConditionReason, m interface{}) status.Condition {
 	message := fmt.Sprintf("%s", m)
 	return status.Condition{
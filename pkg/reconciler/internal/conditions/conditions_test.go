@@ -77,4 +77,88 @@ var _ = Describe("Conditions", func() {
 			Expect(Irreconcilable(e.Status, e.Reason, err)).To(Equal(e))
 		})
 	})
+
+	var _ = Describe("PendingReleaseRecovered", func() {
+		It("should return a PendingReleaseRecovered condition with the correct status, reason, and message", func() {
+			e := status.Condition{
+				Type:    TypePendingReleaseRecovered,
+				Status:  corev1.ConditionTrue,
+				Reason:  ReasonPendingReleaseRolledBack,
+				Message: "message",
+			}
+			Expect(PendingReleaseRecovered(e.Status, e.Reason, e.Message)).To(Equal(e))
+		})
+	})
+
+	var _ = Describe("Stalled", func() {
+		It("should return a Stalled condition with the correct status, reason, and message", func() {
+			e := status.Condition{
+				Type:    TypeStalled,
+				Status:  corev1.ConditionTrue,
+				Reason:  ReasonRetriesExhausted,
+				Message: "message",
+			}
+			Expect(Stalled(e.Status, e.Reason, e.Message)).To(Equal(e))
+		})
+	})
+
+	var _ = Describe("Paused", func() {
+		It("should return a Paused condition with the correct status, reason, and message", func() {
+			e := status.Condition{
+				Type:    TypePaused,
+				Status:  corev1.ConditionTrue,
+				Reason:  ReasonPausedByAnnotation,
+				Message: "message",
+			}
+			Expect(Paused(e.Status, e.Reason, e.Message)).To(Equal(e))
+		})
+	})
+
+	var _ = Describe("UpgradePending", func() {
+		It("should return an UpgradePending condition with the correct status, reason, and message", func() {
+			e := status.Condition{
+				Type:    TypeUpgradePending,
+				Status:  corev1.ConditionTrue,
+				Reason:  ReasonAwaitingApproval,
+				Message: "message",
+			}
+			Expect(UpgradePending(e.Status, e.Reason, e.Message)).To(Equal(e))
+		})
+	})
+
+	var _ = Describe("Canary", func() {
+		It("should return a Canary condition with the correct status, reason, and message", func() {
+			e := status.Condition{
+				Type:    TypeCanary,
+				Status:  corev1.ConditionTrue,
+				Reason:  ReasonCanaryProgressing,
+				Message: "message",
+			}
+			Expect(Canary(e.Status, e.Reason, e.Message)).To(Equal(e))
+		})
+	})
+
+	var _ = Describe("Tested", func() {
+		It("should return a Tested condition with the correct status, reason, and message", func() {
+			e := status.Condition{
+				Type:    TypeTested,
+				Status:  corev1.ConditionFalse,
+				Reason:  ReasonTestsFailed,
+				Message: "message",
+			}
+			Expect(Tested(e.Status, e.Reason, e.Message)).To(Equal(e))
+		})
+	})
+
+	var _ = Describe("PolicyViolation", func() {
+		It("should return a PolicyViolation condition with the correct status, reason, and message", func() {
+			e := status.Condition{
+				Type:    TypePolicyViolation,
+				Status:  corev1.ConditionTrue,
+				Reason:  ReasonPolicyDenied,
+				Message: "message",
+			}
+			Expect(PolicyViolation(e.Status, e.Reason, e.Message)).To(Equal(e))
+		})
+	})
 })
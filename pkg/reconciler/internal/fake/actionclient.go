@@ -49,19 +49,25 @@ func (hcg *fakeActionClientGetter) ActionClientFor(_ context.Context, _ crclient
 }
 
 type ActionClient struct {
-	Gets       []GetCall
-	Histories  []HistoryCall
-	Installs   []InstallCall
-	Upgrades   []UpgradeCall
-	Uninstalls []UninstallCall
-	Reconciles []ReconcileCall
-
-	HandleGet       func() (*release.Release, error)
-	HandleHistory   func() ([]*release.Release, error)
-	HandleInstall   func() (*release.Release, error)
-	HandleUpgrade   func() (*release.Release, error)
-	HandleUninstall func() (*release.UninstallReleaseResponse, error)
-	HandleReconcile func() error
+	Gets        []GetCall
+	Histories   []HistoryCall
+	Installs    []InstallCall
+	Upgrades    []UpgradeCall
+	Uninstalls  []UninstallCall
+	Rollbacks   []RollbackCall
+	MarkFaileds []MarkFailedCall
+	Reconciles  []ReconcileCall
+	Tests       []TestCall
+
+	HandleGet        func() (*release.Release, error)
+	HandleHistory    func() ([]*release.Release, error)
+	HandleInstall    func() (*release.Release, error)
+	HandleUpgrade    func() (*release.Release, error)
+	HandleUninstall  func() (*release.UninstallReleaseResponse, error)
+	HandleRollback   func() error
+	HandleMarkFailed func() error
+	HandleReconcile  func() error
+	HandleTest       func() (*release.Release, error)
 }
 
 func NewActionClient() ActionClient {
@@ -78,19 +84,25 @@ func NewActionClient() ActionClient {
 		return func() error { return err }
 	}
 	return ActionClient{
-		Gets:       make([]GetCall, 0),
-		Histories:  make([]HistoryCall, 0),
-		Installs:   make([]InstallCall, 0),
-		Upgrades:   make([]UpgradeCall, 0),
-		Uninstalls: make([]UninstallCall, 0),
-		Reconciles: make([]ReconcileCall, 0),
-
-		HandleGet:       relFunc(errors.New("get not implemented")),
-		HandleHistory:   historyFunc(errors.New("history not implemented")),
-		HandleInstall:   relFunc(errors.New("install not implemented")),
-		HandleUpgrade:   relFunc(errors.New("upgrade not implemented")),
-		HandleUninstall: uninstFunc(errors.New("uninstall not implemented")),
-		HandleReconcile: recFunc(errors.New("reconcile not implemented")),
+		Gets:        make([]GetCall, 0),
+		Histories:   make([]HistoryCall, 0),
+		Installs:    make([]InstallCall, 0),
+		Upgrades:    make([]UpgradeCall, 0),
+		Uninstalls:  make([]UninstallCall, 0),
+		Rollbacks:   make([]RollbackCall, 0),
+		MarkFaileds: make([]MarkFailedCall, 0),
+		Reconciles:  make([]ReconcileCall, 0),
+		Tests:       make([]TestCall, 0),
+
+		HandleGet:        relFunc(errors.New("get not implemented")),
+		HandleHistory:    historyFunc(errors.New("history not implemented")),
+		HandleInstall:    relFunc(errors.New("install not implemented")),
+		HandleUpgrade:    relFunc(errors.New("upgrade not implemented")),
+		HandleUninstall:  uninstFunc(errors.New("uninstall not implemented")),
+		HandleRollback:   recFunc(errors.New("rollback not implemented")),
+		HandleMarkFailed: recFunc(errors.New("mark failed not implemented")),
+		HandleReconcile:  recFunc(errors.New("reconcile not implemented")),
+		HandleTest:       relFunc(errors.New("test not implemented")),
 	}
 }
 
@@ -127,10 +139,25 @@ type UninstallCall struct {
 	Opts []client.UninstallOption
 }
 
+type RollbackCall struct {
+	Name string
+	Opts []client.RollbackOption
+}
+
+type MarkFailedCall struct {
+	Release     *release.Release
+	Description string
+}
+
 type ReconcileCall struct {
 	Release *release.Release
 }
 
+type TestCall struct {
+	Name string
+	Opts []client.TestOption
+}
+
 func (c *ActionClient) Get(name string, opts ...client.GetOption) (*release.Release, error) {
 	c.Gets = append(c.Gets, GetCall{name, opts})
 	return c.HandleGet()
@@ -156,7 +183,22 @@ func (c *ActionClient) Uninstall(name string, opts ...client.UninstallOption) (*
 	return c.HandleUninstall()
 }
 
+func (c *ActionClient) Rollback(name string, opts ...client.RollbackOption) error {
+	c.Rollbacks = append(c.Rollbacks, RollbackCall{name, opts})
+	return c.HandleRollback()
+}
+
+func (c *ActionClient) MarkFailed(rel *release.Release, description string) error {
+	c.MarkFaileds = append(c.MarkFaileds, MarkFailedCall{rel, description})
+	return c.HandleMarkFailed()
+}
+
 func (c *ActionClient) Reconcile(rel *release.Release) error {
 	c.Reconciles = append(c.Reconciles, ReconcileCall{rel})
 	return c.HandleReconcile()
 }
+
+func (c *ActionClient) Test(name string, opts ...client.TestOption) (*release.Release, error) {
+	c.Tests = append(c.Tests, TestCall{name, opts})
+	return c.HandleTest()
+}
@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	crtpredicate "sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"sigs.k8s.io/yaml"
 
@@ -39,12 +40,16 @@ import (
 	"github.com/operator-framework/helm-operator-plugins/pkg/manifestutil"
 )
 
-func NewDependentResourceWatcher(c controller.Controller, rm meta.RESTMapper, cache cache.Cache, scheme *runtime.Scheme) hook.PostHook {
+// NewDependentResourceWatcher returns a post hook that watches the resources
+// of a release. Events for dependent resources are filtered by preds in
+// addition to the default dependent resource predicates.
+func NewDependentResourceWatcher(c controller.Controller, rm meta.RESTMapper, cache cache.Cache, scheme *runtime.Scheme, preds ...crtpredicate.TypedPredicate[*unstructured.Unstructured]) hook.PostHook {
 	return &dependentResourceWatcher{
 		controller: c,
 		restMapper: rm,
 		cache:      cache,
 		scheme:     scheme,
+		predicates: preds,
 		m:          sync.Mutex{},
 		watches:    make(map[schema.GroupVersionKind]struct{}),
 	}
@@ -55,6 +60,7 @@ type dependentResourceWatcher struct {
 	restMapper meta.RESTMapper
 	cache      cache.Cache
 	scheme     *runtime.Scheme
+	predicates []crtpredicate.TypedPredicate[*unstructured.Unstructured]
 
 	m       sync.Mutex
 	watches map[schema.GroupVersionKind]struct{}
@@ -62,7 +68,7 @@ type dependentResourceWatcher struct {
 
 func (d *dependentResourceWatcher) Exec(owner *unstructured.Unstructured, rel release.Release, log logr.Logger) error {
 	// using predefined functions for filtering events
-	dependentPredicate := predicate.DependentPredicateFuncs()
+	preds := append([]crtpredicate.TypedPredicate[*unstructured.Unstructured]{predicate.DependentPredicateFuncs()}, d.predicates...)
 
 	resources := releaseutil.SplitManifests(rel.Manifest)
 	d.m.Lock()
@@ -103,7 +109,7 @@ func (d *dependentResourceWatcher) Exec(owner *unstructured.Unstructured, rel re
 						d.cache,
 						unstructuredObj,
 						handler.TypedEnqueueRequestForOwner[*unstructured.Unstructured](d.scheme, d.restMapper, owner, handler.OnlyControllerOwner()),
-						dependentPredicate,
+						preds...,
 					),
 				); err != nil {
 					return err
@@ -116,7 +122,7 @@ func (d *dependentResourceWatcher) Exec(owner *unstructured.Unstructured, rel re
 						&sdkhandler.EnqueueRequestForAnnotation[*unstructured.Unstructured]{
 							Type: owner.GetObjectKind().GroupVersionKind().GroupKind(),
 						},
-						dependentPredicate,
+						preds...,
 					),
 				); err != nil {
 					return err
@@ -0,0 +1,296 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rollout orders custom resources into waves and tracks the progress
+// of a chart rollout across them.
+package rollout
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/operator-framework/helm-operator-plugins/internal/metrics"
+)
+
+// Status is the rollout status of a single custom resource.
+type Status int
+
+const (
+	// Pending members have not been reconciled with the new chart yet.
+	Pending Status = iota
+	// Done members have been reconciled with the new chart successfully.
+	Done
+	// Failed members failed to be upgraded.
+	Failed
+)
+
+// Member is a custom resource that takes part in a rollout.
+type Member struct {
+	Key    types.NamespacedName
+	Labels map[string]string
+	Status Status
+}
+
+// Policy configures how members are ordered into waves and when a rollout
+// halts.
+type Policy struct {
+	// WaveLabel is the key of a label whose integer values order members into
+	// waves, lowest first. Members without a valid value form the last wave.
+	WaveLabel string
+
+	// WavePercentages are the cumulative percentages of members in each wave.
+	// Members are assigned to waves by a stable hash of their key.
+	WavePercentages []int
+
+	// FailureThreshold is the fraction of members of a wave that may fail
+	// before the rollout halts.
+	FailureThreshold float64
+}
+
+// Validate returns an error if p is not a valid policy.
+func (p Policy) Validate() error {
+	if p.WaveLabel != "" && len(p.WavePercentages) > 0 {
+		return errors.New("wave label and wave percentages are mutually exclusive")
+	}
+	if p.WaveLabel == "" && len(p.WavePercentages) == 0 {
+		return errors.New("either a wave label or wave percentages must be set")
+	}
+	prev := 0
+	for _, pct := range p.WavePercentages {
+		if pct <= prev || pct > 100 {
+			return errors.New("wave percentages must be increasing and between 1 and 100")
+		}
+		prev = pct
+	}
+	if p.FailureThreshold < 0 || p.FailureThreshold > 1 {
+		return errors.New("failure threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// Progress describes how far a rollout has progressed.
+type Progress struct {
+	// Wave is the index of the current wave, which is the first wave that
+	// is not complete. A wave is complete once none of its members are
+	// pending and its failures do not exceed the failure threshold. Wave
+	// equals Waves once the rollout is complete.
+	Wave  int
+	Waves int
+
+	// Done, Failed and Pending count the members of the current wave.
+	Done    int
+	Failed  int
+	Pending int
+
+	// Halted is true if the failures of the current wave exceed the failure
+	// threshold.
+	Halted bool
+}
+
+// Complete returns whether all waves are complete.
+func (p Progress) Complete() bool {
+	return p.Wave >= p.Waves
+}
+
+// Plan is the order of the waves of a rollout and its progress.
+type Plan struct {
+	Progress
+
+	waveOf map[types.NamespacedName]int
+	status map[types.NamespacedName]Status
+}
+
+// Plan orders members into waves and determines the progress of the rollout.
+func (p Policy) Plan(members []Member) Plan {
+	waves := p.waves(members)
+	plan := Plan{
+		Progress: Progress{Wave: len(waves), Waves: len(waves)},
+		waveOf:   make(map[types.NamespacedName]int, len(members)),
+		status:   make(map[types.NamespacedName]Status, len(members)),
+	}
+	for i, wave := range waves {
+		for _, m := range wave {
+			plan.waveOf[m.Key] = i
+			plan.status[m.Key] = m.Status
+		}
+	}
+	for i, wave := range waves {
+		var done, failed, pending int
+		for _, m := range wave {
+			switch m.Status {
+			case Done:
+				done++
+			case Failed:
+				failed++
+			default:
+				pending++
+			}
+		}
+		halted := float64(failed) > p.FailureThreshold*float64(len(wave))
+		if pending > 0 || halted {
+			plan.Progress = Progress{
+				Wave:    i,
+				Waves:   len(waves),
+				Done:    done,
+				Failed:  failed,
+				Pending: pending,
+				Halted:  halted,
+			}
+			break
+		}
+	}
+	return plan
+}
+
+// WaveOf returns the index of the wave of the member with key, or -1 if it is
+// not part of the rollout.
+func (p Plan) WaveOf(key types.NamespacedName) int {
+	if i, ok := p.waveOf[key]; ok {
+		return i
+	}
+	return -1
+}
+
+// Admits returns whether the member with key may be upgraded. These are the
+// members of the current and earlier waves, except for the pending members of
+// the current wave while the rollout is halted. Failed members are admitted,
+// so that they can be retried.
+func (p Plan) Admits(key types.NamespacedName) bool {
+	i := p.WaveOf(key)
+	switch {
+	case i < 0 || i > p.Wave:
+		return false
+	case i < p.Wave:
+		return true
+	default:
+		return !p.Halted || p.status[key] != Pending
+	}
+}
+
+func (p Policy) waves(members []Member) [][]Member {
+	if p.WaveLabel != "" {
+		return p.labelWaves(members)
+	}
+	return p.percentageWaves(members)
+}
+
+func (p Policy) labelWaves(members []Member) [][]Member {
+	groups := map[int][]Member{}
+	var unlabeled []Member
+	for _, m := range members {
+		v, err := strconv.Atoi(m.Labels[p.WaveLabel])
+		if err != nil {
+			unlabeled = append(unlabeled, m)
+			continue
+		}
+		groups[v] = append(groups[v], m)
+	}
+	keys := make([]int, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	waves := make([][]Member, 0, len(keys)+1)
+	for _, k := range keys {
+		waves = append(waves, groups[k])
+	}
+	if len(unlabeled) > 0 {
+		waves = append(waves, unlabeled)
+	}
+	return waves
+}
+
+func (p Policy) percentageWaves(members []Member) [][]Member {
+	sorted := slices.Clone(members)
+	slices.SortFunc(sorted, func(a, b Member) int {
+		return cmp.Or(cmp.Compare(keyHash(a.Key), keyHash(b.Key)), cmp.Compare(a.Key.String(), b.Key.String()))
+	})
+
+	var waves [][]Member
+	start := 0
+	for _, pct := range p.WavePercentages {
+		end := (len(sorted)*pct + 99) / 100
+		if end > start {
+			waves = append(waves, sorted[start:end])
+			start = end
+		}
+	}
+	if start < len(sorted) {
+		waves = append(waves, sorted[start:])
+	}
+	return waves
+}
+
+func keyHash(key types.NamespacedName) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key.String()))
+	return h.Sum64()
+}
+
+// Event describes a transition of a rollout.
+type Event struct {
+	Type    string
+	Reason  string
+	Message string
+}
+
+// Tracker follows the progress of a rollout and reports its transitions.
+type Tracker struct {
+	mu   sync.Mutex
+	last *Progress
+}
+
+// Observe records p as the latest progress of the rollout of custom resources
+// of kind gvk and returns the events for the transitions since the previous
+// progress.
+func (t *Tracker) Observe(gvk schema.GroupVersionKind, p Progress) []Event {
+	metrics.SetRolloutProgress(gvk, p.Wave, p.Waves, p.Done, p.Failed, p.Pending, p.Halted)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	last := t.last
+	t.last = &p
+
+	var events []Event
+	switch {
+	case last != nil && last.Wave == p.Wave && last.Waves == p.Waves:
+	case p.Complete():
+		if last != nil {
+			events = append(events, Event{corev1.EventTypeNormal, "RolloutCompleted", fmt.Sprintf("Rollout completed all %d waves", p.Waves)})
+		}
+	default:
+		events = append(events, Event{corev1.EventTypeNormal, "RolloutWaveStarted",
+			fmt.Sprintf("Rollout wave %d of %d started with %d resources", p.Wave+1, p.Waves, p.Done+p.Failed+p.Pending)})
+	}
+	switch {
+	case p.Halted && (last == nil || !last.Halted || last.Wave != p.Wave):
+		events = append(events, Event{corev1.EventTypeWarning, "RolloutHalted",
+			fmt.Sprintf("Rollout halted in wave %d of %d: %d of %d resources failed", p.Wave+1, p.Waves, p.Failed, p.Done+p.Failed+p.Pending)})
+	case !p.Halted && last != nil && last.Halted && last.Wave == p.Wave:
+		events = append(events, Event{corev1.EventTypeNormal, "RolloutResumed", fmt.Sprintf("Rollout resumed in wave %d of %d", p.Wave+1, p.Waves)})
+	}
+	return events
+}
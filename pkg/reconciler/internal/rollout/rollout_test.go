@@ -0,0 +1,204 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/rollout"
+)
+
+var gvk = schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Test"}
+
+func member(name, wave string, status rollout.Status) rollout.Member {
+	m := rollout.Member{Key: types.NamespacedName{Namespace: "default", Name: name}, Status: status}
+	if wave != "" {
+		m.Labels = map[string]string{"rollout-wave": wave}
+	}
+	return m
+}
+
+func key(name string) types.NamespacedName {
+	return types.NamespacedName{Namespace: "default", Name: name}
+}
+
+var _ = Describe("Policy", func() {
+	DescribeTable("should validate",
+		func(p rollout.Policy, errMsg string) {
+			err := p.Validate()
+			if errMsg == "" {
+				Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			Expect(err).To(MatchError(ContainSubstring(errMsg)))
+		},
+		Entry("a wave label", rollout.Policy{WaveLabel: "rollout-wave"}, ""),
+		Entry("wave percentages", rollout.Policy{WavePercentages: []int{10, 50}, FailureThreshold: 0.2}, ""),
+		Entry("neither", rollout.Policy{}, "either a wave label or wave percentages must be set"),
+		Entry("both", rollout.Policy{WaveLabel: "rollout-wave", WavePercentages: []int{50}}, "mutually exclusive"),
+		Entry("decreasing percentages", rollout.Policy{WavePercentages: []int{50, 10}}, "must be increasing"),
+		Entry("percentages above 100", rollout.Policy{WavePercentages: []int{150}}, "between 1 and 100"),
+		Entry("a negative threshold", rollout.Policy{WaveLabel: "rollout-wave", FailureThreshold: -1}, "failure threshold"),
+	)
+
+	When("ordering by label", func() {
+		p := rollout.Policy{WaveLabel: "rollout-wave"}
+
+		It("should order waves by label value and put unlabeled members last", func() {
+			plan := p.Plan([]rollout.Member{
+				member("a", "10", rollout.Pending),
+				member("b", "", rollout.Pending),
+				member("c", "2", rollout.Pending),
+				member("d", "invalid", rollout.Pending),
+			})
+			Expect(plan.Waves).To(Equal(3))
+			Expect(plan.WaveOf(key("c"))).To(Equal(0))
+			Expect(plan.WaveOf(key("a"))).To(Equal(1))
+			Expect(plan.WaveOf(key("b"))).To(Equal(2))
+			Expect(plan.WaveOf(key("d"))).To(Equal(2))
+			Expect(plan.WaveOf(key("unknown"))).To(Equal(-1))
+		})
+
+		It("should admit members up to the first wave that is not done", func() {
+			plan := p.Plan([]rollout.Member{
+				member("a", "1", rollout.Done),
+				member("b", "2", rollout.Pending),
+				member("c", "2", rollout.Done),
+				member("d", "3", rollout.Pending),
+			})
+			Expect(plan.Progress).To(Equal(rollout.Progress{Wave: 1, Waves: 3, Done: 1, Pending: 1}))
+			Expect(plan.Admits(key("a"))).To(BeTrue())
+			Expect(plan.Admits(key("b"))).To(BeTrue())
+			Expect(plan.Admits(key("d"))).To(BeFalse())
+			Expect(plan.Admits(key("unknown"))).To(BeFalse())
+		})
+
+		It("should halt when the failures exceed the threshold", func() {
+			members := []rollout.Member{
+				member("a", "1", rollout.Failed),
+				member("b", "1", rollout.Pending),
+				member("c", "1", rollout.Done),
+				member("d", "1", rollout.Done),
+				member("e", "2", rollout.Pending),
+			}
+			plan := rollout.Policy{WaveLabel: "rollout-wave", FailureThreshold: 0.25}.Plan(members)
+			Expect(plan.Halted).To(BeFalse())
+
+			plan = p.Plan(members)
+			Expect(plan.Halted).To(BeTrue())
+			Expect(plan.Admits(key("a"))).To(BeTrue())
+			Expect(plan.Admits(key("b"))).To(BeFalse())
+			Expect(plan.Admits(key("e"))).To(BeFalse())
+		})
+
+		It("should admit the next wave when the failures of a wave are tolerated", func() {
+			members := []rollout.Member{
+				member("a", "1", rollout.Failed),
+				member("b", "1", rollout.Done),
+				member("c", "1", rollout.Done),
+				member("d", "1", rollout.Done),
+				member("e", "2", rollout.Pending),
+			}
+			plan := rollout.Policy{WaveLabel: "rollout-wave", FailureThreshold: 0.25}.Plan(members)
+			Expect(plan.Wave).To(Equal(1))
+			Expect(plan.Halted).To(BeFalse())
+			Expect(plan.Admits(key("a"))).To(BeTrue())
+			Expect(plan.Admits(key("e"))).To(BeTrue())
+
+			By("completing the rollout with tolerated failures")
+			members[4] = member("e", "2", rollout.Done)
+			plan = rollout.Policy{WaveLabel: "rollout-wave", FailureThreshold: 0.25}.Plan(members)
+			Expect(plan.Complete()).To(BeTrue())
+		})
+
+		It("should be complete when all members are done", func() {
+			plan := p.Plan([]rollout.Member{member("a", "1", rollout.Done), member("b", "", rollout.Done)})
+			Expect(plan.Complete()).To(BeTrue())
+			Expect(plan.Admits(key("b"))).To(BeTrue())
+		})
+	})
+
+	When("ordering by percentage", func() {
+		It("should split the members into stable waves of the given sizes", func() {
+			var members []rollout.Member
+			for i := range 20 {
+				members = append(members, member(fmt.Sprintf("cr-%d", i), "", rollout.Pending))
+			}
+			p := rollout.Policy{WavePercentages: []int{10, 50, 100}}
+			plan := p.Plan(members)
+			Expect(plan.Progress).To(Equal(rollout.Progress{Wave: 0, Waves: 3, Pending: 2}))
+
+			sizes := make([]int, plan.Waves)
+			for _, m := range members {
+				sizes[plan.WaveOf(m.Key)]++
+			}
+			Expect(sizes).To(Equal([]int{2, 8, 10}))
+
+			reversed := make([]rollout.Member, 0, len(members))
+			for i := len(members) - 1; i >= 0; i-- {
+				reversed = append(reversed, members[i])
+			}
+			other := p.Plan(reversed)
+			for _, m := range members {
+				Expect(other.WaveOf(m.Key)).To(Equal(plan.WaveOf(m.Key)))
+			}
+		})
+
+		It("should put members beyond the last percentage into a final wave", func() {
+			p := rollout.Policy{WavePercentages: []int{50}}
+			plan := p.Plan([]rollout.Member{member("a", "", rollout.Pending), member("b", "", rollout.Pending), member("c", "", rollout.Pending)})
+			Expect(plan.Waves).To(Equal(2))
+		})
+	})
+})
+
+var _ = Describe("Tracker", func() {
+	reasons := func(events []rollout.Event) []string {
+		var out []string
+		for _, e := range events {
+			out = append(out, e.Reason)
+		}
+		return out
+	}
+
+	It("should report the transitions of a rollout", func() {
+		t := &rollout.Tracker{}
+		Expect(reasons(t.Observe(gvk, rollout.Progress{Wave: 0, Waves: 2, Pending: 2}))).To(Equal([]string{"RolloutWaveStarted"}))
+		Expect(t.Observe(gvk, rollout.Progress{Wave: 0, Waves: 2, Done: 1, Pending: 1})).To(BeEmpty())
+
+		events := t.Observe(gvk, rollout.Progress{Wave: 0, Waves: 2, Done: 1, Failed: 1, Halted: true})
+		Expect(reasons(events)).To(Equal([]string{"RolloutHalted"}))
+		Expect(events[0].Type).To(Equal(corev1.EventTypeWarning))
+		Expect(events[0].Message).To(Equal("Rollout halted in wave 1 of 2: 1 of 2 resources failed"))
+
+		Expect(reasons(t.Observe(gvk, rollout.Progress{Wave: 0, Waves: 2, Done: 1, Pending: 1}))).To(Equal([]string{"RolloutResumed"}))
+		Expect(reasons(t.Observe(gvk, rollout.Progress{Wave: 1, Waves: 2, Pending: 3}))).To(Equal([]string{"RolloutWaveStarted"}))
+		Expect(reasons(t.Observe(gvk, rollout.Progress{Wave: 2, Waves: 2}))).To(Equal([]string{"RolloutCompleted"}))
+		Expect(t.Observe(gvk, rollout.Progress{Wave: 2, Waves: 2})).To(BeEmpty())
+	})
+
+	It("should not report a completed rollout on the first observation", func() {
+		t := &rollout.Tracker{}
+		Expect(t.Observe(gvk, rollout.Progress{Wave: 1, Waves: 1})).To(BeEmpty())
+	})
+})
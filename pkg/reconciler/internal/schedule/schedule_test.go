@@ -0,0 +1,66 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/schedule"
+)
+
+var _ = Describe("Schedule", func() {
+	// 2025-01-01 is a Wednesday.
+	from := time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	DescribeTable("should compute the next match",
+		func(expr string, expected time.Time) {
+			s, err := schedule.Parse(expr)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Next(from)).To(BeTemporally("==", expected))
+		},
+		Entry("every minute", "* * * * *", time.Date(2025, 1, 1, 10, 31, 0, 0, time.UTC)),
+		Entry("later the same hour", "45 * * * *", time.Date(2025, 1, 1, 10, 45, 0, 0, time.UTC)),
+		Entry("the next day", "0 2 * * *", time.Date(2025, 1, 2, 2, 0, 0, 0, time.UTC)),
+		Entry("a day of week", "0 2 * * 6", time.Date(2025, 1, 4, 2, 0, 0, 0, time.UTC)),
+		Entry("Sunday as 7", "0 2 * * 7", time.Date(2025, 1, 5, 2, 0, 0, 0, time.UTC)),
+		Entry("a range of days of week", "0 22 * * 1-5", time.Date(2025, 1, 1, 22, 0, 0, 0, time.UTC)),
+		Entry("a list of hours", "0 4,12 * * *", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+		Entry("a step", "*/20 * * * *", time.Date(2025, 1, 1, 10, 40, 0, 0, time.UTC)),
+		Entry("a step from a value", "5/20 11 * * *", time.Date(2025, 1, 1, 11, 5, 0, 0, time.UTC)),
+		Entry("a month", "0 0 1 3 *", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("either day of month or day of week", "0 0 15 * 5", time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)),
+		Entry("a leap day", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)),
+		Entry("a time zone", "CRON_TZ=Europe/Berlin 0 12 * * *", time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)),
+	)
+
+	DescribeTable("should fail for invalid expressions",
+		func(expr, expectedErr string) {
+			_, err := schedule.Parse(expr)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("too few fields", "0 2 * *", "expected 5 fields, found 4"),
+		Entry("out of range", "0 24 * * *", `invalid hour "24": values must be in range 0-23`),
+		Entry("reversed range", "0 0 * * 5-1", `invalid day of week "5-1"`),
+		Entry("not a number", "x * * * *", `invalid minute "x": invalid value "x"`),
+		Entry("invalid step", "*/0 * * * *", `invalid step "0"`),
+		Entry("unknown time zone", "CRON_TZ=Mars/Olympus 0 0 * * *", "invalid time zone"),
+		Entry("never matching", "0 0 31 2 *", "expression never matches"),
+	)
+})
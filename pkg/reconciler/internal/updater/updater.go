@@ -21,7 +21,9 @@ import (
 
 	"helm.sh/helm/v3/pkg/release"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -165,9 +167,233 @@ func RemoveDeployedRelease() UpdateStatusFunc {
 	return EnsureDeployedRelease(nil)
 }
 
+// ReleaseAttempts counts the failed installs and upgrades of a generation of
+// the custom resource.
+type ReleaseAttempts struct {
+	ObservedGeneration int64       `json:"observedGeneration"`
+	Failed             int         `json:"failed"`
+	LastFailureTime    metav1.Time `json:"lastFailureTime,omitempty"`
+}
+
+// ReleaseAttemptsFor returns the release attempts recorded in the status of
+// obj, or nil if there are none.
+func ReleaseAttemptsFor(obj *unstructured.Unstructured) *ReleaseAttempts {
+	st := statusFor(obj)
+	if st == nil {
+		return nil
+	}
+	return st.ReleaseAttempts
+}
+
+func EnsureReleaseAttempts(attempts *ReleaseAttempts) UpdateStatusFunc {
+	return func(status *helmAppStatus) bool {
+		if status.ReleaseAttempts == nil && attempts == nil {
+			return false
+		}
+		if status.ReleaseAttempts != nil && attempts != nil &&
+			status.ReleaseAttempts.ObservedGeneration == attempts.ObservedGeneration &&
+			status.ReleaseAttempts.Failed == attempts.Failed &&
+			status.ReleaseAttempts.LastFailureTime.Equal(&attempts.LastFailureTime) {
+			return false
+		}
+		status.ReleaseAttempts = attempts
+		return true
+	}
+}
+
+func RemoveReleaseAttempts() UpdateStatusFunc {
+	return EnsureReleaseAttempts(nil)
+}
+
+// PendingUpgrade describes an upgrade of the release that has not been
+// applied yet.
+type PendingUpgrade struct {
+	RevisionHash string `json:"revisionHash"`
+}
+
+func EnsurePendingUpgrade(upgrade *PendingUpgrade) UpdateStatusFunc {
+	return func(status *helmAppStatus) bool {
+		if status.PendingUpgrade == nil && upgrade == nil {
+			return false
+		}
+		if status.PendingUpgrade != nil && upgrade != nil && *status.PendingUpgrade == *upgrade {
+			return false
+		}
+		status.PendingUpgrade = upgrade
+		return true
+	}
+}
+
+func RemovePendingUpgrade() UpdateStatusFunc {
+	return EnsurePendingUpgrade(nil)
+}
+
+// RolledBackUpgrade describes an upgrade of the release that was rolled back
+// because its tests failed.
+type RolledBackUpgrade struct {
+	RevisionHash string `json:"revisionHash"`
+}
+
+// RolledBackUpgradeFor returns the rolled back upgrade recorded in the status
+// of obj, or nil if there is none.
+func RolledBackUpgradeFor(obj *unstructured.Unstructured) *RolledBackUpgrade {
+	st := statusFor(obj)
+	if st == nil {
+		return nil
+	}
+	return st.RolledBackUpgrade
+}
+
+func EnsureRolledBackUpgrade(upgrade *RolledBackUpgrade) UpdateStatusFunc {
+	return func(status *helmAppStatus) bool {
+		if status.RolledBackUpgrade == nil && upgrade == nil {
+			return false
+		}
+		if status.RolledBackUpgrade != nil && upgrade != nil && *status.RolledBackUpgrade == *upgrade {
+			return false
+		}
+		status.RolledBackUpgrade = upgrade
+		return true
+	}
+}
+
+func RemoveRolledBackUpgrade() UpdateStatusFunc {
+	return EnsureRolledBackUpgrade(nil)
+}
+
+// Canary describes the canary release of an upgrade.
+type Canary struct {
+	Name         string      `json:"name"`
+	RevisionHash string      `json:"revisionHash"`
+	StartTime    metav1.Time `json:"startTime"`
+	Aborted      bool        `json:"aborted,omitempty"`
+}
+
+// CanaryFor returns the canary recorded in the status of obj, or nil if there
+// is none.
+func CanaryFor(obj *unstructured.Unstructured) *Canary {
+	st := statusFor(obj)
+	if st == nil {
+		return nil
+	}
+	return st.Canary
+}
+
+func EnsureCanary(canary *Canary) UpdateStatusFunc {
+	return func(status *helmAppStatus) bool {
+		if status.Canary == nil && canary == nil {
+			return false
+		}
+		if status.Canary != nil && canary != nil &&
+			status.Canary.Name == canary.Name &&
+			status.Canary.RevisionHash == canary.RevisionHash &&
+			status.Canary.StartTime.Equal(&canary.StartTime) &&
+			status.Canary.Aborted == canary.Aborted {
+			return false
+		}
+		status.Canary = canary
+		return true
+	}
+}
+
+func RemoveCanary() UpdateStatusFunc {
+	return EnsureCanary(nil)
+}
+
+// TestRun records the outcome of the last run of the tests of a release.
+type TestRun struct {
+	Revision       int          `json:"revision"`
+	CompletionTime metav1.Time  `json:"completionTime"`
+	Results        []TestResult `json:"results,omitempty"`
+}
+
+// TestResult is the outcome of a single test of a release.
+type TestResult struct {
+	Name  string `json:"name"`
+	Phase string `json:"phase"`
+	Log   string `json:"log,omitempty"`
+}
+
+// TestRunFor returns the test run recorded in the status of obj, or nil if
+// there is none.
+func TestRunFor(obj *unstructured.Unstructured) *TestRun {
+	st := statusFor(obj)
+	if st == nil {
+		return nil
+	}
+	return st.TestRun
+}
+
+func EnsureTestRun(run *TestRun) UpdateStatusFunc {
+	return func(status *helmAppStatus) bool {
+		if equality.Semantic.DeepEqual(status.TestRun, run) {
+			return false
+		}
+		status.TestRun = run
+		return true
+	}
+}
+
+// ConditionsFor returns the conditions recorded in the status of obj.
+func ConditionsFor(obj *unstructured.Unstructured) status.Conditions {
+	st := statusFor(obj)
+	if st == nil {
+		return nil
+	}
+	return st.Conditions
+}
+
+// ReconciledChartFor returns the chart that the release of obj was last
+// successfully reconciled with, or "" if it is not known.
+func ReconciledChartFor(obj *unstructured.Unstructured) string {
+	st := statusFor(obj)
+	if st == nil {
+		return ""
+	}
+	return st.ReconciledChart
+}
+
+func EnsureReconciledChart(chart string) UpdateStatusFunc {
+	return func(status *helmAppStatus) bool {
+		if status.ReconciledChart == chart {
+			return false
+		}
+		status.ReconciledChart = chart
+		return true
+	}
+}
+
+// FailedChartFor returns the chart of the last failed install or upgrade of
+// the release of obj, or "" if the release has not failed since it was last
+// reconciled successfully.
+func FailedChartFor(obj *unstructured.Unstructured) string {
+	st := statusFor(obj)
+	if st == nil {
+		return ""
+	}
+	return st.FailedChart
+}
+
+func EnsureFailedChart(chart string) UpdateStatusFunc {
+	return func(status *helmAppStatus) bool {
+		if status.FailedChart == chart {
+			return false
+		}
+		status.FailedChart = chart
+		return true
+	}
+}
+
 type helmAppStatus struct {
-	Conditions      status.Conditions `json:"conditions"`
-	DeployedRelease *helmAppRelease   `json:"deployedRelease,omitempty"`
+	Conditions        status.Conditions  `json:"conditions"`
+	DeployedRelease   *helmAppRelease    `json:"deployedRelease,omitempty"`
+	ReconciledChart   string             `json:"reconciledChart,omitempty"`
+	FailedChart       string             `json:"failedChart,omitempty"`
+	ReleaseAttempts   *ReleaseAttempts   `json:"releaseAttempts,omitempty"`
+	PendingUpgrade    *PendingUpgrade    `json:"pendingUpgrade,omitempty"`
+	RolledBackUpgrade *RolledBackUpgrade `json:"rolledBackUpgrade,omitempty"`
+	Canary            *Canary            `json:"canary,omitempty"`
+	TestRun           *TestRun           `json:"testRun,omitempty"`
 }
 
 type helmAppRelease struct {
@@ -19,6 +19,7 @@ package updater
 import (
 	"context"
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -26,6 +27,7 @@ import (
 	"helm.sh/helm/v3/pkg/release"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -214,6 +216,236 @@ var _ = Describe("RemoveDeployedRelease", func() {
 	})
 })
 
+var _ = Describe("EnsureCanary", func() {
+	var obj *helmAppStatus
+	var canary *Canary
+
+	BeforeEach(func() {
+		obj = &helmAppStatus{}
+		canary = &Canary{
+			Name:         "test-canary",
+			RevisionHash: "abc",
+			StartTime:    metav1.NewTime(time.Now().Truncate(time.Second)),
+		}
+	})
+
+	It("should add the canary if not present", func() {
+		Expect(EnsureCanary(canary)(obj)).To(BeTrue())
+		Expect(obj.Canary).To(Equal(canary))
+	})
+
+	It("should not update an identical canary", func() {
+		obj.Canary = canary
+		same := *canary
+		Expect(EnsureCanary(&same)(obj)).To(BeFalse())
+	})
+
+	It("should update the canary if different", func() {
+		obj.Canary = canary
+		aborted := *canary
+		aborted.Aborted = true
+		Expect(EnsureCanary(&aborted)(obj)).To(BeTrue())
+		Expect(obj.Canary).To(Equal(&aborted))
+	})
+
+	It("should remove the canary", func() {
+		obj.Canary = canary
+		Expect(RemoveCanary()(obj)).To(BeTrue())
+		Expect(obj.Canary).To(BeNil())
+		Expect(RemoveCanary()(obj)).To(BeFalse())
+	})
+
+	It("should read the canary from an unstructured status", func() {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"canary": map[string]interface{}{
+					"name":         "test-canary",
+					"revisionHash": "abc",
+					"startTime":    canary.StartTime.UTC().Format(time.RFC3339),
+					"aborted":      true,
+				},
+			},
+		}}
+		Expect(CanaryFor(u)).To(HaveField("Aborted", true))
+		Expect(CanaryFor(&unstructured.Unstructured{Object: map[string]interface{}{}})).To(BeNil())
+	})
+})
+
+var _ = Describe("EnsureTestRun", func() {
+	It("should set the test run if different", func() {
+		obj := &helmAppStatus{}
+		run := &TestRun{
+			Revision:       2,
+			CompletionTime: metav1.NewTime(time.Now().Truncate(time.Second)),
+			Results:        []TestResult{{Name: "test-connection", Phase: "Failed", Log: "connection refused"}},
+		}
+		Expect(EnsureTestRun(run)(obj)).To(BeTrue())
+		Expect(obj.TestRun).To(Equal(run))
+
+		same := *run
+		same.Results = []TestResult{{Name: "test-connection", Phase: "Failed", Log: "connection refused"}}
+		Expect(EnsureTestRun(&same)(obj)).To(BeFalse())
+
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"testRun": map[string]interface{}{
+					"revision":       int64(2),
+					"completionTime": run.CompletionTime.UTC().Format(time.RFC3339),
+				},
+			},
+		}}
+		Expect(TestRunFor(u)).To(HaveField("Revision", 2))
+	})
+})
+
+var _ = Describe("EnsureReconciledChart", func() {
+	It("should set the reconciled chart if different", func() {
+		obj := &helmAppStatus{}
+		Expect(EnsureReconciledChart("test-chart-1.0.0")(obj)).To(BeTrue())
+		Expect(obj.ReconciledChart).To(Equal("test-chart-1.0.0"))
+		Expect(EnsureReconciledChart("test-chart-1.0.0")(obj)).To(BeFalse())
+	})
+
+	It("should read the reconciled chart and conditions from an unstructured status", func() {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"reconciledChart": "test-chart-1.0.0",
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Deployed", "status": "True"},
+				},
+			},
+		}}
+		Expect(ReconciledChartFor(u)).To(Equal("test-chart-1.0.0"))
+		Expect(ConditionsFor(u).IsTrueFor("Deployed")).To(BeTrue())
+		Expect(ReconciledChartFor(&unstructured.Unstructured{Object: map[string]interface{}{}})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("EnsureFailedChart", func() {
+	It("should set the failed chart if different", func() {
+		obj := &helmAppStatus{}
+		Expect(EnsureFailedChart("test-chart-1.0.0")(obj)).To(BeTrue())
+		Expect(obj.FailedChart).To(Equal("test-chart-1.0.0"))
+		Expect(EnsureFailedChart("test-chart-1.0.0")(obj)).To(BeFalse())
+		Expect(EnsureFailedChart("")(obj)).To(BeTrue())
+		Expect(obj.FailedChart).To(BeEmpty())
+	})
+
+	It("should read the failed chart from an unstructured status", func() {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"failedChart": "test-chart-1.0.0"},
+		}}
+		Expect(FailedChartFor(u)).To(Equal("test-chart-1.0.0"))
+		Expect(FailedChartFor(&unstructured.Unstructured{Object: map[string]interface{}{}})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("EnsureReleaseAttempts", func() {
+	var obj *helmAppStatus
+	var attempts *ReleaseAttempts
+
+	BeforeEach(func() {
+		obj = &helmAppStatus{}
+		attempts = &ReleaseAttempts{
+			ObservedGeneration: 1,
+			Failed:             2,
+			LastFailureTime:    metav1.NewTime(time.Now().Truncate(time.Second)),
+		}
+	})
+
+	It("should add release attempts if not present", func() {
+		Expect(EnsureReleaseAttempts(attempts)(obj)).To(BeTrue())
+		Expect(obj.ReleaseAttempts).To(Equal(attempts))
+	})
+
+	It("should not update identical release attempts", func() {
+		obj.ReleaseAttempts = attempts
+		same := *attempts
+		Expect(EnsureReleaseAttempts(&same)(obj)).To(BeFalse())
+	})
+
+	It("should update release attempts if different", func() {
+		obj.ReleaseAttempts = attempts
+		next := *attempts
+		next.Failed++
+		Expect(EnsureReleaseAttempts(&next)(obj)).To(BeTrue())
+		Expect(obj.ReleaseAttempts).To(Equal(&next))
+	})
+
+	It("should remove release attempts", func() {
+		obj.ReleaseAttempts = attempts
+		Expect(RemoveReleaseAttempts()(obj)).To(BeTrue())
+		Expect(obj.ReleaseAttempts).To(BeNil())
+		Expect(RemoveReleaseAttempts()(obj)).To(BeFalse())
+	})
+
+	It("should read release attempts from an unstructured status", func() {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"releaseAttempts": map[string]interface{}{
+					"observedGeneration": int64(1),
+					"failed":             int64(2),
+					"lastFailureTime":    attempts.LastFailureTime.UTC().Format(time.RFC3339),
+				},
+			},
+		}}
+		Expect(ReleaseAttemptsFor(u)).To(HaveField("Failed", 2))
+		Expect(ReleaseAttemptsFor(u).LastFailureTime.Equal(&attempts.LastFailureTime)).To(BeTrue())
+		Expect(ReleaseAttemptsFor(&unstructured.Unstructured{Object: map[string]interface{}{}})).To(BeNil())
+	})
+})
+
+var _ = Describe("EnsurePendingUpgrade", func() {
+	var obj *helmAppStatus
+
+	BeforeEach(func() {
+		obj = &helmAppStatus{}
+	})
+
+	It("should add the pending upgrade if not present", func() {
+		Expect(EnsurePendingUpgrade(&PendingUpgrade{RevisionHash: "abc"})(obj)).To(BeTrue())
+		Expect(obj.PendingUpgrade).To(Equal(&PendingUpgrade{RevisionHash: "abc"}))
+	})
+
+	It("should not update an identical pending upgrade", func() {
+		obj.PendingUpgrade = &PendingUpgrade{RevisionHash: "abc"}
+		Expect(EnsurePendingUpgrade(&PendingUpgrade{RevisionHash: "abc"})(obj)).To(BeFalse())
+	})
+
+	It("should update the pending upgrade if the hash is different", func() {
+		obj.PendingUpgrade = &PendingUpgrade{RevisionHash: "abc"}
+		Expect(EnsurePendingUpgrade(&PendingUpgrade{RevisionHash: "def"})(obj)).To(BeTrue())
+		Expect(obj.PendingUpgrade).To(Equal(&PendingUpgrade{RevisionHash: "def"}))
+	})
+
+	It("should remove the pending upgrade", func() {
+		obj.PendingUpgrade = &PendingUpgrade{RevisionHash: "abc"}
+		Expect(RemovePendingUpgrade()(obj)).To(BeTrue())
+		Expect(obj.PendingUpgrade).To(BeNil())
+		Expect(RemovePendingUpgrade()(obj)).To(BeFalse())
+	})
+})
+
+var _ = Describe("EnsureRolledBackUpgrade", func() {
+	It("should set, keep and remove the rolled back upgrade", func() {
+		obj := &helmAppStatus{}
+		Expect(EnsureRolledBackUpgrade(&RolledBackUpgrade{RevisionHash: "abc"})(obj)).To(BeTrue())
+		Expect(obj.RolledBackUpgrade).To(Equal(&RolledBackUpgrade{RevisionHash: "abc"}))
+		Expect(EnsureRolledBackUpgrade(&RolledBackUpgrade{RevisionHash: "abc"})(obj)).To(BeFalse())
+		Expect(RemoveRolledBackUpgrade()(obj)).To(BeTrue())
+		Expect(obj.RolledBackUpgrade).To(BeNil())
+		Expect(RemoveRolledBackUpgrade()(obj)).To(BeFalse())
+	})
+
+	It("should read the rolled back upgrade from an unstructured status", func() {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"rolledBackUpgrade": map[string]interface{}{"revisionHash": "abc"}},
+		}}
+		Expect(RolledBackUpgradeFor(u)).To(Equal(&RolledBackUpgrade{RevisionHash: "abc"}))
+		Expect(RolledBackUpgradeFor(&unstructured.Unstructured{Object: map[string]interface{}{}})).To(BeNil())
+	})
+})
+
 var _ = Describe("statusFor", func() {
 	var obj *unstructured.Unstructured
 
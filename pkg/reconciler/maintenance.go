@@ -0,0 +1,170 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/schedule"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+// MaintenanceWindow is a recurring period of time during which upgrades may
+// be applied, see WithMaintenanceWindows.
+type MaintenanceWindow struct {
+	spec     string
+	schedule *schedule.Schedule
+	duration time.Duration
+}
+
+// ParseMaintenanceWindow parses a maintenance window of the form
+// "<cron expression> <duration>", e.g. "0 2 * * 6 4h" for four hours from
+// 02:00 UTC on Saturdays. The cron expression has the five fields minute,
+// hour, day of month, month and day of week, and may be prefixed with
+// "CRON_TZ=<zone>" to use a time zone other than UTC.
+func ParseMaintenanceWindow(s string) (MaintenanceWindow, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return MaintenanceWindow{}, fmt.Errorf("invalid maintenance window %q: expected a cron expression and a duration", s)
+	}
+	duration, err := time.ParseDuration(fields[len(fields)-1])
+	if err != nil || duration <= 0 {
+		return MaintenanceWindow{}, fmt.Errorf("invalid maintenance window %q: duration must be positive", s)
+	}
+	sched, err := schedule.Parse(strings.Join(fields[:len(fields)-1], " "))
+	if err != nil {
+		return MaintenanceWindow{}, fmt.Errorf("invalid maintenance window %q: %w", s, err)
+	}
+	return MaintenanceWindow{spec: strings.Join(fields, " "), schedule: sched, duration: duration}, nil
+}
+
+// ParseMaintenanceWindows parses a semicolon-separated list of maintenance
+// windows, see ParseMaintenanceWindow.
+func ParseMaintenanceWindows(s string) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		w, err := ParseMaintenanceWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// String returns the textual form of w.
+func (w MaintenanceWindow) String() string {
+	return w.spec
+}
+
+// MaintenanceWindowPolicy configures when releases may be changed, see
+// WithMaintenanceWindows.
+type MaintenanceWindowPolicy struct {
+	// Windows are the maintenance windows of custom resources that do not
+	// set the annotation.MaintenanceWindows annotation.
+	Windows []MaintenanceWindow
+
+	// RestrictInstalls defers installs outside of maintenance windows, too.
+	RestrictInstalls bool
+
+	// RestrictDriftCorrection defers reconciling unchanged releases outside
+	// of maintenance windows, too.
+	RestrictDriftCorrection bool
+}
+
+// WithMaintenanceWindows is an Option that restricts upgrades to maintenance
+// windows. Outside of them, upgrades are deferred, the UpgradePending
+// condition gives the start of the next window, and the custom resource is
+// reconciled again when it starts. Custom resources can override the windows
+// with the annotation.MaintenanceWindows annotation, which is also honored
+// without this option.
+//
+// By default, installs and drift correction are not restricted.
+func WithMaintenanceWindows(policy MaintenanceWindowPolicy) Option {
+	return func(r *Reconciler) error {
+		r.maintenanceWindowPolicy = policy
+		return nil
+	}
+}
+
+// maintenanceWindowsFor returns the maintenance windows of obj, which are
+// set by the annotation.MaintenanceWindows annotation or else by
+// WithMaintenanceWindows.
+func (r *Reconciler) maintenanceWindowsFor(obj metav1.Object) ([]MaintenanceWindow, error) {
+	val, ok := obj.GetAnnotations()[annotation.MaintenanceWindows]
+	if !ok {
+		return r.maintenanceWindowPolicy.Windows, nil
+	}
+	windows, err := ParseMaintenanceWindows(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q for annotation %q: %w", val, annotation.MaintenanceWindows, err)
+	}
+	return windows, nil
+}
+
+// nextMaintenanceWindow returns the start of the window of windows that is
+// open at t and true, or else the start of the next window and false.
+func nextMaintenanceWindow(windows []MaintenanceWindow, t time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, w := range windows {
+		start := w.schedule.Next(t.Add(-w.duration))
+		if start.IsZero() {
+			continue
+		}
+		if !start.After(t) {
+			return start, true
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+	return next, false
+}
+
+// checkMaintenanceWindows returns whether the action required by state may be
+// applied now according to windows. If not, it returns when the custom
+// resource should be reconciled again.
+func (r *Reconciler) checkMaintenanceWindows(u *updater.Updater, windows []MaintenanceWindow, state helmReleaseState, log logr.Logger) (ctrl.Result, bool) {
+	if len(windows) == 0 ||
+		state == stateNeedsInstall && !r.maintenanceWindowPolicy.RestrictInstalls ||
+		state == stateUnchanged && !r.maintenanceWindowPolicy.RestrictDriftCorrection {
+		return ctrl.Result{}, true
+	}
+	now := time.Now()
+	next, open := nextMaintenanceWindow(windows, now)
+	if open {
+		return ctrl.Result{}, true
+	}
+	log.V(1).Info("Deferring release until the next maintenance window", "state", state, "nextWindow", next)
+	if state == stateNeedsUpgrade {
+		u.UpdateStatus(updater.EnsureCondition(conditions.UpgradePending(corev1.ConditionTrue, conditions.ReasonOutsideMaintenanceWindow,
+			fmt.Sprintf("upgrade is deferred until the next maintenance window starts at %s", next.UTC().Format(time.RFC3339)))))
+	}
+	return ctrl.Result{RequeueAfter: next.Sub(now)}, false
+}
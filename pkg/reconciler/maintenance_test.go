@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MaintenanceWindow", func() {
+	It("should parse a window with a time zone", func() {
+		w, err := ParseMaintenanceWindow("CRON_TZ=Europe/Berlin  0 2 * * 6   4h")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.String()).To(Equal("CRON_TZ=Europe/Berlin 0 2 * * 6 4h"))
+	})
+	It("should fail without a positive duration", func() {
+		_, err := ParseMaintenanceWindow("0 2 * * 6")
+		Expect(err).To(MatchError(ContainSubstring("duration must be positive")))
+		_, err = ParseMaintenanceWindow("0 2 * * 6 -1h")
+		Expect(err).To(MatchError(ContainSubstring("duration must be positive")))
+	})
+	It("should fail for an invalid schedule", func() {
+		_, err := ParseMaintenanceWindow("0 2 * 6 4h")
+		Expect(err).To(HaveOccurred())
+	})
+	It("should parse a list of windows", func() {
+		windows, err := ParseMaintenanceWindows("0 2 * * 6 4h; 0 22 * * 1-5 1h;")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(HaveLen(2))
+	})
+	It("should find the open or next window", func() {
+		windows, err := ParseMaintenanceWindows("0 2 * * 6 4h;0 22 * * 3 1h")
+		Expect(err).ToNot(HaveOccurred())
+
+		// 2025-01-04 is a Saturday.
+		start, open := nextMaintenanceWindow(windows, time.Date(2025, 1, 4, 5, 59, 0, 0, time.UTC))
+		Expect(open).To(BeTrue())
+		Expect(start).To(BeTemporally("==", time.Date(2025, 1, 4, 2, 0, 0, 0, time.UTC)))
+
+		start, open = nextMaintenanceWindow(windows, time.Date(2025, 1, 4, 6, 0, 0, 0, time.UTC))
+		Expect(open).To(BeFalse())
+		Expect(start).To(BeTemporally("==", time.Date(2025, 1, 8, 22, 0, 0, 0, time.UTC)))
+	})
+})
@@ -0,0 +1,40 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
+)
+
+// isPaused returns whether the reconciliation of obj is paused by the
+// annotation.Paused annotation.
+func isPaused(obj metav1.Object) (bool, error) {
+	val, ok := obj.GetAnnotations()[annotation.Paused]
+	if !ok {
+		return false, nil
+	}
+	paused, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q for annotation %q: must be a boolean", val, annotation.Paused)
+	}
+	return paused, nil
+}
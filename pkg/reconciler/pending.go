@@ -0,0 +1,141 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/internal/status"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+// PendingReleaseRecoveryPolicy determines how a release that is stuck in a
+// pending state is recovered, see WithPendingReleaseRecovery.
+type PendingReleaseRecoveryPolicy string
+
+const (
+	// PendingReleaseRollback rolls the release back to its last deployed
+	// revision. The next reconciliation then upgrades it again.
+	PendingReleaseRollback PendingReleaseRecoveryPolicy = "Rollback"
+
+	// PendingReleaseMarkFailed marks the pending revision as failed, so that
+	// it is upgraded again right away.
+	PendingReleaseMarkFailed PendingReleaseRecoveryPolicy = "MarkFailed"
+)
+
+// WithPendingReleaseRecovery is an Option that configures the recovery of
+// releases that are stuck in a pending state, e.g. because the operator was
+// stopped during an install or upgrade. Helm refuses to upgrade such
+// releases until their pending revision is resolved.
+//
+// If the latest revision of a release has been pending for longer than
+// timeout, it is recovered according to policy. Releases that have never
+// been deployed are uninstalled regardless of the policy, so they are
+// installed again. Each recovery is recorded in the PendingReleaseRecovered
+// condition and as an event.
+//
+// By default, pending releases are not recovered.
+func WithPendingReleaseRecovery(policy PendingReleaseRecoveryPolicy, timeout time.Duration) Option {
+	return func(r *Reconciler) error {
+		if timeout <= 0 {
+			return errors.New("pending release timeout must be a positive value")
+		}
+		switch policy {
+		case PendingReleaseRollback, PendingReleaseMarkFailed:
+		default:
+			return fmt.Errorf("unknown pending release recovery policy %q", policy)
+		}
+		r.pendingReleaseTimeout = timeout
+		r.pendingReleaseRecoveryPolicy = policy
+		return nil
+	}
+}
+
+// recoverPendingRelease recovers rel if pending release recovery is enabled
+// and rel has been pending for longer than the configured timeout.
+func (r *Reconciler) recoverPendingRelease(actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, rel *release.Release, log logr.Logger) error {
+	if r.pendingReleaseTimeout == 0 || rel == nil || rel.Info == nil || !rel.Info.Status.IsPending() {
+		return nil
+	}
+	pendingFor := time.Since(rel.Info.LastDeployed.Time)
+	if pendingFor < r.pendingReleaseTimeout {
+		return nil
+	}
+
+	history, err := actionClient.History(rel.Name)
+	if err != nil {
+		return fmt.Errorf("could not get the history of pending release: %w", err)
+	}
+	var lastDeployed *release.Release
+	for _, h := range history {
+		if h.Version < rel.Version && (h.Info.Status == release.StatusDeployed || h.Info.Status == release.StatusSuperseded) {
+			lastDeployed = h
+			break
+		}
+	}
+
+	pending := fmt.Sprintf("revision %d of release %q was %s for %s", rel.Version, rel.Name, rel.Info.Status, pendingFor.Round(time.Second))
+	var (
+		reason  status.ConditionReason
+		message string
+	)
+	switch {
+	case lastDeployed == nil:
+		log.Info("Uninstalling pending release that was never deployed", "name", rel.Name, "version", rel.Version, "status", rel.Info.Status)
+		if _, err := actionClient.Uninstall(rel.Name); err != nil {
+			return fmt.Errorf("could not uninstall pending release: %w", err)
+		}
+		reason = conditions.ReasonPendingReleaseUninstalled
+		message = fmt.Sprintf("%s and was uninstalled, since it was never deployed", pending)
+		u.UpdateStatus(
+			updater.EnsureCondition(conditions.Deployed(corev1.ConditionFalse, "", "")),
+			updater.RemoveDeployedRelease(),
+		)
+	case r.pendingReleaseRecoveryPolicy == PendingReleaseRollback:
+		log.Info("Rolling back pending release", "name", rel.Name, "version", rel.Version, "status", rel.Info.Status, "toVersion", lastDeployed.Version)
+		if err := actionClient.Rollback(rel.Name, func(rb *action.Rollback) error {
+			rb.Version = lastDeployed.Version
+			rb.MaxHistory = *r.maxReleaseHistory
+			return nil
+		}); err != nil {
+			return fmt.Errorf("could not roll back pending release: %w", err)
+		}
+		reason = conditions.ReasonPendingReleaseRolledBack
+		message = fmt.Sprintf("%s and was rolled back to revision %d", pending, lastDeployed.Version)
+	default:
+		log.Info("Marking pending release as failed", "name", rel.Name, "version", rel.Version, "status", rel.Info.Status)
+		if err := actionClient.MarkFailed(rel, fmt.Sprintf("Marked as failed after being %s for %s", rel.Info.Status, pendingFor.Round(time.Second))); err != nil {
+			return fmt.Errorf("could not mark pending release as failed: %w", err)
+		}
+		reason = conditions.ReasonPendingReleaseMarkedFailed
+		message = fmt.Sprintf("%s and was marked as failed", pending)
+	}
+
+	r.eventRecorder.Event(obj, "Warning", string(reason), message)
+	u.UpdateStatus(updater.EnsureCondition(conditions.PendingReleaseRecovered(corev1.ConditionTrue, reason, message)))
+	return nil
+}
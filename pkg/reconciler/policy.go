@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/policy"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+// WithPolicy is an Option that evaluates p for the rendered manifests of
+// every install and upgrade before it is applied. Installs are rendered by a
+// dry run of the install for this purpose. A release that is denied by any
+// policy is not applied, which is reported by the PolicyViolation condition
+// and a warning event, and it is evaluated again when the custom resource
+// changes or after the reconcile period. The violations of allowed releases
+// are reported by warning events.
+//
+// This option can be used multiple times to evaluate multiple policies.
+func WithPolicy(p policy.Policy) Option {
+	return func(r *Reconciler) error {
+		if p == nil {
+			return errors.New("policy must not be nil")
+		}
+		r.policies = append(r.policies, p)
+		return nil
+	}
+}
+
+// dryRunInstall returns the release that installing the chart for obj with
+// vals would result in.
+func (r *Reconciler) dryRunInstall(actionClient helmclient.ActionInterface, obj *unstructured.Unstructured, vals map[string]interface{}) (*release.Release, error) {
+	opts := r.installOptions(obj)
+	opts = append(opts, func(i *action.Install) error {
+		i.DryRun = true
+		i.DryRunOption = "server"
+		return nil
+	})
+	return actionClient.Install(obj.GetName(), obj.GetNamespace(), r.chrt, vals, opts...)
+}
+
+// maxReportedViolations is the maximum number of policy violations that are
+// listed in the PolicyViolation condition and in events.
+const maxReportedViolations = 5
+
+// checkPolicies returns whether the policies allow the release of desired.
+// Denied releases are reported by the PolicyViolation condition.
+func (r *Reconciler) checkPolicies(ctx context.Context, u *updater.Updater, obj *unstructured.Unstructured, desired *release.Release, log logr.Logger) (bool, error) {
+	var (
+		allowed          = true
+		denied, warnings []policy.Violation
+	)
+	for _, p := range r.policies {
+		decision, err := p.Evaluate(ctx, obj, desired)
+		if err != nil {
+			err = fmt.Errorf("failed to evaluate policy: %w", err)
+			u.UpdateStatus(updater.EnsureCondition(conditions.PolicyViolation(corev1.ConditionUnknown, conditions.ReasonPolicyError, err)))
+			return false, err
+		}
+		if decision.Allowed {
+			warnings = append(warnings, decision.Violations...)
+		} else {
+			allowed = false
+			denied = append(denied, decision.Violations...)
+		}
+	}
+	if !allowed {
+		message := "release is denied by policy"
+		if len(denied) > 0 {
+			message += ": " + violationsMessage(denied)
+		}
+		log.Info("Release is denied by policy", "violations", len(denied))
+		r.eventRecorder.Event(obj, "Warning", string(conditions.ReasonPolicyDenied), message)
+		u.UpdateStatus(updater.EnsureCondition(conditions.PolicyViolation(corev1.ConditionTrue, conditions.ReasonPolicyDenied, message)))
+		return false, nil
+	}
+	message := ""
+	if len(warnings) > 0 {
+		message = "release is allowed by policy with warnings: " + violationsMessage(warnings)
+		r.eventRecorder.Event(obj, "Warning", "PolicyWarning", message)
+	}
+	u.UpdateStatus(updater.EnsureCondition(conditions.PolicyViolation(corev1.ConditionFalse, conditions.ReasonPolicyAllowed, message)))
+	return true, nil
+}
+
+// violationsMessage lists up to maxReportedViolations of violations.
+func violationsMessage(violations []policy.Violation) string {
+	var msgs []string
+	for i, v := range violations {
+		if i == maxReportedViolations {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(violations)-i))
+			break
+		}
+		msgs = append(msgs, v.String())
+	}
+	return strings.Join(msgs, "; ")
+}
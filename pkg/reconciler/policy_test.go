@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/policy"
+	helmfake "github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/fake"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+var _ = Describe("policy gate", func() {
+	var (
+		r        *Reconciler
+		recorder *record.FakeRecorder
+		obj      *unstructured.Unstructured
+		u        updater.Updater
+		rel      *release.Release
+	)
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		r = &Reconciler{eventRecorder: recorder}
+		obj = &unstructured.Unstructured{Object: map[string]interface{}{}}
+		u = updater.New(nil)
+		rel = &release.Release{Name: "test", Manifest: "kind: Pod"}
+	})
+	violations := func(n int) []policy.Violation {
+		var vs []policy.Violation
+		for i := 0; i < n; i++ {
+			vs = append(vs, policy.Violation{Rule: "rule", Message: strconv.Itoa(i)})
+		}
+		return vs
+	}
+
+	It("should render installs with a dry run", func() {
+		ac := helmfake.NewActionClient()
+		ac.HandleInstall = func() (*release.Release, error) { return rel, nil }
+		Expect(r.dryRunInstall(&ac, obj, nil)).To(Equal(rel))
+		Expect(ac.Installs).To(HaveLen(1))
+		install := action.Install{}
+		for _, o := range ac.Installs[0].Opts {
+			Expect(o(&install)).To(Succeed())
+		}
+		Expect(install.DryRun).To(BeTrue())
+		Expect(install.DryRunOption).To(Equal("server"))
+	})
+
+	It("should evaluate every policy with the desired release", func() {
+		var evaluated []*release.Release
+		p := policy.Func(func(_ context.Context, _ *unstructured.Unstructured, rel *release.Release) (policy.Decision, error) {
+			evaluated = append(evaluated, rel)
+			return policy.Allow(), nil
+		})
+		Expect(WithPolicy(p)(r)).To(Succeed())
+		Expect(WithPolicy(p)(r)).To(Succeed())
+		Expect(r.checkPolicies(context.Background(), &u, obj, rel, logr.Discard())).To(BeTrue())
+		Expect(evaluated).To(Equal([]*release.Release{rel, rel}))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should deny releases denied by any policy", func() {
+		Expect(WithPolicy(policy.Func(func(context.Context, *unstructured.Unstructured, *release.Release) (policy.Decision, error) {
+			return policy.Allow(), nil
+		}))(r)).To(Succeed())
+		Expect(WithPolicy(policy.Func(func(context.Context, *unstructured.Unstructured, *release.Release) (policy.Decision, error) {
+			return policy.Deny(violations(7)...), nil
+		}))(r)).To(Succeed())
+		Expect(r.checkPolicies(context.Background(), &u, obj, rel, logr.Discard())).To(BeFalse())
+		Expect(recorder.Events).To(Receive(Equal("Warning PolicyDenied release is denied by policy: rule: 0; rule: 1; rule: 2; rule: 3; rule: 4; and 2 more")))
+	})
+
+	It("should warn about violations of allowed releases", func() {
+		Expect(WithPolicy(policy.Func(func(context.Context, *unstructured.Unstructured, *release.Release) (policy.Decision, error) {
+			return policy.Allow(violations(1)...), nil
+		}))(r)).To(Succeed())
+		Expect(r.checkPolicies(context.Background(), &u, obj, rel, logr.Discard())).To(BeTrue())
+		Expect(recorder.Events).To(Receive(Equal("Warning PolicyWarning release is allowed by policy with warnings: rule: 0")))
+	})
+
+	It("should fail if a policy cannot be evaluated", func() {
+		Expect(WithPolicy(policy.Func(func(context.Context, *unstructured.Unstructured, *release.Release) (policy.Decision, error) {
+			return policy.Decision{}, errors.New("boom")
+		}))(r)).To(Succeed())
+		_, err := r.checkPolicies(context.Background(), &u, obj, rel, logr.Discard())
+		Expect(err).To(MatchError("failed to evaluate policy: boom"))
+	})
+})
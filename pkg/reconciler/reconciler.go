@@ -37,8 +37,11 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -51,9 +54,13 @@ import (
 	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
 	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
 	"github.com/operator-framework/helm-operator-plugins/pkg/hook"
+	internalpredicate "github.com/operator-framework/helm-operator-plugins/pkg/internal/predicate"
+	"github.com/operator-framework/helm-operator-plugins/pkg/policy"
+	"github.com/operator-framework/helm-operator-plugins/pkg/postrenderer"
 	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
 	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/diff"
 	internalhook "github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/hook"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/rollout"
 	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
 	internalvalues "github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/values"
 	"github.com/operator-framework/helm-operator-plugins/pkg/values"
@@ -64,6 +71,7 @@ const uninstallFinalizer = "uninstall-helm-release"
 // Reconciler reconciles a Helm object
 type Reconciler struct {
 	client             client.Client
+	cache              cache.Cache
 	actionClientGetter helmclient.ActionClientGetter
 	valueTranslator    values.Translator
 	valueMapper        values.Mapper // nolint:staticcheck
@@ -75,12 +83,25 @@ type Reconciler struct {
 	gvk                              *schema.GroupVersionKind
 	chrt                             *chart.Chart
 	selectorPredicate                predicate.Predicate
+	namespaces                       []string
 	overrideValues                   map[string]string
 	skipDependentWatches             bool
 	maxConcurrentReconciles          int
 	reconcilePeriod                  time.Duration
 	waitForDeletionTimeout           time.Duration
 	maxReleaseHistory                *int
+	pendingReleaseTimeout            time.Duration
+	pendingReleaseRecoveryPolicy     PendingReleaseRecoveryPolicy
+	retryPolicy                      *RetryPolicy
+	upgradeApprovalRequired          bool
+	maintenanceWindowPolicy          MaintenanceWindowPolicy
+	rolloutPolicy                    *RolloutPolicy
+	rolloutTracker                   rollout.Tracker
+	canaryStrategy                   *CanaryStrategy
+	testPolicy                       *TestPolicy
+	podsGetter                       corev1client.PodsGetter
+	policies                         []policy.Policy
+	commonMetadata                   *postrenderer.CommonMetadata
 	skipPrimaryGVKSchemeRegistration bool
 	controllerSetupFuncs             []ControllerSetupFunc
 
@@ -376,6 +397,19 @@ func WithMaxReleaseHistory(maxHistory int) Option {
 	}
 }
 
+// WithCommonMetadata is an Option that adds the labels and annotations of m
+// to all objects of the releases, see postrenderer.CommonMetadata. They are
+// added after the post-renderers of the action client.
+func WithCommonMetadata(m *postrenderer.CommonMetadata) Option {
+	return func(r *Reconciler) error {
+		if m == nil {
+			return errors.New("common metadata must not be nil")
+		}
+		r.commonMetadata = m
+		return nil
+	}
+}
+
 // WithInstallAnnotations is an Option that configures Install annotations
 // to enable custom action.Install fields to be set based on the value of
 // annotations found in the custom resource watched by this reconciler.
@@ -508,6 +542,40 @@ func WithSelector(s metav1.LabelSelector) Option {
 	}
 }
 
+// WithNamespaces is an Option that configures the reconciler to only watch
+// custom resources and their dependent resources in the given namespaces.
+// Cluster-scoped dependent resources are always watched. If no namespaces are
+// given, resources in all namespaces are watched.
+//
+// This option only filters events. To also limit the manager's cache to the
+// given namespaces, configure its cache options accordingly, e.g. with
+// manager.ConfigureObjectNamespaces.
+func WithNamespaces(namespaces ...string) Option {
+	return func(r *Reconciler) error {
+		for _, ns := range namespaces {
+			if ns == "" {
+				return errors.New("namespace must not be empty")
+			}
+		}
+		r.namespaces = namespaces
+		return nil
+	}
+}
+
+// WithCache is an Option that configures the reconciler to watch and read
+// custom resources from c instead of the manager's cache, e.g. from a cache
+// created by manager.NewNamespaceSelectorCache. Custom resources that are not
+// in c are not reconciled. c must be started by the caller.
+//
+// Unless WithClient is configured, the reconciler's client reads all objects
+// from c.
+func WithCache(c cache.Cache) Option {
+	return func(r *Reconciler) error {
+		r.cache = c
+		return nil
+	}
+}
+
 // WithControllerSetupFunc is an Option that allows customizing a controller before it is started.
 // The only supported customization here is adding additional Watch sources to the controller.
 func WithControllerSetupFunc(f ControllerSetupFunc) Option {
@@ -556,6 +624,17 @@ type ControllerSetupFunc func(c ControllerSetup) error
 //   - Deployed - a release for this CR is deployed (but not necessarily ready).
 //   - ReleaseFailed - an installation or upgrade failed.
 //   - Irreconcilable - an error occurred during reconciliation
+//   - PendingReleaseRecovered - a release that was stuck in a pending state
+//     was recovered, see WithPendingReleaseRecovery.
+//   - Stalled - installs or upgrades are no longer retried until the spec
+//     changes, see WithRetryPolicy.
+//   - Paused - reconciliation is paused by the annotation.Paused annotation.
+//   - UpgradePending - an upgrade is awaiting approval, the next
+//     maintenance window or its rollout wave, see
+//     WithUpgradeApprovalRequired, WithMaintenanceWindows and WithRollout.
+//   - Canary - a canary release verifies an upgrade, see WithCanaryUpgrades.
+//   - Tested - the outcome of the last run of the release tests, see
+//     WithReleaseTests.
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	log := r.log.WithValues(strings.ToLower(r.gvk.Kind), req.NamespacedName)
 	log.V(1).Info("Reconciliation triggered")
@@ -630,6 +709,43 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		return ctrl.Result{}, nil
 	}
 
+	if err := errors.Join(validateAnnotations(obj, r.installAnnotations), validateAnnotations(obj, r.upgradeAnnotations)); err != nil {
+		r.reportInvalidAnnotations(&u, obj, err)
+		return ctrl.Result{}, err
+	}
+
+	paused, err := isPaused(obj)
+	if err != nil {
+		r.reportInvalidAnnotations(&u, obj, err)
+		return ctrl.Result{}, err
+	}
+	if paused {
+		log.V(1).Info("Reconciliation is paused")
+		u.UpdateStatus(updater.EnsureCondition(conditions.Paused(corev1.ConditionTrue, conditions.ReasonPausedByAnnotation,
+			fmt.Sprintf("reconciliation is paused by the %s annotation", annotation.Paused))))
+		return ctrl.Result{}, nil
+	}
+	u.UpdateStatus(updater.EnsureCondition(conditions.Paused(corev1.ConditionFalse, "", "")))
+
+	windows, err := r.maintenanceWindowsFor(obj)
+	if err != nil {
+		r.reportInvalidAnnotations(&u, obj, err)
+		return ctrl.Result{}, err
+	}
+	canaryStrategy, err := r.canaryStrategyFor(obj)
+	if err != nil {
+		r.reportInvalidAnnotations(&u, obj, err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.recoverPendingRelease(actionClient, &u, obj, rel, log); err != nil {
+		u.UpdateStatus(
+			updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionTrue, conditions.ReasonPendingRecoveryError, err)),
+			updater.EnsureConditionUnknown(conditions.TypeReleaseFailed),
+		)
+		return ctrl.Result{}, err
+	}
+
 	vals, err := r.getValues(ctx, obj)
 	if err != nil {
 		u.UpdateStatus(
@@ -639,7 +755,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		return ctrl.Result{}, err
 	}
 
-	rel, state, err := r.getReleaseState(actionClient, obj, vals.AsMap())
+	rel, desiredRel, state, err := r.getReleaseState(actionClient, obj, vals.AsMap())
 	if err != nil {
 		u.UpdateStatus(
 			updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionTrue, conditions.ReasonErrorGettingReleaseState, err)),
@@ -649,25 +765,78 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		)
 		return ctrl.Result{}, err
 	}
+	if state == stateNeedsUpgrade && isRolledBack(obj, rel, desiredRel) {
+		log.V(1).Info("Not retrying upgrade, it was rolled back because its tests failed")
+		return ctrl.Result{RequeueAfter: r.reconcilePeriod}, nil
+	}
+	if state == stateNeedsInstall || state == stateNeedsUpgrade {
+		if result, ok := r.checkReleaseRetry(&u, obj, log); !ok {
+			return result, nil
+		}
+	}
 	u.UpdateStatus(updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionFalse, "", "")))
 
+	if len(r.policies) > 0 && (state == stateNeedsInstall || state == stateNeedsUpgrade) {
+		if state == stateNeedsInstall {
+			desiredRel, err = r.dryRunInstall(actionClient, obj, vals.AsMap())
+			if err != nil {
+				u.UpdateStatus(
+					updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionTrue, conditions.ReasonReconcileError, err)),
+					updater.EnsureCondition(conditions.ReleaseFailed(corev1.ConditionTrue, conditions.ReasonInstallError, err)),
+				)
+				return r.handleReleaseError(&u, obj, err, log)
+			}
+		}
+		allowed, err := r.checkPolicies(ctx, &u, obj, desiredRel, log)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !allowed {
+			return ctrl.Result{RequeueAfter: r.reconcilePeriod}, nil
+		}
+	}
+	if state == stateNeedsUpgrade && r.upgradeApprovalRequired && !r.checkUpgradeApproval(&u, obj, rel, desiredRel, log) {
+		return ctrl.Result{RequeueAfter: r.reconcilePeriod}, nil
+	}
+	if result, ok := r.checkMaintenanceWindows(&u, windows, state, log); !ok {
+		return result, nil
+	}
+	if state == stateNeedsUpgrade && r.rolloutPolicy != nil {
+		result, ok, err := r.checkRollout(ctx, &u, obj, log)
+		if err != nil {
+			u.UpdateStatus(updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionTrue, conditions.ReasonErrorGettingRolloutState, err)))
+			return ctrl.Result{}, err
+		}
+		if !ok {
+			return result, nil
+		}
+	}
+	if state == stateNeedsUpgrade && canaryStrategy != nil {
+		result, ok, err := r.runCanary(ctx, actionClient, &u, obj, vals, rel, desiredRel, *canaryStrategy, log)
+		if err != nil || !ok {
+			return result, err
+		}
+	}
+
 	for _, h := range r.preHooks {
 		if err := h.Exec(obj, vals, log); err != nil {
 			log.Error(err, "pre-release hook failed")
 		}
 	}
 
+	var upgradeHash string
 	switch state {
 	case stateNeedsInstall:
 		rel, err = r.doInstall(actionClient, &u, obj, vals.AsMap(), log)
 		if err != nil {
-			return ctrl.Result{}, err
+			return r.handleReleaseError(&u, obj, err, log)
 		}
 
 	case stateNeedsUpgrade:
+		upgradeHash = revisionHash(rel, desiredRel)
 		rel, err = r.doUpgrade(actionClient, &u, obj, vals.AsMap(), log)
 		if err != nil {
-			return ctrl.Result{}, err
+			return r.handleReleaseError(&u, obj, err, log)
 		}
 
 	case stateUnchanged:
@@ -678,6 +847,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		return ctrl.Result{}, fmt.Errorf("unexpected release state: %s", state)
 	}
 
+	if r.testPolicy != nil && r.testsDue(obj, rel, state) {
+		if err := r.doTest(ctx, actionClient, &u, obj, rel, log); err != nil && state == stateNeedsUpgrade && r.testPolicy.RollbackOnFailure {
+			return r.rollbackFailedTests(actionClient, &u, obj, rel, upgradeHash, err, log)
+		}
+	}
+
 	for _, h := range r.postHooks {
 		if err := h.Exec(obj, *rel, log); err != nil {
 			log.Error(err, "post-release hook failed", "name", rel.Name, "version", rel.Version)
@@ -688,9 +863,34 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 	u.UpdateStatus(
 		updater.EnsureCondition(conditions.ReleaseFailed(corev1.ConditionFalse, "", "")),
 		updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionFalse, "", "")),
+		updater.EnsureReconciledChart(r.chartRevision()),
+		updater.EnsureFailedChart(""),
+		updater.RemoveRolledBackUpgrade(),
 	)
+	if r.retryPolicy != nil {
+		u.UpdateStatus(
+			updater.EnsureCondition(conditions.Stalled(corev1.ConditionFalse, "", "")),
+			updater.RemoveReleaseAttempts(),
+		)
+	}
+	if r.upgradeApprovalRequired || len(windows) > 0 || r.rolloutPolicy != nil {
+		u.UpdateStatus(
+			updater.EnsureCondition(conditions.UpgradePending(corev1.ConditionFalse, "", "")),
+			updater.RemovePendingUpgrade(),
+		)
+	}
+	if canary := updater.CanaryFor(obj); canary != nil {
+		if err := r.uninstallCanary(actionClient, obj, canary.Name, log); err != nil {
+			return ctrl.Result{}, err
+		}
+		u.UpdateStatus(updater.RemoveCanary())
+		if !canary.Aborted && state == stateNeedsUpgrade {
+			u.UpdateStatus(updater.EnsureCondition(conditions.Canary(corev1.ConditionFalse, conditions.ReasonCanaryPromoted,
+				fmt.Sprintf("canary release %s was promoted", canary.Name))))
+		}
+	}
 
-	return ctrl.Result{RequeueAfter: r.reconcilePeriod}, nil
+	return ctrl.Result{RequeueAfter: r.nextReconcile(obj)}, nil
 }
 
 func (r *Reconciler) getValues(ctx context.Context, obj *unstructured.Unstructured) (chartutil.Values, error) {
@@ -754,28 +954,20 @@ func (r *Reconciler) handleDeletion(ctx context.Context, actionClient helmclient
 	return controllerutil.WaitForDeletion(timeoutCtx, r.client, obj)
 }
 
-func (r *Reconciler) getReleaseState(client helmclient.ActionInterface, obj metav1.Object, vals map[string]interface{}) (*release.Release, helmReleaseState, error) {
+// getReleaseState returns the current release of obj, the release that a dry
+// run of an upgrade with vals would result in, and whether the release must
+// be installed or upgraded.
+func (r *Reconciler) getReleaseState(client helmclient.ActionInterface, obj *unstructured.Unstructured, vals map[string]interface{}) (*release.Release, *release.Release, helmReleaseState, error) {
 	currentRelease, err := client.Get(obj.GetName())
 	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
-		return nil, stateError, err
+		return nil, nil, stateError, err
 	}
 
 	if errors.Is(err, driver.ErrReleaseNotFound) {
-		return nil, stateNeedsInstall, nil
+		return nil, nil, stateNeedsInstall, nil
 	}
 
-	var opts []helmclient.UpgradeOption
-	if *r.maxReleaseHistory > 0 {
-		opts = append(opts, func(u *action.Upgrade) error {
-			u.MaxHistory = *r.maxReleaseHistory
-			return nil
-		})
-	}
-	for name, annot := range r.upgradeAnnotations {
-		if v, ok := obj.GetAnnotations()[name]; ok {
-			opts = append(opts, annot.UpgradeOption(v))
-		}
-	}
+	opts := r.upgradeOptions(obj)
 	opts = append(opts, func(u *action.Upgrade) error {
 		u.DryRun = true
 		u.DryRunOption = "server"
@@ -783,28 +975,57 @@ func (r *Reconciler) getReleaseState(client helmclient.ActionInterface, obj meta
 	})
 	specRelease, err := client.Upgrade(obj.GetName(), obj.GetNamespace(), r.chrt, vals, opts...)
 	if err != nil {
-		return currentRelease, stateError, err
+		return currentRelease, nil, stateError, err
 	}
 	if specRelease.Manifest != currentRelease.Manifest ||
 		currentRelease.Info.Status == release.StatusFailed ||
 		currentRelease.Info.Status == release.StatusSuperseded {
-		return currentRelease, stateNeedsUpgrade, nil
+		return currentRelease, specRelease, stateNeedsUpgrade, nil
 	}
-	return currentRelease, stateUnchanged, nil
+	return currentRelease, specRelease, stateUnchanged, nil
 }
 
-func (r *Reconciler) doInstall(actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, vals map[string]interface{}, log logr.Logger) (*release.Release, error) {
+// installOptions returns the options of installs of the release of obj.
+func (r *Reconciler) installOptions(obj *unstructured.Unstructured) []helmclient.InstallOption {
 	var opts []helmclient.InstallOption
 	for name, annot := range r.installAnnotations {
 		if v, ok := obj.GetAnnotations()[name]; ok {
 			opts = append(opts, annot.InstallOption(v))
 		}
 	}
-	rel, err := actionClient.Install(obj.GetName(), obj.GetNamespace(), r.chrt, vals, opts...)
+	if r.commonMetadata != nil {
+		opts = append(opts, helmclient.AppendInstallPostRenderer(r.commonMetadata.PostRenderer(obj)))
+	}
+	return opts
+}
+
+// upgradeOptions returns the options of upgrades of the release of obj.
+func (r *Reconciler) upgradeOptions(obj *unstructured.Unstructured) []helmclient.UpgradeOption {
+	var opts []helmclient.UpgradeOption
+	if *r.maxReleaseHistory > 0 {
+		opts = append(opts, func(u *action.Upgrade) error {
+			u.MaxHistory = *r.maxReleaseHistory
+			return nil
+		})
+	}
+	for name, annot := range r.upgradeAnnotations {
+		if v, ok := obj.GetAnnotations()[name]; ok {
+			opts = append(opts, annot.UpgradeOption(v))
+		}
+	}
+	if r.commonMetadata != nil {
+		opts = append(opts, helmclient.AppendUpgradePostRenderer(r.commonMetadata.PostRenderer(obj)))
+	}
+	return opts
+}
+
+func (r *Reconciler) doInstall(actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, vals map[string]interface{}, log logr.Logger) (*release.Release, error) {
+	rel, err := actionClient.Install(obj.GetName(), obj.GetNamespace(), r.chrt, vals, r.installOptions(obj)...)
 	if err != nil {
 		u.UpdateStatus(
 			updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionTrue, conditions.ReasonReconcileError, err)),
 			updater.EnsureCondition(conditions.ReleaseFailed(corev1.ConditionTrue, conditions.ReasonInstallError, err)),
+			updater.EnsureFailedChart(r.chartRevision()),
 		)
 		return nil, err
 	}
@@ -821,18 +1042,7 @@ func (r *Reconciler) doInstall(actionClient helmclient.ActionInterface, u *updat
 }
 
 func (r *Reconciler) doUpgrade(actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, vals map[string]interface{}, log logr.Logger) (*release.Release, error) {
-	var opts []helmclient.UpgradeOption
-	if *r.maxReleaseHistory > 0 {
-		opts = append(opts, func(u *action.Upgrade) error {
-			u.MaxHistory = *r.maxReleaseHistory
-			return nil
-		})
-	}
-	for name, annot := range r.upgradeAnnotations {
-		if v, ok := obj.GetAnnotations()[name]; ok {
-			opts = append(opts, annot.UpgradeOption(v))
-		}
-	}
+	opts := r.upgradeOptions(obj)
 
 	// Get the current release so we can compare the new release in the diff if the diff is being logged.
 	curRel, err := actionClient.Get(obj.GetName())
@@ -845,6 +1055,7 @@ func (r *Reconciler) doUpgrade(actionClient helmclient.ActionInterface, u *updat
 		u.UpdateStatus(
 			updater.EnsureCondition(conditions.Irreconcilable(corev1.ConditionTrue, conditions.ReasonReconcileError, err)),
 			updater.EnsureCondition(conditions.ReleaseFailed(corev1.ConditionTrue, conditions.ReasonUpgradeError, err)),
+			updater.EnsureFailedChart(r.chartRevision()),
 		)
 		return nil, err
 	}
@@ -887,6 +1098,11 @@ func (r *Reconciler) doReconcile(actionClient helmclient.ActionInterface, u *upd
 }
 
 func (r *Reconciler) doUninstall(actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, log logr.Logger) error {
+	if err := validateAnnotations(obj, r.uninstallAnnotations); err != nil {
+		r.reportInvalidAnnotations(u, obj, err)
+		return err
+	}
+
 	var opts []helmclient.UninstallOption
 	for name, annot := range r.uninstallAnnotations {
 		if v, ok := obj.GetAnnotations()[name]; ok {
@@ -911,6 +1127,13 @@ func (r *Reconciler) doUninstall(actionClient helmclient.ActionInterface, u *upd
 			fmt.Println(diff.Generate(resp.Release.Manifest, ""))
 		}
 	}
+	if canary := updater.CanaryFor(obj); canary != nil {
+		if err := r.uninstallCanary(actionClient, obj, canary.Name, log); err != nil {
+			u.UpdateStatus(updater.EnsureCondition(conditions.ReleaseFailed(corev1.ConditionTrue, conditions.ReasonUninstallError, err)))
+			return err
+		}
+		u.UpdateStatus(updater.RemoveCanary())
+	}
 	u.Update(updater.RemoveFinalizer(uninstallFinalizer))
 	u.UpdateStatus(
 		updater.EnsureCondition(conditions.ReleaseFailed(corev1.ConditionFalse, "", "")),
@@ -920,6 +1143,15 @@ func (r *Reconciler) doUninstall(actionClient helmclient.ActionInterface, u *upd
 	return nil
 }
 
+// chartRevision identifies the chart of the reconciler, see
+// updater.EnsureReconciledChart.
+func (r *Reconciler) chartRevision() string {
+	if r.chrt == nil || r.chrt.Metadata == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s-%s", r.chrt.Metadata.Name, r.chrt.Metadata.Version)
+}
+
 func (r *Reconciler) validate() error {
 	if r.gvk == nil {
 		return errors.New("gvk must not be nil")
@@ -931,6 +1163,18 @@ func (r *Reconciler) validate() error {
 }
 
 func (r *Reconciler) addDefaults(mgr ctrl.Manager, controllerName string) error {
+	if r.client == nil && r.cache != nil {
+		cl, err := client.New(mgr.GetConfig(), client.Options{
+			HTTPClient: mgr.GetHTTPClient(),
+			Scheme:     mgr.GetScheme(),
+			Mapper:     mgr.GetRESTMapper(),
+			Cache:      &client.CacheOptions{Reader: r.cache},
+		})
+		if err != nil {
+			return fmt.Errorf("creating client: %w", err)
+		}
+		r.client = cl
+	}
 	if r.client == nil {
 		r.client = mgr.GetClient()
 	}
@@ -950,6 +1194,13 @@ func (r *Reconciler) addDefaults(mgr ctrl.Manager, controllerName string) error
 	if r.eventRecorder == nil {
 		r.eventRecorder = mgr.GetEventRecorderFor(controllerName)
 	}
+	if r.testPolicy != nil && r.podsGetter == nil {
+		clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			return fmt.Errorf("creating kubernetes client: %w", err)
+		}
+		r.podsGetter = clientset.CoreV1()
+	}
 	if r.valueTranslator == nil {
 		r.valueTranslator = internalvalues.DefaultTranslator
 	}
@@ -981,10 +1232,17 @@ func (r *Reconciler) setupWatches(mgr ctrl.Manager, c controller.Controller) err
 	if r.selectorPredicate != nil {
 		preds = append(preds, r.selectorPredicate)
 	}
+	if len(r.namespaces) > 0 {
+		preds = append(preds, internalpredicate.NamespacePredicate[client.Object](r.namespaces...))
+	}
 
+	crCache := mgr.GetCache()
+	if r.cache != nil {
+		crCache = r.cache
+	}
 	if err := c.Watch(
 		source.Kind(
-			mgr.GetCache(),
+			crCache,
 			client.Object(obj),
 			&sdkhandler.InstrumentedEnqueueRequestForObject[client.Object]{},
 			preds...,
@@ -1016,7 +1274,11 @@ func (r *Reconciler) setupWatches(mgr ctrl.Manager, c controller.Controller) err
 	}
 
 	if !r.skipDependentWatches {
-		r.postHooks = append([]hook.PostHook{internalhook.NewDependentResourceWatcher(c, mgr.GetRESTMapper(), mgr.GetCache(), mgr.GetScheme())}, r.postHooks...)
+		var dependentPreds []predicate.TypedPredicate[*unstructured.Unstructured]
+		if len(r.namespaces) > 0 {
+			dependentPreds = append(dependentPreds, internalpredicate.NamespacePredicate[*unstructured.Unstructured](r.namespaces...))
+		}
+		r.postHooks = append([]hook.PostHook{internalhook.NewDependentResourceWatcher(c, mgr.GetRESTMapper(), mgr.GetCache(), mgr.GetScheme(), dependentPreds...)}, r.postHooks...)
 	}
 	return nil
 }
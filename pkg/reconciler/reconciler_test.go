@@ -35,6 +35,7 @@ import (
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -45,6 +46,7 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
@@ -65,6 +67,8 @@ import (
 	"github.com/operator-framework/helm-operator-plugins/pkg/hook"
 	"github.com/operator-framework/helm-operator-plugins/pkg/internal/status"
 	"github.com/operator-framework/helm-operator-plugins/pkg/internal/testutil"
+	"github.com/operator-framework/helm-operator-plugins/pkg/policy"
+	"github.com/operator-framework/helm-operator-plugins/pkg/postrenderer"
 	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
 	helmfake "github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/fake"
 	"github.com/operator-framework/helm-operator-plugins/pkg/values"
@@ -244,6 +248,145 @@ var _ = Describe("Reconciler", func() {
 				Expect(WithMaxReleaseHistory(-1)(r)).NotTo(Succeed())
 			})
 		})
+		_ = Describe("WithPendingReleaseRecovery", func() {
+			It("should set the pending release timeout and recovery policy", func() {
+				Expect(WithPendingReleaseRecovery(PendingReleaseRollback, time.Minute)(r)).To(Succeed())
+				Expect(r.pendingReleaseTimeout).To(Equal(time.Minute))
+				Expect(r.pendingReleaseRecoveryPolicy).To(Equal(PendingReleaseRollback))
+			})
+			It("should fail if the timeout is not positive", func() {
+				Expect(WithPendingReleaseRecovery(PendingReleaseMarkFailed, 0)(r)).NotTo(Succeed())
+			})
+			It("should fail if the policy is unknown", func() {
+				Expect(WithPendingReleaseRecovery("Retry", time.Minute)(r)).NotTo(Succeed())
+			})
+		})
+		_ = Describe("RetryPolicy", func() {
+			It("should back off exponentially up to the maximum", func() {
+				p := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, Factor: 3, MaxBackoff: 20 * time.Second, SlowRetryInterval: time.Hour}
+				for failed, expected := range map[int]time.Duration{1: time.Second, 2: 3 * time.Second, 3: 9 * time.Second, 4: 20 * time.Second} {
+					d, exhausted := p.delay(failed)
+					Expect(d).To(Equal(expected))
+					Expect(exhausted).To(BeFalse())
+				}
+				d, exhausted := p.delay(5)
+				Expect(d).To(Equal(time.Hour))
+				Expect(exhausted).To(BeTrue())
+			})
+		})
+		_ = Describe("WithRetryPolicy", func() {
+			It("should set the retry policy with defaults", func() {
+				Expect(WithRetryPolicy(RetryPolicy{MaxAttempts: 3})(r)).To(Succeed())
+				Expect(r.retryPolicy).To(Equal(&RetryPolicy{
+					MaxAttempts:       3,
+					InitialBackoff:    5 * time.Second,
+					Factor:            2,
+					MaxBackoff:        5 * time.Minute,
+					OnExhausted:       RetryExhaustedStall,
+					SlowRetryInterval: time.Hour,
+				}))
+			})
+			It("should fail if a value is negative", func() {
+				Expect(WithRetryPolicy(RetryPolicy{MaxAttempts: -1})(r)).NotTo(Succeed())
+				Expect(WithRetryPolicy(RetryPolicy{MaxBackoff: -time.Second})(r)).NotTo(Succeed())
+			})
+			It("should fail if the factor is less than 1", func() {
+				Expect(WithRetryPolicy(RetryPolicy{Factor: 0.5})(r)).NotTo(Succeed())
+			})
+			It("should fail if the exhausted action is unknown", func() {
+				Expect(WithRetryPolicy(RetryPolicy{OnExhausted: "GiveUp"})(r)).NotTo(Succeed())
+			})
+		})
+		_ = Describe("WithUpgradeApprovalRequired", func() {
+			It("should require upgrade approval", func() {
+				Expect(WithUpgradeApprovalRequired(true)(r)).To(Succeed())
+				Expect(r.upgradeApprovalRequired).To(BeTrue())
+			})
+		})
+		_ = Describe("WithMaintenanceWindows", func() {
+			It("should set the maintenance window policy", func() {
+				w, err := ParseMaintenanceWindow("0 2 * * 6 4h")
+				Expect(err).ToNot(HaveOccurred())
+				policy := MaintenanceWindowPolicy{Windows: []MaintenanceWindow{w}, RestrictInstalls: true}
+				Expect(WithMaintenanceWindows(policy)(r)).To(Succeed())
+				Expect(r.maintenanceWindowPolicy).To(Equal(policy))
+			})
+		})
+		_ = Describe("WithCanaryUpgrades", func() {
+			It("should set the canary strategy with defaults", func() {
+				Expect(WithCanaryUpgrades(CanaryStrategy{CheckInterval: time.Minute})(r)).To(Succeed())
+				Expect(r.canaryStrategy).To(Equal(&CanaryStrategy{Timeout: 10 * time.Minute, CheckInterval: time.Minute}))
+			})
+			It("should fail if the timeout is negative", func() {
+				Expect(WithCanaryUpgrades(CanaryStrategy{Timeout: -time.Second})(r)).NotTo(Succeed())
+			})
+		})
+		_ = Describe("WithReleaseTests", func() {
+			It("should set the test policy with defaults", func() {
+				Expect(WithReleaseTests(TestPolicy{AfterRelease: true})(r)).To(Succeed())
+				Expect(r.testPolicy).To(Equal(&TestPolicy{AfterRelease: true, Timeout: 5 * time.Minute, LogLines: 10}))
+			})
+			It("should fail if tests never run", func() {
+				Expect(WithReleaseTests(TestPolicy{RollbackOnFailure: true})(r)).NotTo(Succeed())
+			})
+			It("should fail if the interval is negative", func() {
+				Expect(WithReleaseTests(TestPolicy{AfterRelease: true, Interval: -time.Second})(r)).NotTo(Succeed())
+			})
+		})
+		_ = Describe("WithPolicy", func() {
+			It("should append the policies", func() {
+				Expect(WithPolicy(policy.Rules{DenyPrivileged: true})(r)).To(Succeed())
+				Expect(WithPolicy(policy.Rules{DenyLatestTag: true})(r)).To(Succeed())
+				Expect(r.policies).To(Equal([]policy.Policy{policy.Rules{DenyPrivileged: true}, policy.Rules{DenyLatestTag: true}}))
+			})
+			It("should fail for a nil policy", func() {
+				Expect(WithPolicy(nil)(r)).NotTo(Succeed())
+			})
+		})
+		_ = Describe("WithCommonMetadata", func() {
+			It("should set the common metadata", func() {
+				m, err := postrenderer.NewCommonMetadata(map[string]string{"team": "{{ .CR.metadata.labels.team }}"}, nil, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(WithCommonMetadata(m)(r)).To(Succeed())
+				Expect(r.commonMetadata).To(Equal(m))
+			})
+			It("should add the post-renderer to installs and upgrades", func() {
+				m, err := postrenderer.NewCommonMetadata(map[string]string{"team": "payments"}, nil, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(WithCommonMetadata(m)(r)).To(Succeed())
+				Expect(WithMaxReleaseHistory(0)(r)).To(Succeed())
+				obj := &unstructured.Unstructured{}
+
+				install := &action.Install{}
+				for _, o := range r.installOptions(obj) {
+					Expect(o(install)).To(Succeed())
+				}
+				Expect(install.PostRenderer).NotTo(BeNil())
+				upgrade := &action.Upgrade{}
+				for _, o := range r.upgradeOptions(obj) {
+					Expect(o(upgrade)).To(Succeed())
+				}
+				Expect(upgrade.PostRenderer).NotTo(BeNil())
+			})
+			It("should fail for nil common metadata", func() {
+				Expect(WithCommonMetadata(nil)(r)).NotTo(Succeed())
+			})
+		})
+		_ = Describe("WithRollout", func() {
+			It("should set the rollout policy with a default progress interval", func() {
+				Expect(WithRollout(RolloutPolicy{WaveLabel: "rollout-wave", FailureThreshold: 0.1})(r)).To(Succeed())
+				Expect(r.rolloutPolicy).To(Equal(&RolloutPolicy{WaveLabel: "rollout-wave", FailureThreshold: 0.1, ProgressInterval: 30 * time.Second}))
+			})
+			It("should fail without waves", func() {
+				Expect(WithRollout(RolloutPolicy{})(r)).NotTo(Succeed())
+			})
+			It("should fail if the wave percentages are not increasing", func() {
+				Expect(WithRollout(RolloutPolicy{WavePercentages: []int{50, 10}})(r)).NotTo(Succeed())
+			})
+			It("should fail if the progress interval is negative", func() {
+				Expect(WithRollout(RolloutPolicy{WaveLabel: "rollout-wave", ProgressInterval: -time.Second})(r)).NotTo(Succeed())
+			})
+		})
 		_ = Describe("WithInstallAnnotations", func() {
 			It("should set multiple reconciler install annotations", func() {
 				a1 := annotation.InstallDisableHooks{CustomName: "my.domain/custom-name1"}
@@ -463,6 +606,22 @@ var _ = Describe("Reconciler", func() {
 				Expect(r.selectorPredicate.Generic(event.GenericEvent{Object: objUnlabeled})).To(BeFalse())
 			})
 		})
+		_ = Describe("WithNamespaces", func() {
+			It("should set the reconciler namespaces", func() {
+				Expect(WithNamespaces("ns1", "ns2")(r)).To(Succeed())
+				Expect(r.namespaces).To(Equal([]string{"ns1", "ns2"}))
+			})
+			It("should fail for an empty namespace", func() {
+				Expect(WithNamespaces("ns1", "")(r)).NotTo(Succeed())
+			})
+		})
+		_ = Describe("WithCache", func() {
+			It("should set the reconciler cache", func() {
+				c := &informertest.FakeInformers{}
+				Expect(WithCache(c)(r)).To(Succeed())
+				Expect(r.cache).To(BeIdenticalTo(c))
+			})
+		})
 	})
 
 	_ = Describe("Reconcile", func() {
@@ -703,6 +862,46 @@ var _ = Describe("Reconciler", func() {
 							})
 						})
 					})
+					When("an annotation is invalid", func() {
+						BeforeEach(func() {
+							Expect(WithInstallAnnotations(annotation.InstallDisableHooks{})(r)).To(Succeed())
+							Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+							obj.SetAnnotations(map[string]string{"helm.sdk.operatorframework.io/install-disable-hooks": "maybe"})
+							Expect(mgr.GetClient().Update(ctx, obj)).To(Succeed())
+							Eventually(func(g Gomega) {
+								g.Expect(mgr.GetClient().Get(ctx, objKey, obj)).To(Succeed())
+								g.Expect(obj.GetAnnotations()).To(HaveKey("helm.sdk.operatorframework.io/install-disable-hooks"))
+							}).Should(Succeed())
+						})
+						It("reports the annotation instead of installing the release", func() {
+							const message = `invalid value "maybe" for annotation "helm.sdk.operatorframework.io/install-disable-hooks": must be a boolean`
+
+							By("reconciling unsuccessfully", func() {
+								_, err := r.Reconcile(ctx, req)
+								Expect(err).To(MatchError(message))
+							})
+
+							By("verifying the release is not installed", func() {
+								_, err := ac.Get(obj.GetName())
+								Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+							})
+
+							By("verifying the CR status", func() {
+								Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+								objStat := &objStatus{}
+								Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+								c := objStat.Status.Conditions.GetCondition(conditions.TypeIrreconcilable)
+								Expect(c).NotTo(BeNil())
+								Expect(c.Status).To(Equal(corev1.ConditionTrue))
+								Expect(c.Reason).To(Equal(conditions.ReasonInvalidAnnotation))
+								Expect(c.Message).To(Equal(message))
+							})
+
+							By("verifying the event", func() {
+								verifyEvent(ctx, mgr.GetAPIReader(), obj, "Warning", string(conditions.ReasonInvalidAnnotation), message)
+							})
+						})
+					})
 					When("cache contains stale CR that has actually been deleted", func() {
 						// This test simulates what we expect to happen when we time out waiting for a CR that we
 						// deleted to be removed from the cache.
@@ -863,6 +1062,62 @@ var _ = Describe("Reconciler", func() {
 									Expect(controllerutil.ContainsFinalizer(obj, uninstallFinalizer)).To(BeTrue())
 								})
 							})
+							When("a retry policy is configured", func() {
+								BeforeEach(func() {
+									Expect(WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})(r)).To(Succeed())
+								})
+								getStatus := func() *objStatus {
+									objStat := &objStatus{}
+									Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+									Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+									return objStat
+								}
+								It("backs off and stalls once the retries are exhausted", func() {
+									By("requeueing after the initial backoff", func() {
+										res, err := r.Reconcile(ctx, req)
+										Expect(err).ToNot(HaveOccurred())
+										Expect(res.RequeueAfter).To(Equal(time.Millisecond))
+										objStat := getStatus()
+										Expect(objStat.Status.ReleaseAttempts).NotTo(BeNil())
+										Expect(objStat.Status.ReleaseAttempts.Failed).To(Equal(1))
+										Expect(objStat.Status.ReleaseAttempts.ObservedGeneration).To(Equal(obj.GetGeneration()))
+										Expect(objStat.Status.Conditions.IsTrueFor(conditions.TypeReleaseFailed)).To(BeTrue())
+									})
+
+									By("stalling after the last attempt", func() {
+										Eventually(func(g Gomega) {
+											_, err := r.Reconcile(ctx, req)
+											g.Expect(err).ToNot(HaveOccurred())
+											objStat := getStatus()
+											g.Expect(objStat.Status.ReleaseAttempts.Failed).To(Equal(2))
+											g.Expect(objStat.Status.Conditions.IsTrueFor(conditions.TypeStalled)).To(BeTrue())
+										}).Should(Succeed())
+										c := getStatus().Status.Conditions.GetCondition(conditions.TypeStalled)
+										Expect(c.Reason).To(Equal(conditions.ReasonRetriesExhausted))
+									})
+
+									By("not retrying until the spec changes", func() {
+										res, err := r.Reconcile(ctx, req)
+										Expect(err).ToNot(HaveOccurred())
+										Expect(res).To(Equal(reconcile.Result{}))
+										Expect(getStatus().Status.ReleaseAttempts.Failed).To(Equal(2))
+									})
+
+									By("retrying and clearing the stalled condition once the spec changes", func() {
+										Expect(unstructured.SetNestedField(obj.Object, "changed", "spec", "retryTrigger")).To(Succeed())
+										Expect(mgr.GetClient().Update(ctx, obj)).To(Succeed())
+										generation := obj.GetGeneration()
+										Eventually(func(g Gomega) {
+											_, err := r.Reconcile(ctx, req)
+											g.Expect(err).ToNot(HaveOccurred())
+											objStat := getStatus()
+											g.Expect(objStat.Status.ReleaseAttempts.ObservedGeneration).To(Equal(generation))
+											g.Expect(objStat.Status.ReleaseAttempts.Failed).To(Equal(1))
+											g.Expect(objStat.Status.Conditions.IsFalseFor(conditions.TypeStalled)).To(BeTrue())
+										}).Should(Succeed())
+									})
+								})
+							})
 						})
 						When("installation succeeds", func() {
 							It("installs the release", func() {
@@ -1133,6 +1388,85 @@ var _ = Describe("Reconciler", func() {
 							})
 						})
 					})
+					When("requested CR release is stuck pending", func() {
+						var actionConf *action.Configuration
+						BeforeEach(func() {
+							acg, err := helmclient.NewActionConfigGetter(mgr.GetConfig(), mgr.GetRESTMapper())
+							Expect(err).ShouldNot(HaveOccurred())
+							actionConf, err = acg.ActionConfigFor(ctx, obj)
+							Expect(err).ToNot(HaveOccurred())
+						})
+
+						setPendingUpgrade := func() {
+							pendingRelease := *currentRelease
+							pendingRelease.Info = &release.Info{
+								FirstDeployed: currentRelease.Info.FirstDeployed,
+								LastDeployed:  helmtime.Time{Time: time.Now().Add(-time.Hour)},
+								Status:        release.StatusPendingUpgrade,
+							}
+							pendingRelease.Version = currentRelease.Version + 1
+							Expect(actionConf.Releases.Create(&pendingRelease)).To(Succeed())
+						}
+						verifyRecovery := func(version int, reason status.ConditionReason) {
+							GinkgoHelper()
+							rel, err := ac.Get(obj.GetName())
+							Expect(err).ToNot(HaveOccurred())
+							Expect(rel.Version).To(Equal(version))
+							Expect(rel.Info.Status).To(Equal(release.StatusDeployed))
+
+							Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+							objStat := &objStatus{}
+							Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+							c := objStat.Status.Conditions.GetCondition(conditions.TypePendingReleaseRecovered)
+							Expect(c).NotTo(BeNil())
+							Expect(c.Status).To(Equal(corev1.ConditionTrue))
+							Expect(c.Reason).To(Equal(reason))
+							verifyEvent(ctx, mgr.GetAPIReader(), obj, "Warning", string(reason), c.Message)
+						}
+
+						It("does not recover it if recovery is disabled", func() {
+							setPendingUpgrade()
+							_, err := r.Reconcile(ctx, req)
+							Expect(err).To(MatchError(ContainSubstring("another operation (install/upgrade/rollback) is in progress")))
+						})
+						It("does not recover it before the timeout", func() {
+							Expect(WithPendingReleaseRecovery(PendingReleaseRollback, 2*time.Hour)(r)).To(Succeed())
+							setPendingUpgrade()
+							_, err := r.Reconcile(ctx, req)
+							Expect(err).To(HaveOccurred())
+						})
+						It("rolls back a pending upgrade", func() {
+							Expect(WithPendingReleaseRecovery(PendingReleaseRollback, time.Minute)(r)).To(Succeed())
+							setPendingUpgrade()
+							res, err := r.Reconcile(ctx, req)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(res).To(Equal(reconcile.Result{}))
+							verifyRecovery(3, conditions.ReasonPendingReleaseRolledBack)
+						})
+						It("marks a pending upgrade as failed and upgrades it again", func() {
+							Expect(WithPendingReleaseRecovery(PendingReleaseMarkFailed, time.Minute)(r)).To(Succeed())
+							setPendingUpgrade()
+							res, err := r.Reconcile(ctx, req)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(res).To(Equal(reconcile.Result{}))
+							verifyRecovery(3, conditions.ReasonPendingReleaseMarkedFailed)
+
+							failed, err := ac.Get(obj.GetName(), func(g *action.Get) error { g.Version = 2; return nil })
+							Expect(err).ToNot(HaveOccurred())
+							Expect(failed.Info.Status).To(Equal(release.StatusFailed))
+						})
+						It("uninstalls and installs again a pending install", func() {
+							Expect(WithPendingReleaseRecovery(PendingReleaseMarkFailed, time.Minute)(r)).To(Succeed())
+							currentRelease.Info.Status = release.StatusPendingInstall
+							currentRelease.Info.LastDeployed = helmtime.Time{Time: time.Now().Add(-time.Hour)}
+							Expect(actionConf.Releases.Update(currentRelease)).To(Succeed())
+
+							res, err := r.Reconcile(ctx, req)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(res).To(Equal(reconcile.Result{}))
+							verifyRecovery(1, conditions.ReasonPendingReleaseUninstalled)
+						})
+					})
 					When("state is Deployed", func() {
 						When("upgrade fails", func() {
 							BeforeEach(func() {
@@ -1187,6 +1521,54 @@ var _ = Describe("Reconciler", func() {
 								})
 							})
 						})
+						When("the tests of the upgrade fail", func() {
+							var ac helmfake.ActionClient
+							BeforeEach(func() {
+								Expect(WithReleaseTests(TestPolicy{AfterRelease: true, RollbackOnFailure: true})(r)).To(Succeed())
+								ac = helmfake.NewActionClient()
+								ac.HandleGet = func() (*release.Release, error) {
+									return &release.Release{Name: "test", Version: 1, Manifest: "manifest: 1", Info: &release.Info{Status: release.StatusDeployed}}, nil
+								}
+								ac.HandleUpgrade = func() (*release.Release, error) {
+									return &release.Release{Name: "test", Version: 2, Manifest: "manifest: 2", Info: &release.Info{Status: release.StatusDeployed}}, nil
+								}
+								ac.HandleTest = func() (*release.Release, error) {
+									return &release.Release{Name: "test", Version: 2}, errors.New("tests failed: foobar")
+								}
+								ac.HandleRollback = func() error { return nil }
+								r.actionClientGetter = helmfake.NewActionClientGetter(&ac, nil)
+							})
+							It("rolls back the upgrade and does not retry it", func() {
+								By("rolling back the upgrade", func() {
+									_, err := r.Reconcile(ctx, req)
+									Expect(err).ToNot(HaveOccurred())
+									Expect(ac.Upgrades).To(HaveLen(2))
+									Expect(ac.Tests).To(HaveLen(1))
+									Expect(ac.Rollbacks).To(HaveLen(1))
+								})
+
+								By("recording the rolled back upgrade on the CR", func() {
+									Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+									objStat := &objStatus{}
+									Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+									Expect(objStat.Status.RolledBackUpgrade).NotTo(BeNil())
+									c := objStat.Status.Conditions.GetCondition(conditions.TypeReleaseFailed)
+									Expect(c).NotTo(BeNil())
+									Expect(c.Status).To(Equal(corev1.ConditionTrue))
+									Expect(c.Reason).To(Equal(conditions.ReasonTestsFailed))
+								})
+
+								By("not upgrading again on the next reconcile", func() {
+									res, err := r.Reconcile(ctx, req)
+									Expect(err).ToNot(HaveOccurred())
+									Expect(res.RequeueAfter).To(Equal(r.reconcilePeriod))
+									// Only the dry-run upgrade that determines the release state.
+									Expect(ac.Upgrades).To(HaveLen(3))
+									Expect(ac.Tests).To(HaveLen(1))
+									Expect(ac.Rollbacks).To(HaveLen(1))
+								})
+							})
+						})
 						When("upgrade succeeds", func() {
 							It("upgrades the release", func() {
 								var (
@@ -1240,6 +1622,260 @@ var _ = Describe("Reconciler", func() {
 								})
 							})
 						})
+						When("reconciliation is paused", func() {
+							It("does not upgrade the release", func() {
+								By("pausing and changing the CR", func() {
+									Expect(mgr.GetClient().Get(ctx, objKey, obj)).To(Succeed())
+									obj.SetAnnotations(map[string]string{annotation.Paused: "true"})
+									obj.Object["spec"] = map[string]interface{}{"replicaCount": "2"}
+									Expect(mgr.GetClient().Update(ctx, obj)).To(Succeed())
+								})
+
+								By("reconciling until the pause is observed", func() {
+									Eventually(func(g Gomega) {
+										res, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										g.Expect(res).To(Equal(reconcile.Result{}))
+										g.Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+										objStat := &objStatus{}
+										g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+										g.Expect(objStat.Status.Conditions.IsTrueFor(conditions.TypePaused)).To(BeTrue())
+										g.Expect(objStat.Status.Conditions.IsTrueFor(conditions.TypeDeployed)).To(BeTrue())
+									}).Should(Succeed())
+								})
+
+								By("verifying the release is unchanged", func() {
+									rel, err := ac.Get(obj.GetName())
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(currentRelease.Version))
+								})
+							})
+						})
+						When("upgrades require approval", func() {
+							BeforeEach(func() {
+								Expect(WithUpgradeApprovalRequired(true)(r)).To(Succeed())
+							})
+							It("upgrades the release once the revision hash is approved", func() {
+								var hash string
+								By("changing the CR", func() {
+									Expect(mgr.GetClient().Get(ctx, objKey, obj)).To(Succeed())
+									obj.Object["spec"] = map[string]interface{}{"replicaCount": "2"}
+									Expect(mgr.GetClient().Update(ctx, obj)).To(Succeed())
+								})
+
+								By("recording the pending upgrade", func() {
+									Eventually(func(g Gomega) {
+										_, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										g.Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+										objStat := &objStatus{}
+										g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+										g.Expect(objStat.Status.Conditions.IsTrueFor(conditions.TypeUpgradePending)).To(BeTrue())
+										g.Expect(objStat.Status.PendingUpgrade).NotTo(BeNil())
+										hash = objStat.Status.PendingUpgrade.RevisionHash
+									}).Should(Succeed())
+									rel, err := ac.Get(obj.GetName())
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(currentRelease.Version))
+								})
+
+								By("approving the upgrade", func() {
+									obj.SetAnnotations(map[string]string{annotation.ApprovedRevisionHash: hash})
+									Expect(mgr.GetClient().Update(ctx, obj)).To(Succeed())
+								})
+
+								By("upgrading the release", func() {
+									Eventually(func(g Gomega) {
+										_, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										rel, err := ac.Get(obj.GetName())
+										g.Expect(err).ToNot(HaveOccurred())
+										g.Expect(rel.Version).To(Equal(currentRelease.Version + 1))
+									}).Should(Succeed())
+									Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+									objStat := &objStatus{}
+									Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+									Expect(objStat.Status.Conditions.IsFalseFor(conditions.TypeUpgradePending)).To(BeTrue())
+									Expect(objStat.Status.PendingUpgrade).To(BeNil())
+								})
+							})
+						})
+						When("the CR is outside of its maintenance windows", func() {
+							It("defers the upgrade until the next window", func() {
+								By("changing the CR", func() {
+									Expect(mgr.GetClient().Get(ctx, objKey, obj)).To(Succeed())
+									obj.Object["spec"] = map[string]interface{}{"replicaCount": "2"}
+									obj.SetAnnotations(map[string]string{annotation.MaintenanceWindows: "0 0 1 1 * 1m"})
+									Expect(mgr.GetClient().Update(ctx, obj)).To(Succeed())
+								})
+
+								By("deferring the upgrade", func() {
+									Eventually(func(g Gomega) {
+										res, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										g.Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+										g.Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+										objStat := &objStatus{}
+										g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+										c := objStat.Status.Conditions.GetCondition(conditions.TypeUpgradePending)
+										g.Expect(c).NotTo(BeNil())
+										g.Expect(c.Status).To(Equal(corev1.ConditionTrue))
+										g.Expect(c.Reason).To(Equal(conditions.ReasonOutsideMaintenanceWindow))
+									}).Should(Succeed())
+									rel, err := ac.Get(obj.GetName())
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(currentRelease.Version))
+								})
+							})
+						})
+						When("upgrades use canary releases", func() {
+							var healthy error
+							BeforeEach(func() {
+								healthy = nil
+								Expect(WithCanaryUpgrades(CanaryStrategy{
+									HealthChecks: []CanaryHealthCheck{CanaryHealthCheckFunc(func(context.Context, *unstructured.Unstructured, *release.Release) error {
+										return healthy
+									})},
+									Timeout: time.Minute,
+								})(r)).To(Succeed())
+								By("changing the CR", func() {
+									Expect(mgr.GetClient().Get(ctx, objKey, obj)).To(Succeed())
+									obj.Object["spec"] = map[string]interface{}{"replicaCount": "2"}
+									Expect(mgr.GetClient().Update(ctx, obj)).To(Succeed())
+								})
+							})
+							canaryCondition := func(g Gomega) *status.Condition {
+								g.Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+								objStat := &objStatus{}
+								g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+								return objStat.Status.Conditions.GetCondition(conditions.TypeCanary)
+							}
+							It("promotes a healthy canary", func() {
+								By("deploying the canary release", func() {
+									Eventually(func(g Gomega) {
+										_, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										c := canaryCondition(g)
+										g.Expect(c).NotTo(BeNil())
+										g.Expect(c.Reason).To(Equal(conditions.ReasonCanaryProgressing))
+									}).Should(Succeed())
+									_, err := ac.Get(obj.GetName() + "-canary")
+									Expect(err).ToNot(HaveOccurred())
+									rel, err := ac.Get(obj.GetName())
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(currentRelease.Version))
+								})
+
+								By("promoting the canary release", func() {
+									Eventually(func(g Gomega) {
+										_, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										c := canaryCondition(g)
+										g.Expect(c).NotTo(BeNil())
+										g.Expect(c.Reason).To(Equal(conditions.ReasonCanaryPromoted))
+									}).Should(Succeed())
+									rel, err := ac.Get(obj.GetName())
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(currentRelease.Version + 1))
+									_, err = ac.Get(obj.GetName() + "-canary")
+									Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+								})
+							})
+							It("aborts an unhealthy canary", func() {
+								healthy = errors.New("not ready")
+								Expect(WithCanaryUpgrades(CanaryStrategy{
+									HealthChecks: r.canaryStrategy.HealthChecks,
+									Timeout:      time.Nanosecond,
+								})(r)).To(Succeed())
+
+								By("aborting the canary release", func() {
+									Eventually(func(g Gomega) {
+										_, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										c := canaryCondition(g)
+										g.Expect(c).NotTo(BeNil())
+										g.Expect(c.Reason).To(Equal(conditions.ReasonCanaryAborted))
+									}).Should(Succeed())
+									rel, err := ac.Get(obj.GetName())
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(currentRelease.Version))
+									_, err = ac.Get(obj.GetName() + "-canary")
+									Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+								})
+							})
+							It("does not touch the release of another CR named like the canary", func() {
+								var other *release.Release
+								By("installing the release of another CR", func() {
+									var err error
+									other, err = ac.Install(obj.GetName()+"-canary", obj.GetNamespace(), &chrt, nil)
+									Expect(err).ToNot(HaveOccurred())
+									DeferCleanup(func() {
+										_, err := ac.Uninstall(other.Name)
+										Expect(err).ToNot(HaveOccurred())
+									})
+								})
+
+								By("aborting the canary release", func() {
+									Eventually(func(g Gomega) {
+										_, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										c := canaryCondition(g)
+										g.Expect(c).NotTo(BeNil())
+										g.Expect(c.Reason).To(Equal(conditions.ReasonCanaryAborted))
+										g.Expect(c.Message).To(ContainSubstring("is not the canary release of"))
+									}).Should(Succeed())
+								})
+
+								By("leaving the release of the other CR untouched", func() {
+									rel, err := ac.Get(other.Name)
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(other.Version))
+									rel, err = ac.Get(obj.GetName())
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(currentRelease.Version))
+								})
+							})
+						})
+						When("the CR waits for its rollout wave", func() {
+							var other *unstructured.Unstructured
+							BeforeEach(func() {
+								Expect(WithRollout(RolloutPolicy{WaveLabel: "rollout-wave"})(r)).To(Succeed())
+								other = testutil.BuildTestCR(gvk)
+								other.SetName("other")
+								other.SetUID("")
+								other.SetLabels(map[string]string{"rollout-wave": "1"})
+								Expect(mgr.GetClient().Create(ctx, other)).To(Succeed())
+							})
+							AfterEach(func() {
+								Expect(client.IgnoreNotFound(mgr.GetClient().Delete(ctx, other))).To(Succeed())
+							})
+							It("defers the upgrade until the earlier waves are done", func() {
+								By("changing the CR", func() {
+									Expect(mgr.GetClient().Get(ctx, objKey, obj)).To(Succeed())
+									obj.Object["spec"] = map[string]interface{}{"replicaCount": "2"}
+									obj.SetLabels(map[string]string{"rollout-wave": "2"})
+									Expect(mgr.GetClient().Update(ctx, obj)).To(Succeed())
+								})
+
+								By("deferring the upgrade", func() {
+									Eventually(func(g Gomega) {
+										res, err := r.Reconcile(ctx, req)
+										g.Expect(err).ToNot(HaveOccurred())
+										g.Expect(res.RequeueAfter).To(Equal(30 * time.Second))
+										g.Expect(mgr.GetAPIReader().Get(ctx, objKey, obj)).To(Succeed())
+										objStat := &objStatus{}
+										g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, objStat)).To(Succeed())
+										c := objStat.Status.Conditions.GetCondition(conditions.TypeUpgradePending)
+										g.Expect(c).NotTo(BeNil())
+										g.Expect(c.Status).To(Equal(corev1.ConditionTrue))
+										g.Expect(c.Reason).To(Equal(conditions.ReasonAwaitingRolloutWave))
+									}).Should(Succeed())
+									rel, err := ac.Get(obj.GetName())
+									Expect(err).ToNot(HaveOccurred())
+									Expect(rel.Version).To(Equal(currentRelease.Version))
+								})
+							})
+						})
 						When("reconciliation fails", func() {
 							BeforeEach(func() {
 								ac := helmfake.NewActionClient()
@@ -1509,6 +2145,17 @@ type objStatus struct {
 			Name     string `json:"name"`
 			Manifest string `json:"manifest"`
 		} `json:"deployedRelease"`
+		PendingUpgrade *struct {
+			RevisionHash string `json:"revisionHash"`
+		} `json:"pendingUpgrade"`
+		RolledBackUpgrade *struct {
+			RevisionHash string `json:"revisionHash"`
+		} `json:"rolledBackUpgrade"`
+		ReleaseAttempts *struct {
+			ObservedGeneration int64       `json:"observedGeneration"`
+			Failed             int         `json:"failed"`
+			LastFailureTime    metav1.Time `json:"lastFailureTime"`
+		} `json:"releaseAttempts"`
 	} `json:"status"`
 }
 
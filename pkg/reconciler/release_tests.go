@@ -0,0 +1,220 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+// TestPolicy configures when the tests of a release run, see
+// WithReleaseTests.
+type TestPolicy struct {
+	// AfterRelease runs the tests after every install and upgrade.
+	AfterRelease bool
+
+	// Interval runs the tests periodically. 0 disables periodic tests.
+	Interval time.Duration
+
+	// Timeout is how long a test run may take. It defaults to 5m.
+	Timeout time.Duration
+
+	// RollbackOnFailure rolls back upgrades whose tests fail. A rolled back
+	// upgrade is not retried until the chart or the values of the custom
+	// resource change, which is reported by `status.rolledBackUpgrade`.
+	RollbackOnFailure bool
+
+	// LogLines is the number of log lines of each failed test pod that are
+	// recorded in the status. It defaults to 10.
+	LogLines int64
+}
+
+// WithReleaseTests is an Option that runs the tests of the chart, which are
+// its hooks annotated with "helm.sh/hook: test", after installs and upgrades
+// or periodically. The outcome of the last run is reported by the Tested
+// condition and by `status.testRun`, which lists the phase of each test and
+// an excerpt of the logs of failed test pods. Test failures do not fail the
+// reconciliation, unless RollbackOnFailure rolls back a failed upgrade.
+func WithReleaseTests(policy TestPolicy) Option {
+	return func(r *Reconciler) error {
+		if !policy.AfterRelease && policy.Interval == 0 {
+			return errors.New("release tests must run after releases or periodically")
+		}
+		if policy.Interval < 0 || policy.Timeout < 0 || policy.LogLines < 0 {
+			return errors.New("release test interval, timeout and log lines must not be negative")
+		}
+		if policy.Timeout == 0 {
+			policy.Timeout = 5 * time.Minute
+		}
+		if policy.LogLines == 0 {
+			policy.LogLines = 10
+		}
+		r.testPolicy = &policy
+		return nil
+	}
+}
+
+// testsDue returns whether the tests of rel must run now.
+func (r *Reconciler) testsDue(obj *unstructured.Unstructured, rel *release.Release, state helmReleaseState) bool {
+	if state == stateNeedsInstall || state == stateNeedsUpgrade {
+		return r.testPolicy.AfterRelease
+	}
+	last := updater.TestRunFor(obj)
+	if last == nil {
+		return true
+	}
+	if r.testPolicy.AfterRelease && last.Revision != rel.Version {
+		return true
+	}
+	return r.testPolicy.Interval > 0 && time.Since(last.CompletionTime.Time) >= r.testPolicy.Interval
+}
+
+// doTest runs the tests of rel and records their outcome. It returns an
+// error if the tests could not run or failed.
+func (r *Reconciler) doTest(ctx context.Context, actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, rel *release.Release, log logr.Logger) error {
+	testedRel, err := actionClient.Test(rel.Name, func(t *action.ReleaseTesting) error {
+		t.Timeout = r.testPolicy.Timeout
+		t.Namespace = rel.Namespace
+		return nil
+	})
+	if testedRel == nil {
+		if err == nil {
+			err = errors.New("no release was returned")
+		}
+		err = fmt.Errorf("failed to run the tests of release %s: %w", rel.Name, err)
+		log.Error(err, "Release tests could not run")
+		u.UpdateStatus(updater.EnsureCondition(conditions.Tested(corev1.ConditionFalse, conditions.ReasonTestError, err)))
+		return err
+	}
+
+	run := &updater.TestRun{Revision: testedRel.Version, CompletionTime: metav1.Now()}
+	var failed []string
+	for _, h := range testedRel.Hooks {
+		if !slices.Contains(h.Events, release.HookTest) || h.LastRun.Phase == "" {
+			continue
+		}
+		result := updater.TestResult{Name: h.Name, Phase: h.LastRun.Phase.String()}
+		if h.LastRun.Phase != release.HookPhaseSucceeded {
+			failed = append(failed, h.Name)
+			if h.Kind == "Pod" {
+				result.Log = r.testLogExcerpt(ctx, testedRel.Namespace, h.Name, log)
+			}
+		}
+		run.Results = append(run.Results, result)
+	}
+	u.UpdateStatus(updater.EnsureTestRun(run))
+
+	if err != nil {
+		if len(failed) > 0 {
+			err = fmt.Errorf("%d of %d tests of release %s failed: %s: %w", len(failed), len(run.Results), rel.Name, strings.Join(failed, ", "), err)
+		} else {
+			err = fmt.Errorf("tests of release %s failed: %w", rel.Name, err)
+		}
+		log.Error(err, "Release tests failed")
+		r.eventRecorder.Event(obj, "Warning", string(conditions.ReasonTestsFailed), err.Error())
+		u.UpdateStatus(updater.EnsureCondition(conditions.Tested(corev1.ConditionFalse, conditions.ReasonTestsFailed, err)))
+		return err
+	}
+	log.Info("Release tests succeeded", "name", rel.Name, "version", testedRel.Version, "tests", len(run.Results))
+	u.UpdateStatus(updater.EnsureCondition(conditions.Tested(corev1.ConditionTrue, conditions.ReasonTestsSucceeded,
+		fmt.Sprintf("%d tests of release %s succeeded", len(run.Results), rel.Name))))
+	return nil
+}
+
+// testLogExcerpt returns the last lines of the logs of the test pod with the
+// given name, or a note why they are not available.
+func (r *Reconciler) testLogExcerpt(ctx context.Context, namespace, name string, log logr.Logger) string {
+	if r.podsGetter == nil {
+		return ""
+	}
+	lines := r.testPolicy.LogLines
+	logs, err := r.podsGetter.Pods(namespace).GetLogs(name, &corev1.PodLogOptions{TailLines: &lines}).DoRaw(ctx)
+	if err != nil {
+		log.V(1).Info("Could not get the logs of test pod", "name", name, "error", err.Error())
+		return fmt.Sprintf("logs are not available: %v", err)
+	}
+	return strings.TrimSpace(string(logs))
+}
+
+// rollbackFailedTests rolls back the upgrade to rel, whose tests failed with
+// testErr. The upgrade is identified by hash, see revisionHash, and is not
+// retried until it changes. If the rollback fails, it is handled like a
+// failed upgrade.
+func (r *Reconciler) rollbackFailedTests(actionClient helmclient.ActionInterface, u *updater.Updater, obj *unstructured.Unstructured, rel *release.Release, hash string, testErr error, log logr.Logger) (ctrl.Result, error) {
+	if rbErr := actionClient.Rollback(rel.Name, func(rb *action.Rollback) error {
+		rb.MaxHistory = *r.maxReleaseHistory
+		return nil
+	}); rbErr != nil {
+		err := fmt.Errorf("failed to roll back the upgrade to revision %d: %v: %w", rel.Version, rbErr, testErr)
+		u.UpdateStatus(
+			updater.EnsureCondition(conditions.ReleaseFailed(corev1.ConditionTrue, conditions.ReasonTestsFailed, err)),
+			updater.EnsureFailedChart(r.chartRevision()),
+		)
+		return r.handleReleaseError(u, obj, err, log)
+	}
+
+	err := fmt.Errorf("upgrade to revision %d was rolled back: %w", rel.Version, testErr)
+	log.Info("Release rolled back after failed tests", "name", rel.Name, "version", rel.Version)
+	r.eventRecorder.Eventf(obj, "Warning", "RolledBack", "Upgrade to revision %d was rolled back because its tests failed", rel.Version)
+	u.UpdateStatus(
+		updater.EnsureCondition(conditions.ReleaseFailed(corev1.ConditionTrue, conditions.ReasonTestsFailed, err)),
+		updater.EnsureFailedChart(r.chartRevision()),
+		updater.EnsureRolledBackUpgrade(&updater.RolledBackUpgrade{RevisionHash: hash}),
+	)
+	return ctrl.Result{RequeueAfter: r.reconcilePeriod}, nil
+}
+
+// isRolledBack returns whether the upgrade from current to desired was rolled
+// back because its tests failed.
+func isRolledBack(obj *unstructured.Unstructured, current, desired *release.Release) bool {
+	rolledBack := updater.RolledBackUpgradeFor(obj)
+	return rolledBack != nil && rolledBack.RevisionHash == revisionHash(current, desired)
+}
+
+// nextReconcile returns when obj should be reconciled again after a
+// successful reconciliation, which is after the reconcile period or when the
+// next periodic test run is due, whichever is earlier.
+func (r *Reconciler) nextReconcile(obj *unstructured.Unstructured) time.Duration {
+	next := r.reconcilePeriod
+	if r.testPolicy == nil || r.testPolicy.Interval == 0 {
+		return next
+	}
+	untilTest := r.testPolicy.Interval
+	if last := updater.TestRunFor(obj); last != nil {
+		untilTest = max(time.Until(last.CompletionTime.Add(r.testPolicy.Interval)), time.Second)
+	}
+	if next == 0 || untilTest < next {
+		next = untilTest
+	}
+	return next
+}
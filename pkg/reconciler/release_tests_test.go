@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	helmfake "github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/fake"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+var _ = Describe("release tests", func() {
+	var (
+		r   *Reconciler
+		ac  helmfake.ActionClient
+		obj *unstructured.Unstructured
+		u   updater.Updater
+		rel *release.Release
+	)
+	BeforeEach(func() {
+		r = &Reconciler{eventRecorder: record.NewFakeRecorder(10)}
+		Expect(WithReleaseTests(TestPolicy{AfterRelease: true, Interval: time.Hour})(r)).To(Succeed())
+		ac = helmfake.NewActionClient()
+		obj = &unstructured.Unstructured{Object: map[string]interface{}{}}
+		u = updater.New(nil)
+		rel = &release.Release{Name: "test", Namespace: "default", Version: 2}
+	})
+	testHook := func(name string, phase release.HookPhase) *release.Hook {
+		return &release.Hook{Name: name, Kind: "Job", Events: []release.HookEvent{release.HookTest}, LastRun: release.HookExecution{Phase: phase}}
+	}
+
+	It("should be due after releases, for new revisions and after the interval", func() {
+		Expect(r.testsDue(obj, rel, stateNeedsUpgrade)).To(BeTrue())
+		Expect(r.testsDue(obj, rel, stateUnchanged)).To(BeTrue())
+
+		obj.Object["status"] = map[string]interface{}{"testRun": map[string]interface{}{
+			"revision":       int64(2),
+			"completionTime": time.Now().UTC().Format(time.RFC3339),
+		}}
+		Expect(r.testsDue(obj, rel, stateUnchanged)).To(BeFalse())
+		Expect(r.nextReconcile(obj)).To(BeNumerically("~", time.Hour, time.Minute))
+		Expect(r.testsDue(obj, &release.Release{Version: 3}, stateUnchanged)).To(BeTrue())
+
+		obj.Object["status"] = map[string]interface{}{"testRun": map[string]interface{}{
+			"revision":       int64(2),
+			"completionTime": time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+		}}
+		Expect(r.testsDue(obj, rel, stateUnchanged)).To(BeTrue())
+	})
+
+	It("should succeed if all tests succeed", func() {
+		ac.HandleTest = func() (*release.Release, error) {
+			return &release.Release{Name: "test", Version: 2, Hooks: []*release.Hook{
+				testHook("test-a", release.HookPhaseSucceeded),
+				{Name: "pre-install", Events: []release.HookEvent{release.HookPreInstall}},
+			}}, nil
+		}
+		Expect(r.doTest(context.Background(), &ac, &u, obj, rel, logr.Discard())).To(Succeed())
+		Expect(ac.Tests).To(HaveLen(1))
+		Expect(ac.Tests[0].Name).To(Equal("test"))
+	})
+
+	It("should fail and name the failed tests", func() {
+		ac.HandleTest = func() (*release.Release, error) {
+			return &release.Release{Name: "test", Version: 2, Hooks: []*release.Hook{
+				testHook("test-a", release.HookPhaseSucceeded),
+				testHook("test-b", release.HookPhaseFailed),
+			}}, errors.New("job failed")
+		}
+		err := r.doTest(context.Background(), &ac, &u, obj, rel, logr.Discard())
+		Expect(err).To(MatchError(ContainSubstring("1 of 2 tests of release test failed: test-b: job failed")))
+	})
+
+	It("should roll back failed upgrades with the configured history limit", func() {
+		Expect(WithMaxReleaseHistory(5)(r)).To(Succeed())
+		ac.HandleRollback = func() error { return nil }
+		res, err := r.rollbackFailedTests(&ac, &u, obj, rel, "abc", errors.New("tests failed"), logr.Discard())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{RequeueAfter: r.reconcilePeriod}))
+		Expect(ac.Rollbacks).To(HaveLen(1))
+		rb := &action.Rollback{}
+		for _, opt := range ac.Rollbacks[0].Opts {
+			Expect(opt(rb)).To(Succeed())
+		}
+		Expect(rb.MaxHistory).To(Equal(5))
+	})
+
+	It("should fail if the rollback fails", func() {
+		Expect(WithMaxReleaseHistory(5)(r)).To(Succeed())
+		ac.HandleRollback = func() error { return errors.New("rollback failed") }
+		_, err := r.rollbackFailedTests(&ac, &u, obj, rel, "abc", errors.New("tests failed"), logr.Discard())
+		Expect(err).To(MatchError(ContainSubstring("failed to roll back the upgrade to revision 2: rollback failed: tests failed")))
+	})
+})
@@ -0,0 +1,192 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+// RetryExhaustedAction determines what the reconciler does once an install or
+// upgrade of a custom resource has failed RetryPolicy.MaxAttempts times.
+type RetryExhaustedAction string
+
+const (
+	// RetryExhaustedStall stops retrying and sets the Stalled condition until
+	// the spec of the custom resource changes.
+	RetryExhaustedStall RetryExhaustedAction = "Stall"
+
+	// RetryExhaustedSlowRetry keeps retrying every
+	// RetryPolicy.SlowRetryInterval.
+	RetryExhaustedSlowRetry RetryExhaustedAction = "SlowRetry"
+)
+
+// RetryPolicy configures how failed installs and upgrades are retried, see
+// WithRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the number of failed installs or upgrades of each
+	// generation of a custom resource after which OnExhausted applies. Zero
+	// means unlimited.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. Defaults to 5s.
+	InitialBackoff time.Duration
+
+	// Factor is multiplied with the delay after each further failure.
+	// Defaults to 2.
+	Factor float64
+
+	// MaxBackoff caps the delay between retries. Defaults to 5m.
+	MaxBackoff time.Duration
+
+	// OnExhausted determines what happens once MaxAttempts is reached.
+	// Defaults to RetryExhaustedStall.
+	OnExhausted RetryExhaustedAction
+
+	// SlowRetryInterval is the delay between retries once MaxAttempts is
+	// reached and OnExhausted is RetryExhaustedSlowRetry. Defaults to 1h.
+	SlowRetryInterval time.Duration
+}
+
+// delay returns the delay before retrying after the given number of failed
+// attempts, and whether the retries are exhausted.
+func (p RetryPolicy) delay(failed int) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && failed >= p.MaxAttempts {
+		return p.SlowRetryInterval, true
+	}
+	d := float64(p.InitialBackoff) * math.Pow(p.Factor, float64(failed-1))
+	if d > float64(p.MaxBackoff) {
+		return p.MaxBackoff, false
+	}
+	return time.Duration(d), false
+}
+
+// WithRetryPolicy is an Option that configures how failed installs and
+// upgrades are retried. The failed attempts of the current generation of a
+// custom resource are counted in `status.releaseAttempts`, and reset when the
+// spec changes or a release succeeds. Retries are delayed by an exponential
+// backoff, and stop or slow down once policy.MaxAttempts is reached.
+//
+// By default, failed releases are retried indefinitely with the default
+// backoff of the controller.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(r *Reconciler) error {
+		if policy.MaxAttempts < 0 || policy.InitialBackoff < 0 || policy.MaxBackoff < 0 || policy.SlowRetryInterval < 0 {
+			return errors.New("retry policy must not contain negative values")
+		}
+		if policy.Factor != 0 && policy.Factor < 1 {
+			return errors.New("retry backoff factor must be at least 1")
+		}
+		if policy.InitialBackoff == 0 {
+			policy.InitialBackoff = 5 * time.Second
+		}
+		if policy.Factor == 0 {
+			policy.Factor = 2
+		}
+		if policy.MaxBackoff == 0 {
+			policy.MaxBackoff = 5 * time.Minute
+		}
+		if policy.SlowRetryInterval == 0 {
+			policy.SlowRetryInterval = time.Hour
+		}
+		switch policy.OnExhausted {
+		case "":
+			policy.OnExhausted = RetryExhaustedStall
+		case RetryExhaustedStall, RetryExhaustedSlowRetry:
+		default:
+			return fmt.Errorf("unknown retry exhausted action %q", policy.OnExhausted)
+		}
+		r.retryPolicy = &policy
+		return nil
+	}
+}
+
+// releaseAttemptsFor returns the failed release attempts of the current
+// generation of obj.
+func releaseAttemptsFor(obj *unstructured.Unstructured) updater.ReleaseAttempts {
+	attempts := updater.ReleaseAttemptsFor(obj)
+	if attempts == nil || attempts.ObservedGeneration != obj.GetGeneration() {
+		return updater.ReleaseAttempts{ObservedGeneration: obj.GetGeneration()}
+	}
+	return *attempts
+}
+
+// checkReleaseRetry returns whether an install or upgrade of obj may be
+// attempted according to the retry policy. If not, it returns when obj
+// should be reconciled again.
+func (r *Reconciler) checkReleaseRetry(u *updater.Updater, obj *unstructured.Unstructured, log logr.Logger) (ctrl.Result, bool) {
+	if r.retryPolicy == nil {
+		return ctrl.Result{}, true
+	}
+	attempts := releaseAttemptsFor(obj)
+	if attempts.Failed == 0 {
+		// The attempts of a new generation start, so a release that stalled
+		// for a previous generation is retried.
+		u.UpdateStatus(updater.EnsureCondition(conditions.Stalled(corev1.ConditionFalse, "", "")))
+		return ctrl.Result{}, true
+	}
+	delay, exhausted := r.retryPolicy.delay(attempts.Failed)
+	if exhausted && r.retryPolicy.OnExhausted == RetryExhaustedStall {
+		log.V(1).Info("Not retrying release, retries are exhausted", "attempts", attempts.Failed)
+		u.UpdateStatus(updater.EnsureCondition(conditions.Stalled(corev1.ConditionTrue, conditions.ReasonRetriesExhausted, stalledMessage(attempts))))
+		return ctrl.Result{}, false
+	}
+	if wait := time.Until(attempts.LastFailureTime.Add(delay)); wait > 0 {
+		log.V(1).Info("Waiting before retrying release", "attempts", attempts.Failed, "retryAfter", wait.Round(time.Second))
+		return ctrl.Result{RequeueAfter: wait}, false
+	}
+	return ctrl.Result{}, true
+}
+
+// handleReleaseError records a failed install or upgrade of obj. Without a
+// retry policy, it returns err, so that the controller retries with its
+// default backoff.
+func (r *Reconciler) handleReleaseError(u *updater.Updater, obj *unstructured.Unstructured, err error, log logr.Logger) (ctrl.Result, error) {
+	if r.retryPolicy == nil {
+		return ctrl.Result{}, err
+	}
+	attempts := releaseAttemptsFor(obj)
+	attempts.Failed++
+	attempts.LastFailureTime = metav1.Now()
+	u.UpdateStatus(updater.EnsureReleaseAttempts(&attempts))
+
+	delay, exhausted := r.retryPolicy.delay(attempts.Failed)
+	if exhausted && r.retryPolicy.OnExhausted == RetryExhaustedStall {
+		log.Error(err, "Release failed, retries are exhausted", "attempts", attempts.Failed)
+		message := stalledMessage(attempts)
+		r.eventRecorder.Event(obj, "Warning", string(conditions.ReasonRetriesExhausted), message)
+		u.UpdateStatus(updater.EnsureCondition(conditions.Stalled(corev1.ConditionTrue, conditions.ReasonRetriesExhausted, message)))
+		return ctrl.Result{}, nil
+	}
+	log.Error(err, "Release failed, retrying", "attempts", attempts.Failed, "retryAfter", delay)
+	return ctrl.Result{RequeueAfter: delay}, nil
+}
+
+func stalledMessage(attempts updater.ReleaseAttempts) string {
+	return fmt.Sprintf("release failed %d times for generation %d and will not be retried until the spec changes", attempts.Failed, attempts.ObservedGeneration)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/internal/status"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/rollout"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/updater"
+)
+
+// RolloutPolicy configures staged rollouts of chart changes, see WithRollout.
+type RolloutPolicy struct {
+	// WaveLabel is the key of a label whose integer values order custom
+	// resources into waves, lowest first. Custom resources without a valid
+	// value form the last wave.
+	WaveLabel string
+
+	// WavePercentages are the cumulative percentages of custom resources in
+	// each wave, e.g. 10, 50 and 100. Custom resources are assigned to waves
+	// by a stable hash of their namespace and name. WavePercentages and
+	// WaveLabel are mutually exclusive.
+	WavePercentages []int
+
+	// FailureThreshold is the fraction of custom resources of a wave that may
+	// fail to upgrade before the rollout halts. It defaults to 0, which halts
+	// the rollout at the first failure. The next wave starts once no custom
+	// resource of a wave is pending and its failures are within the
+	// threshold.
+	FailureThreshold float64
+
+	// ProgressInterval is how often custom resources that wait for their
+	// wave check the progress of the rollout. It defaults to 30s.
+	ProgressInterval time.Duration
+}
+
+func (p RolloutPolicy) plan(members []rollout.Member) rollout.Plan {
+	return rollout.Policy{
+		WaveLabel:        p.WaveLabel,
+		WavePercentages:  p.WavePercentages,
+		FailureThreshold: p.FailureThreshold,
+	}.Plan(members)
+}
+
+// WithRollout is an Option that rolls out chart changes across custom
+// resources in waves. Custom resources whose release was last reconciled with
+// a different chart only upgrade once all custom resources of earlier waves
+// have been reconciled with the current chart successfully. If the failed
+// upgrades of a wave exceed the failure threshold, the rollout halts until
+// they succeed. Waiting custom resources report the UpgradePending condition.
+//
+// The progress of the rollout is reported by events and by the
+// helm_operator_rollout_* metrics. A wave is only as ready as its releases
+// are, so charts that need their workloads to become ready before the next
+// wave starts should enable waiting with the optional upgrade-wait annotation.
+//
+// Upgrades due to changed values, installs and paused custom resources are
+// not part of rollouts.
+func WithRollout(policy RolloutPolicy) Option {
+	return func(r *Reconciler) error {
+		if err := (rollout.Policy{
+			WaveLabel:        policy.WaveLabel,
+			WavePercentages:  policy.WavePercentages,
+			FailureThreshold: policy.FailureThreshold,
+		}).Validate(); err != nil {
+			return err
+		}
+		if policy.ProgressInterval < 0 {
+			return errors.New("rollout progress interval must not be negative")
+		}
+		if policy.ProgressInterval == 0 {
+			policy.ProgressInterval = 30 * time.Second
+		}
+		r.rolloutPolicy = &policy
+		return nil
+	}
+}
+
+// checkRollout returns whether obj may be upgraded according to the rollout
+// of the chart across all custom resources. If not, it returns when obj
+// should be reconciled again.
+func (r *Reconciler) checkRollout(ctx context.Context, u *updater.Updater, obj *unstructured.Unstructured, log logr.Logger) (ctrl.Result, bool, error) {
+	target := r.chartRevision()
+	if updater.ReconciledChartFor(obj) == target {
+		return ctrl.Result{}, true, nil
+	}
+	plan, err := r.rolloutPlan(ctx, target)
+	if err != nil {
+		return ctrl.Result{}, false, err
+	}
+	for _, e := range r.rolloutTracker.Observe(*r.gvk, plan.Progress) {
+		r.eventRecorder.Event(obj, e.Type, e.Reason, e.Message)
+	}
+	key := client.ObjectKeyFromObject(obj)
+	if plan.Admits(key) {
+		return ctrl.Result{}, true, nil
+	}
+
+	var reason status.ConditionReason
+	var message string
+	if plan.Halted {
+		reason = conditions.ReasonRolloutHalted
+		message = fmt.Sprintf("upgrade to chart %s is on hold because the rollout halted in wave %d of %d: %d of %d resources failed",
+			target, plan.Wave+1, plan.Waves, plan.Failed, plan.Done+plan.Failed+plan.Pending)
+	} else {
+		reason = conditions.ReasonAwaitingRolloutWave
+		message = fmt.Sprintf("upgrade to chart %s is scheduled for rollout wave %d of %d, the rollout is in wave %d",
+			target, plan.WaveOf(key)+1, plan.Waves, plan.Wave+1)
+	}
+	log.V(1).Info("Deferring upgrade until its rollout wave", "reason", reason, "wave", plan.WaveOf(key)+1, "currentWave", plan.Wave+1)
+	u.UpdateStatus(updater.EnsureCondition(conditions.UpgradePending(corev1.ConditionTrue, reason, message)))
+	return ctrl.Result{RequeueAfter: r.rolloutPolicy.ProgressInterval}, false, nil
+}
+
+// rolloutPlan lists the custom resources reconciled by r and plans the
+// rollout of the chart identified by target across them.
+func (r *Reconciler) rolloutPlan(ctx context.Context, target string) (rollout.Plan, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(r.gvk.GroupVersion().WithKind(r.gvk.Kind + "List"))
+	if err := r.client.List(ctx, list); err != nil {
+		return rollout.Plan{}, fmt.Errorf("failed to list %s resources: %w", r.gvk.Kind, err)
+	}
+
+	members := make([]rollout.Member, 0, len(list.Items))
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.GetDeletionTimestamp() != nil || !r.reconciles(item) {
+			continue
+		}
+		if paused, _ := isPaused(item); paused {
+			continue
+		}
+		members = append(members, rollout.Member{Key: client.ObjectKeyFromObject(item), Labels: item.GetLabels(), Status: rolloutStatusOf(item, target)})
+	}
+	return r.rolloutPolicy.plan(members), nil
+}
+
+// rolloutStatusOf returns the rollout status of obj for the chart identified
+// by target. Only failures with target count, so that a failure left over
+// from a previous chart does not halt the rollout of target.
+func rolloutStatusOf(obj *unstructured.Unstructured, target string) rollout.Status {
+	reconciled := updater.ReconciledChartFor(obj)
+	failed := updater.ConditionsFor(obj).IsTrueFor(conditions.TypeReleaseFailed)
+	switch {
+	case failed && (reconciled == target || updater.FailedChartFor(obj) == target):
+		return rollout.Failed
+	case reconciled == target:
+		return rollout.Done
+	}
+	return rollout.Pending
+}
+
+// reconciles returns whether obj matches the namespaces and the selector of r.
+func (r *Reconciler) reconciles(obj *unstructured.Unstructured) bool {
+	if len(r.namespaces) > 0 && !slices.Contains(r.namespaces, obj.GetNamespace()) {
+		return false
+	}
+	return r.selectorPredicate == nil || r.selectorPredicate.Generic(event.GenericEvent{Object: obj})
+}
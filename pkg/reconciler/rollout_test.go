@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/conditions"
+	"github.com/operator-framework/helm-operator-plugins/pkg/reconciler/internal/rollout"
+)
+
+var _ = Describe("rollout status", func() {
+	const target = "test-chart-2.0.0"
+	withStatus := func(st map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{"status": st}}
+	}
+	releaseFailed := []interface{}{map[string]interface{}{"type": string(conditions.TypeReleaseFailed), "status": "True"}}
+
+	It("should be pending until the release is reconciled with the target chart", func() {
+		Expect(rolloutStatusOf(withStatus(map[string]interface{}{"reconciledChart": "test-chart-1.0.0"}), target)).To(Equal(rollout.Pending))
+		Expect(rolloutStatusOf(withStatus(map[string]interface{}{"reconciledChart": target}), target)).To(Equal(rollout.Done))
+	})
+
+	It("should fail if the release failed with the target chart", func() {
+		Expect(rolloutStatusOf(withStatus(map[string]interface{}{
+			"reconciledChart": "test-chart-1.0.0",
+			"failedChart":     target,
+			"conditions":      releaseFailed,
+		}), target)).To(Equal(rollout.Failed))
+		Expect(rolloutStatusOf(withStatus(map[string]interface{}{
+			"reconciledChart": target,
+			"conditions":      releaseFailed,
+		}), target)).To(Equal(rollout.Failed))
+	})
+
+	It("should ignore a failure left over from the previous chart", func() {
+		Expect(rolloutStatusOf(withStatus(map[string]interface{}{
+			"reconciledChart": "test-chart-0.9.0",
+			"failedChart":     "test-chart-1.0.0",
+			"conditions":      releaseFailed,
+		}), target)).To(Equal(rollout.Pending))
+	})
+})
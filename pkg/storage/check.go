@@ -0,0 +1,448 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// DefaultCheckPendingTimeout is the CheckOptions.PendingTimeout used when it
+// is not set.
+const DefaultCheckPendingTimeout = 15 * time.Minute
+
+// secretTypeHelmRelease is the type of the secrets written by Helm's secrets
+// driver.
+const secretTypeHelmRelease = corev1.SecretType("helm.sh/release.v1")
+
+// ProblemType identifies a kind of problem found by CheckReleases.
+type ProblemType string
+
+const (
+	// ProblemMultipleDeployed is reported for each deployed revision of a
+	// release that is not its latest deployed revision. It is fixed by
+	// marking the revision as superseded.
+	ProblemMultipleDeployed ProblemType = "MultipleDeployed"
+
+	// ProblemStuckPending is reported for revisions that have been pending
+	// for longer than CheckOptions.PendingTimeout, e.g. because the operator
+	// crashed during an install or upgrade. Helm refuses to upgrade such
+	// releases. It is fixed by marking the revision as failed.
+	ProblemStuckPending ProblemType = "StuckPending"
+
+	// ProblemOrphanChunk is reported for chunk secrets that are not
+	// referenced by an index secret. It is fixed by deleting the chunk.
+	ProblemOrphanChunk ProblemType = "OrphanChunk"
+
+	// ProblemMissingChunk is reported for index secrets that reference chunk
+	// secrets that do not exist. The revision cannot be decoded, so it is
+	// fixed by deleting the index.
+	ProblemMissingChunk ProblemType = "MissingChunk"
+
+	// ProblemReleaseWithoutCustomResource is reported for releases without
+	// a custom resource of the same name, and for canary releases whose
+	// custom resource no longer exists. It is not fixed, since the resources
+	// of the release may still be in use.
+	ProblemReleaseWithoutCustomResource ProblemType = "ReleaseWithoutCustomResource"
+
+	// ProblemCustomResourceWithoutRelease is reported for custom resources
+	// without a release of the same name. It is not fixed, since the
+	// operator installs the release when it reconciles the custom resource.
+	ProblemCustomResourceWithoutRelease ProblemType = "CustomResourceWithoutRelease"
+)
+
+// CanaryOfLabel is the release label of canary releases, see
+// reconciler.WithCanaryUpgrades. Its value is the UID of the custom resource
+// that the canary release belongs to.
+const CanaryOfLabel = "helm.sdk.operatorframework.io/canary-of"
+
+// Problem is an inconsistency found by CheckReleases.
+type Problem struct {
+	Type ProblemType
+
+	// Release is the name of the affected release, if any.
+	Release string
+
+	// Namespace and Object are the namespace and the name of the affected
+	// secret or custom resource.
+	Namespace string
+	Object    string
+
+	Message string
+
+	// Fixed is true if the problem was fixed.
+	Fixed bool
+}
+
+// CheckOptions configures CheckReleases.
+type CheckOptions struct {
+	// Owner is the owner of the releases stored by the chunked secrets
+	// driver.
+	Owner string
+
+	// ChunkedSecretsConfig is used to read and update releases stored by
+	// the chunked secrets driver when problems are fixed. It must contain
+	// the Encrypter of encrypted releases.
+	ChunkedSecretsConfig ChunkedSecretsConfig
+
+	// CustomResources are the custom resources in the namespace. The
+	// operator names each release after its custom resource, except for
+	// canary releases, which have the CanaryOfLabel. If nil, releases and
+	// custom resources are not compared.
+	CustomResources []CustomResource
+
+	// PendingTimeout is the time after which a pending revision is
+	// considered stuck. It defaults to DefaultCheckPendingTimeout.
+	PendingTimeout time.Duration
+
+	// MinOrphanAge is the minimum age of chunk secrets that are not
+	// referenced by an index before they are reported, see
+	// RepairOptions.MinOrphanAge.
+	MinOrphanAge time.Duration
+
+	// Fix fixes the problems that can be fixed safely.
+	Fix bool
+
+	Log func(string, ...interface{})
+}
+
+// CustomResource identifies a custom resource for CheckReleases.
+type CustomResource struct {
+	Name string
+	UID  types.UID
+}
+
+// CheckResult contains the problems found by CheckReleases, ordered by
+// release, type and object.
+type CheckResult struct {
+	Problems []Problem
+}
+
+// Unfixed returns the problems that were not fixed.
+func (r *CheckResult) Unfixed() []Problem {
+	var unfixed []Problem
+	for _, p := range r.Problems {
+		if !p.Fixed {
+			unfixed = append(unfixed, p)
+		}
+	}
+	return unfixed
+}
+
+// storedRevision is a release revision as stored in a secret, without its
+// decoded release.
+type storedRevision struct {
+	key     string
+	name    string
+	version int
+	status  release.Status
+	created time.Time
+	chunked bool
+
+	// labels are the labels of the secret. For Helm's secrets driver, they
+	// include the custom labels of the release.
+	labels map[string]string
+}
+
+// CheckReleases checks the releases stored in namespace by Helm's secrets
+// driver and by the chunked secrets driver for problems. Only the metadata
+// of releases is read, so releases are not decoded unless problems are fixed.
+//
+// Like RepairChunkedSecrets, fixing is best effort: it continues after errors
+// and returns all of them along with the problems that were found.
+func CheckReleases(ctx context.Context, client clientcorev1.SecretsGetter, namespace string, opts CheckOptions) (*CheckResult, error) {
+	if opts.Log == nil {
+		opts.Log = func(string, ...interface{}) {}
+	}
+	if opts.PendingTimeout <= 0 {
+		opts.PendingTimeout = DefaultCheckPendingTimeout
+	}
+
+	secrets := client.Secrets(namespace)
+	helmSecrets, err := secrets.List(ctx, metav1.ListOptions{LabelSelector: labels.Set{"owner": "helm"}.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release secrets: %w", err)
+	}
+	indexes, err := NewChunkedSecrets(secrets, opts.Owner, opts.ChunkedSecretsConfig).(MetadataLister).ListMetadata(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunked releases: %w", err)
+	}
+	chunkSecrets, err := secrets.List(ctx, metav1.ListOptions{LabelSelector: newListChunksSelector(opts.Owner).String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunk secrets: %w", err)
+	}
+
+	// Temporary copies of releases that are being migrated are not
+	// revisions of their releases, see Migrate.
+	var revisions []storedRevision
+	for i := range helmSecrets.Items {
+		if s := &helmSecrets.Items[i]; s.Type == secretTypeHelmRelease && s.Labels[MigratingLabel] == "" {
+			version, _ := strconv.Atoi(s.Labels["version"])
+			revisions = append(revisions, storedRevision{
+				key:     s.Name,
+				name:    s.Labels["name"],
+				version: version,
+				status:  release.Status(s.Labels["status"]),
+				created: s.CreationTimestamp.Time,
+				labels:  s.Labels,
+			})
+		}
+	}
+	chunks := map[string]*corev1.Secret{}
+	for i := range chunkSecrets.Items {
+		if s := &chunkSecrets.Items[i]; s.Type == SecretTypeChunkedChunk {
+			chunks[s.Name] = s
+		}
+	}
+
+	var problems []Problem
+	unreadable := sets.New[string]()
+	referenced := sets.New[string]()
+	for _, md := range indexes {
+		if md.Labels[MigratingLabel] == "" {
+			revisions = append(revisions, storedRevision{
+				key:     md.Key,
+				name:    md.Name,
+				version: md.Version,
+				status:  md.Status,
+				created: md.Created,
+				chunked: true,
+				labels:  md.Labels,
+			})
+		}
+
+		chunkNames, err := indexChunkNames(md.index)
+		if err != nil {
+			return nil, fmt.Errorf("index %q: %w", md.Key, err)
+		}
+		referenced.Insert(chunkNames...)
+		for _, name := range chunkNames {
+			if _, ok := chunks[name]; !ok {
+				unreadable.Insert(md.Key)
+				problems = append(problems, Problem{
+					Type:      ProblemMissingChunk,
+					Release:   md.Name,
+					Namespace: namespace,
+					Object:    md.Key,
+					Message:   fmt.Sprintf("chunk %q does not exist", name),
+				})
+			}
+		}
+	}
+	for name, chunkSecret := range chunks {
+		if referenced.Has(name) || time.Since(chunkSecret.CreationTimestamp.Time) < opts.MinOrphanAge {
+			continue
+		}
+		problems = append(problems, Problem{
+			Type:      ProblemOrphanChunk,
+			Namespace: namespace,
+			Object:    name,
+			Message:   "chunk is not referenced by an index",
+		})
+	}
+
+	byRelease := map[string][]storedRevision{}
+	for _, rev := range revisions {
+		byRelease[rev.name] = append(byRelease[rev.name], rev)
+	}
+	for name, revs := range byRelease {
+		slices.SortFunc(revs, func(a, b storedRevision) int { return b.version - a.version })
+		latestDeployed := true
+		for _, rev := range revs {
+			switch {
+			case rev.status == release.StatusDeployed && latestDeployed:
+				latestDeployed = false
+			case rev.status == release.StatusDeployed:
+				problems = append(problems, Problem{
+					Type:      ProblemMultipleDeployed,
+					Release:   name,
+					Namespace: namespace,
+					Object:    rev.key,
+					Message:   fmt.Sprintf("revision %d is deployed, but a later revision is also deployed", rev.version),
+				})
+			case rev.status.IsPending() && time.Since(rev.created) >= opts.PendingTimeout:
+				problems = append(problems, Problem{
+					Type:      ProblemStuckPending,
+					Release:   name,
+					Namespace: namespace,
+					Object:    rev.key,
+					Message:   fmt.Sprintf("revision %d has been %s since %s", rev.version, rev.status, rev.created.UTC().Format(time.RFC3339)),
+				})
+			}
+		}
+	}
+
+	if opts.CustomResources != nil {
+		customResources := sets.New[string]()
+		uids := sets.New[string]()
+		for _, cr := range opts.CustomResources {
+			customResources.Insert(cr.Name)
+			uids.Insert(string(cr.UID))
+		}
+		chunkedDriver := NewChunkedSecrets(secrets, opts.Owner, opts.ChunkedSecretsConfig)
+		for name, revs := range byRelease {
+			if customResources.Has(name) {
+				continue
+			}
+			message := "release has no custom resource"
+			if canaryOf, ok := canaryOf(chunkedDriver, revs); ok {
+				if uids.Has(canaryOf) {
+					continue
+				}
+				message = "canary release has no custom resource"
+			}
+			problems = append(problems, Problem{
+				Type:      ProblemReleaseWithoutCustomResource,
+				Release:   name,
+				Namespace: namespace,
+				Object:    name,
+				Message:   message,
+			})
+		}
+		for name := range customResources {
+			if _, ok := byRelease[name]; !ok {
+				problems = append(problems, Problem{
+					Type:      ProblemCustomResourceWithoutRelease,
+					Release:   name,
+					Namespace: namespace,
+					Object:    name,
+					Message:   "custom resource has no release",
+				})
+			}
+		}
+	}
+
+	slices.SortFunc(problems, func(a, b Problem) int {
+		return cmp.Or(cmp.Compare(a.Release, b.Release), cmp.Compare(a.Type, b.Type), cmp.Compare(a.Object, b.Object))
+	})
+	result := &CheckResult{Problems: problems}
+	if !opts.Fix || len(problems) == 0 {
+		return result, nil
+	}
+	return result, fixProblems(ctx, client, namespace, revisions, unreadable, result, opts)
+}
+
+// canaryOf returns the value of the CanaryOfLabel of the release with the
+// revisions revs, sorted from the latest to the oldest, and whether it is
+// set. The custom labels of releases stored by the chunked secrets driver
+// are not stored on their index secrets, so the latest of their revisions
+// that can be decoded is read.
+func canaryOf(chunkedDriver driver.Driver, revs []storedRevision) (string, bool) {
+	for _, rev := range revs {
+		labels := rev.labels
+		if rev.chunked {
+			rls, err := chunkedDriver.Get(rev.key)
+			if err != nil {
+				continue
+			}
+			labels = rls.Labels
+		}
+		v, ok := labels[CanaryOfLabel]
+		return v, ok
+	}
+	return "", false
+}
+
+// fixProblems fixes the problems in result that can be fixed safely and marks
+// them as fixed.
+func fixProblems(ctx context.Context, client clientcorev1.SecretsGetter, namespace string, revisions []storedRevision, unreadable sets.Set[string], result *CheckResult, opts CheckOptions) error {
+	var errs []error
+
+	// Chunks are repaired first, since deleted indexes no longer need their
+	// status to be fixed.
+	repaired, err := RepairChunkedSecrets(ctx, client, namespace, RepairOptions{
+		Owner:        opts.Owner,
+		MinOrphanAge: opts.MinOrphanAge,
+		Log:          opts.Log,
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	deletedChunks := sets.New[string]()
+	deletedIndexes := sets.New[string]()
+	if repaired != nil {
+		for _, chunk := range repaired.DeletedChunks {
+			deletedChunks.Insert(chunk.Name)
+		}
+		for _, index := range repaired.DeletedIndexes {
+			deletedIndexes.Insert(index.Name)
+		}
+	}
+
+	secretsDriver := driver.NewSecrets(client.Secrets(namespace))
+	chunkedDriver := NewChunkedSecrets(client.Secrets(namespace), opts.Owner, opts.ChunkedSecretsConfig)
+	chunked := sets.New[string]()
+	for _, rev := range revisions {
+		if rev.chunked {
+			chunked.Insert(rev.key)
+		}
+	}
+	setStatus := func(key string, status release.Status, description string) error {
+		var d driver.Driver = secretsDriver
+		if chunked.Has(key) {
+			d = chunkedDriver
+		}
+		rls, err := d.Get(key)
+		if err != nil {
+			return err
+		}
+		rls.SetStatus(status, description)
+		return d.Update(key, rls)
+	}
+
+	for i := range result.Problems {
+		p := &result.Problems[i]
+		switch {
+		case deletedIndexes.Has(p.Object):
+			// The revision could not be decoded and no longer exists.
+			p.Fixed = true
+		case p.Type == ProblemOrphanChunk:
+			p.Fixed = deletedChunks.Has(p.Object)
+		case unreadable.Has(p.Object):
+			// The status of revisions that cannot be decoded cannot be set.
+		case p.Type == ProblemMultipleDeployed:
+			opts.Log("check: marking revision %q as superseded", p.Object)
+			if err := setStatus(p.Object, release.StatusSuperseded, "Superseded by a later deployed revision"); err != nil {
+				errs = append(errs, fmt.Errorf("failed to mark revision %q as superseded: %w", p.Object, err))
+				continue
+			}
+			p.Fixed = true
+		case p.Type == ProblemStuckPending:
+			opts.Log("check: marking revision %q as failed", p.Object)
+			if err := setStatus(p.Object, release.StatusFailed, fmt.Sprintf("Marked as failed after being pending for longer than %s", opts.PendingTimeout)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to mark revision %q as failed: %w", p.Object, err))
+				continue
+			}
+			p.Fixed = true
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+var _ = Describe("CheckReleases", func() {
+	const (
+		chunkSize = 1000
+		owner     = "test-owner"
+	)
+	var (
+		ctx             = context.Background()
+		coreClient      clientcorev1.CoreV1Interface
+		secretInterface clientcorev1.SecretInterface
+		secretsDriver   driver.Driver
+		chunkedDriver   driver.Driver
+		opts            CheckOptions
+	)
+
+	BeforeEach(func() {
+		coreClient = clientcorev1.NewForConfigOrDie(cfg)
+		secretInterface = coreClient.Secrets("default")
+		secretsDriver = driver.NewSecrets(secretInterface)
+		chunkedDriver = NewChunkedSecrets(secretInterface, owner, ChunkedSecretsConfig{ChunkSize: chunkSize})
+		opts = CheckOptions{Owner: owner}
+	})
+
+	AfterEach(func() {
+		Expect(secretInterface.DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{})).To(Succeed())
+	})
+
+	problemTypes := func(result *CheckResult) []ProblemType {
+		var types []ProblemType
+		for _, p := range result.Problems {
+			types = append(types, p.Type)
+		}
+		return types
+	}
+
+	It("should not report consistent releases", func() {
+		for _, rel := range []*release.Release{
+			genRelease("native", 1, release.StatusSuperseded, nil, chunkSize),
+			genRelease("native", 2, release.StatusDeployed, nil, chunkSize),
+		} {
+			Expect(secretsDriver.Create(releaseStorageKey(rel.Name, rel.Version), rel)).To(Succeed())
+		}
+		rel := genRelease("chunked", 1, release.StatusDeployed, nil, chunkSize*2)
+		Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+
+		opts.CustomResources = []CustomResource{{Name: "native", UID: "native-uid"}, {Name: "chunked", UID: "chunked-uid"}}
+		result, err := CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Problems).To(BeEmpty())
+	})
+
+	It("should report and fix multiple deployed revisions", func() {
+		older := genRelease("native", 1, release.StatusDeployed, nil, chunkSize)
+		newer := genRelease("native", 2, release.StatusDeployed, nil, chunkSize)
+		for _, rel := range []*release.Release{older, newer} {
+			Expect(secretsDriver.Create(releaseStorageKey(rel.Name, rel.Version), rel)).To(Succeed())
+		}
+		olderChunked := genRelease("chunked", 1, release.StatusDeployed, nil, chunkSize*2)
+		newerChunked := genRelease("chunked", 2, release.StatusDeployed, nil, chunkSize*2)
+		for _, rel := range []*release.Release{olderChunked, newerChunked} {
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+		}
+
+		result, err := CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Problems).To(Equal([]Problem{
+			{Type: ProblemMultipleDeployed, Release: "chunked", Namespace: "default", Object: releaseKey(olderChunked), Message: "revision 1 is deployed, but a later revision is also deployed"},
+			{Type: ProblemMultipleDeployed, Release: "native", Namespace: "default", Object: releaseStorageKey("native", 1), Message: "revision 1 is deployed, but a later revision is also deployed"},
+		}))
+
+		opts.Fix = true
+		result, err = CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Problems).To(HaveLen(2))
+		Expect(result.Unfixed()).To(BeEmpty())
+
+		actual, err := secretsDriver.Get(releaseStorageKey("native", 1))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual.Info.Status).To(Equal(release.StatusSuperseded))
+		actual, err = chunkedDriver.Get(releaseKey(olderChunked))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual.Info.Status).To(Equal(release.StatusSuperseded))
+		actual, err = chunkedDriver.Get(releaseKey(newerChunked))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual.Info.Status).To(Equal(release.StatusDeployed))
+	})
+
+	It("should report and fix revisions that are stuck pending", func() {
+		deployed := genRelease("native", 1, release.StatusDeployed, nil, chunkSize)
+		pending := genRelease("native", 2, release.StatusPendingUpgrade, nil, chunkSize)
+		for _, rel := range []*release.Release{deployed, pending} {
+			Expect(secretsDriver.Create(releaseStorageKey(rel.Name, rel.Version), rel)).To(Succeed())
+		}
+
+		opts.PendingTimeout = time.Hour
+		result, err := CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Problems).To(BeEmpty())
+
+		opts.PendingTimeout = time.Nanosecond
+		opts.Fix = true
+		result, err = CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problemTypes(result)).To(Equal([]ProblemType{ProblemStuckPending}))
+		Expect(result.Problems[0].Object).To(Equal(releaseStorageKey("native", 2)))
+		Expect(result.Problems[0].Fixed).To(BeTrue())
+
+		actual, err := secretsDriver.Get(releaseStorageKey("native", 2))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual.Info.Status).To(Equal(release.StatusFailed))
+	})
+
+	It("should report and fix orphan and missing chunks", func() {
+		rel := genRelease("chunked", 1, release.StatusDeployed, nil, chunkSize*2)
+		Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+		broken := genRelease("chunked", 2, release.StatusPendingUpgrade, nil, chunkSize*2)
+		Expect(chunkedDriver.Create(releaseKey(broken), broken)).To(Succeed())
+
+		index, err := secretInterface.Get(ctx, releaseKey(broken), metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		Expect(json.Unmarshal(index.Data["extraChunks"], &names)).To(Succeed())
+		Expect(secretInterface.Delete(ctx, names[0], metav1.DeleteOptions{})).To(Succeed())
+		Expect(secretInterface.Delete(ctx, releaseKey(rel), metav1.DeleteOptions{})).To(Succeed())
+
+		opts.PendingTimeout = time.Nanosecond
+		result, err := CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(problemTypes(result)).To(ConsistOf(ProblemOrphanChunk, ProblemMissingChunk, ProblemStuckPending))
+
+		opts.Fix = true
+		result, err = CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Unfixed()).To(BeEmpty())
+
+		secrets, err := secretInterface.List(ctx, metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secrets.Items).To(BeEmpty())
+	})
+
+	It("should report releases and custom resources without each other", func() {
+		rel := genRelease("orphan", 1, release.StatusDeployed, nil, chunkSize)
+		Expect(secretsDriver.Create(releaseStorageKey(rel.Name, rel.Version), rel)).To(Succeed())
+
+		opts.CustomResources = []CustomResource{{Name: "new", UID: "new-uid"}}
+		opts.Fix = true
+		result, err := CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Problems).To(Equal([]Problem{
+			{Type: ProblemCustomResourceWithoutRelease, Release: "new", Namespace: "default", Object: "new", Message: "custom resource has no release"},
+			{Type: ProblemReleaseWithoutCustomResource, Release: "orphan", Namespace: "default", Object: "orphan", Message: "release has no custom resource"},
+		}))
+	})
+
+	It("should only report canary releases whose custom resource no longer exists", func() {
+		for _, rel := range []*release.Release{
+			genRelease("app", 1, release.StatusDeployed, nil, chunkSize),
+			genRelease("app-canary", 1, release.StatusDeployed, map[string]string{CanaryOfLabel: "app-uid"}, chunkSize),
+			genRelease("gone-canary", 1, release.StatusDeployed, map[string]string{CanaryOfLabel: "gone-uid"}, chunkSize),
+		} {
+			Expect(secretsDriver.Create(releaseStorageKey(rel.Name, rel.Version), rel)).To(Succeed())
+		}
+		rel := genRelease("chunked-canary", 1, release.StatusDeployed, map[string]string{CanaryOfLabel: "app-uid"}, chunkSize*2)
+		Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+
+		opts.CustomResources = []CustomResource{{Name: "app", UID: "app-uid"}}
+		result, err := CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Problems).To(Equal([]Problem{
+			{Type: ProblemReleaseWithoutCustomResource, Release: "gone-canary", Namespace: "default", Object: "gone-canary", Message: "canary release has no custom resource"},
+		}))
+	})
+
+	It("should ignore temporary copies of releases that are being migrated", func() {
+		rel := genRelease("native", 1, release.StatusDeployed, nil, chunkSize)
+		key := releaseStorageKey(rel.Name, rel.Version)
+		Expect(secretsDriver.Create(key, rel)).To(Succeed())
+		Expect(secretsDriver.Create(migratingKey(key), migratingCopy(rel))).To(Succeed())
+		chunked := genRelease("chunked", 1, release.StatusDeployed, nil, chunkSize*2)
+		Expect(chunkedDriver.Create(releaseKey(chunked), chunked)).To(Succeed())
+		Expect(chunkedDriver.Create(migratingKey(releaseKey(chunked)), migratingCopy(chunked))).To(Succeed())
+
+		result, err := CheckReleases(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Problems).To(BeEmpty())
+	})
+})
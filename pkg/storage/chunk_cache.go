@@ -0,0 +1,61 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"k8s.io/utils/lru"
+)
+
+// ChunkCache caches the data of chunk secrets by namespace and name. Chunk
+// secrets are immutable and named after a hash of their data, so cached data
+// never becomes stale. Implementations must be safe for concurrent use; they
+// may, for example, be backed by an LRU cache or by an informer on chunk
+// secrets.
+type ChunkCache interface {
+	// Get returns the cached data of the chunk secret, if any.
+	Get(namespace, name string) ([]byte, bool)
+
+	// Add caches the data of the chunk secret.
+	Add(namespace, name string, data []byte)
+}
+
+// NewLRUChunkCache returns a ChunkCache that holds the data of up to
+// maxEntries chunk secrets, evicting the least recently used ones. Each entry
+// takes up to ChunkedSecretsConfig.ChunkSize bytes.
+func NewLRUChunkCache(maxEntries int) ChunkCache {
+	return &lruChunkCache{cache: lru.New(maxEntries)}
+}
+
+type lruChunkCache struct {
+	cache *lru.Cache
+}
+
+type chunkCacheKey struct {
+	namespace, name string
+}
+
+func (c *lruChunkCache) Get(namespace, name string) ([]byte, bool) {
+	v, ok := c.cache.Get(chunkCacheKey{namespace, name})
+	if !ok {
+		return nil, false
+	}
+	return v.([]byte), true
+}
+
+func (c *lruChunkCache) Add(namespace, name string, data []byte) {
+	c.cache.Add(chunkCacheKey{namespace, name}, data)
+}
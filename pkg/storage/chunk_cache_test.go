@@ -0,0 +1,50 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewLRUChunkCache", func() {
+	It("should return cached chunks by namespace and name", func() {
+		cache := NewLRUChunkCache(2)
+		cache.Add("ns1", "chunk", []byte("data"))
+
+		data, ok := cache.Get("ns1", "chunk")
+		Expect(ok).To(BeTrue())
+		Expect(data).To(Equal([]byte("data")))
+
+		_, ok = cache.Get("ns2", "chunk")
+		Expect(ok).To(BeFalse())
+	})
+	It("should evict the least recently used chunks", func() {
+		cache := NewLRUChunkCache(2)
+		cache.Add("ns", "a", []byte("a"))
+		cache.Add("ns", "b", []byte("b"))
+		_, _ = cache.Get("ns", "a")
+		cache.Add("ns", "c", []byte("c"))
+
+		_, ok := cache.Get("ns", "b")
+		Expect(ok).To(BeFalse())
+		_, ok = cache.Get("ns", "a")
+		Expect(ok).To(BeTrue())
+		_, ok = cache.Get("ns", "c")
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -2,7 +2,6 @@ package storage
 
 import (
 	"bytes"
-	"compress/gzip"
 	"context"
 	"encoding/base32"
 	"encoding/json"
@@ -10,34 +9,78 @@ import (
 	"hash"
 	"hash/fnv"
 	"io"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/utils/ptr"
 )
 
 var _ driver.Driver = (*chunkedSecrets)(nil)
 
+// DefaultChunkSize is the chunk size used when ChunkedSecretsConfig.ChunkSize
+// is not set. It leaves room for the secret's metadata within the 1MiB limit
+// on the size of a Secret.
+const DefaultChunkSize = 1000 * 1000
+
 type ChunkedSecretsConfig struct {
 	ChunkSize      int
 	MaxReadChunks  int
 	MaxWriteChunks int
 	Log            func(string, ...interface{})
+
+	// Encrypter, if set, is used to encrypt the data of releases before they
+	// are written. Releases that were written without encryption can still
+	// be read. Each update re-encrypts a release with the current primary key,
+	// so key rotation takes effect the next time a release is updated.
+	Encrypter Encrypter
+
+	// MaxConcurrentChunkReads is the maximum number of chunk secrets that
+	// are fetched in parallel when a release is read. It defaults to
+	// DefaultMaxConcurrentChunkReads.
+	MaxConcurrentChunkReads int
+
+	// ChunkCache, if set, caches the data of chunk secrets, which never
+	// change. It can be shared by drivers for different namespaces.
+	ChunkCache ChunkCache
+
+	// Codec compresses releases before they are written. It defaults to
+	// DefaultCodec. The codec's name is recorded in the index secret, so
+	// releases written with any supported codec can be read.
+	Codec Codec
 }
 
+// DefaultMaxConcurrentChunkReads is the number of chunk secrets that are
+// fetched in parallel when ChunkedSecretsConfig.MaxConcurrentChunkReads is not
+// set.
+const DefaultMaxConcurrentChunkReads = 4
+
 func NewChunkedSecrets(client clientcorev1.SecretInterface, owner string, config ChunkedSecretsConfig) driver.Driver {
 	if config.Log == nil {
 		config.Log = func(string, ...interface{}) {}
 	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = DefaultChunkSize
+	}
+	if config.MaxConcurrentChunkReads <= 0 {
+		config.MaxConcurrentChunkReads = DefaultMaxConcurrentChunkReads
+	}
+	if config.Codec == nil {
+		config.Codec = DefaultCodec
+	}
 
 	return &chunkedSecrets{
 		client:               client,
@@ -63,13 +106,27 @@ func (c *chunkedSecrets) Create(key string, rls *release.Release) error {
 	c.Log("create: %q", key)
 	defer c.Log("created: %q", key)
 
-	chunks, err := c.encodeReleaseAsChunks(key, rls)
+	chunks, keyID, err := c.encodeReleaseAsChunks(context.Background(), key, rls)
 	if err != nil {
 		return fmt.Errorf("create: failed to encode release %q: %w", rls.Name, err)
 	}
 
+	// The chunks are created before the index, so that the index never
+	// references chunks that do not exist. They can only be owned by the
+	// index once it exists. Chunks that end up without an index, e.g.
+	// because of a crash or because another writer created the release
+	// first, are cleaned up by RepairChunkedSecrets. They are not deleted
+	// here, since a concurrent writer may be about to reference them.
 	createdAt := time.Now()
-	indexSecret := c.indexSecretFromChunks(key, rls, chunks)
+	for i, ch := range chunks[1:] {
+		chunkSecret := c.chunkSecretFromChunk(key, ch, nil)
+		chunkSecret.Labels["createdAt"] = strconv.Itoa(int(createdAt.Unix()))
+		if err := c.createChunk(context.Background(), chunkSecret); err != nil {
+			return fmt.Errorf("create: failed to create chunk secret %d of %d %q: %w", i+2, len(chunks), ch.name, err)
+		}
+	}
+
+	indexSecret := c.indexSecretFromChunks(key, rls, chunks, keyID)
 	indexSecret.Labels["createdAt"] = strconv.Itoa(int(createdAt.Unix()))
 	indexSecret, err = c.client.Create(context.Background(), indexSecret, metav1.CreateOptions{})
 	if err != nil {
@@ -79,11 +136,12 @@ func (c *chunkedSecrets) Create(key string, rls *release.Release) error {
 		return fmt.Errorf("create: failed to create index and chunk %d of %d secret %q: %w", 1, len(chunks), key, err)
 	}
 
-	for i, ch := range chunks[1:] {
-		chunkSecret := c.chunkSecretFromChunk(indexSecret, ch)
-		chunkSecret.Labels["createdAt"] = strconv.Itoa(int(createdAt.Unix()))
-		if _, err := c.client.Create(context.Background(), chunkSecret, metav1.CreateOptions{}); err != nil {
-			return fmt.Errorf("create: failed to create chunk secret %d of %d %q: %w", i+2, len(chunks), ch.name, err)
+	// The release is complete at this point. Failing to set the owner
+	// references only affects garbage collection if the index is deleted
+	// by someone else, so it is not treated as an error.
+	for _, ch := range chunks[1:] {
+		if err := c.adoptChunk(context.Background(), indexSecret, ch.name); err != nil {
+			c.Log("create: failed to set owner of chunk secret %q: %v", ch.name, err)
 		}
 	}
 	return nil
@@ -106,23 +164,38 @@ func wrapRelease(rls *release.Release) *releaseWrapper {
 	}
 }
 
-// encodeRelease encodes a release returning a base64 encoded
-// gzipped string representation, or error.
-func (c *chunkedSecrets) encodeReleaseAsChunks(key string, rls *release.Release) ([]chunk, error) {
+// encodeReleaseAsChunks encodes a release as JSON compressed with the
+// configured Codec, encrypts it if an
+// Encrypter is configured, and splits the result into chunks. It returns the
+// chunks and the ID of the encryption key, which is empty if the release is
+// not encrypted.
+func (c *chunkedSecrets) encodeReleaseAsChunks(ctx context.Context, key string, rls *release.Release) ([]chunk, string, error) {
 	buf := &bytes.Buffer{}
 
 	if err := func() error {
-		gzw, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+		w, err := c.Codec.NewWriter(buf)
 		if err != nil {
 			return err
 		}
-		defer gzw.Close()
-		return json.NewEncoder(gzw).Encode(wrapRelease(rls))
+		if err := json.NewEncoder(w).Encode(wrapRelease(rls)); err != nil {
+			_ = w.Close()
+			return err
+		}
+		return w.Close()
 	}(); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	data := buf.Bytes()
 
+	var keyID string
+	if c.Encrypter != nil {
+		var err error
+		data, keyID, err = c.Encrypter.Encrypt(ctx, data)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encrypt release: %w", err)
+		}
+	}
+
 	// Split the encoded release into chunks of chunkSize
 	// and return the chunks.
 	var chunks []chunk
@@ -138,10 +211,10 @@ func (c *chunkedSecrets) encodeReleaseAsChunks(key string, rls *release.Release)
 	}
 
 	if c.MaxWriteChunks > 0 && len(chunks) > c.MaxWriteChunks {
-		return nil, fmt.Errorf("release too large: %q requires %d chunks, which exceeds the maximum of %d", rls.Name, len(chunks), c.MaxWriteChunks)
+		return nil, "", fmt.Errorf("release too large: %q requires %d chunks, which exceeds the maximum of %d", rls.Name, len(chunks), c.MaxWriteChunks)
 	}
 
-	return chunks, nil
+	return chunks, keyID, nil
 }
 
 const (
@@ -149,7 +222,7 @@ const (
 	SecretTypeChunkedChunk = corev1.SecretType("operatorframework.io/chunk.v1")
 )
 
-func (c *chunkedSecrets) indexSecretFromChunks(key string, rls *release.Release, chunks []chunk) *corev1.Secret {
+func (c *chunkedSecrets) indexSecretFromChunks(key string, rls *release.Release, chunks []chunk, keyID string) *corev1.Secret {
 	extraChunkNames := make([]string, 0, len(chunks)-1)
 	for _, ch := range chunks[1:] {
 		extraChunkNames = append(extraChunkNames, ch.name)
@@ -170,37 +243,102 @@ func (c *chunkedSecrets) indexSecretFromChunks(key string, rls *release.Release,
 		Data: map[string][]byte{
 			"extraChunks": extraChunkNamesData,
 			"chunk":       chunks[0].data,
+			"codec":       []byte(c.Codec.Name()),
 		},
 	}
+	if keyID != "" {
+		indexSecret.Labels["keyID"] = keyID
+		indexSecret.Data["keyID"] = []byte(keyID)
+	}
 	return indexSecret
 }
 
-func (c *chunkedSecrets) chunkSecretFromChunk(indexSecret *corev1.Secret, ch chunk) *corev1.Secret {
-	chunkLabels := newChunkLabels(c.owner, indexSecret.Name)
+// chunkSecretFromChunk returns the chunk secret for ch. If indexSecret is not
+// nil, the chunk secret is owned by it.
+func (c *chunkedSecrets) chunkSecretFromChunk(key string, ch chunk, indexSecret *corev1.Secret) *corev1.Secret {
+	chunkLabels := newChunkLabels(c.owner, key)
 	chunkSecret := &corev1.Secret{
 		Type: SecretTypeChunkedChunk,
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   ch.name,
 			Labels: chunkLabels,
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion:         corev1.SchemeGroupVersion.String(),
-					Kind:               "Secret",
-					Name:               indexSecret.Name,
-					UID:                indexSecret.UID,
-					Controller:         ptr.To(true),
-					BlockOwnerDeletion: ptr.To(false),
-				},
-			},
 		},
 		Immutable: ptr.To(true),
 		Data: map[string][]byte{
 			"chunk": ch.data,
 		},
 	}
+	if indexSecret != nil {
+		chunkSecret.OwnerReferences = []metav1.OwnerReference{indexOwnerReference(indexSecret)}
+	}
 	return chunkSecret
 }
 
+func indexOwnerReference(indexSecret *corev1.Secret) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion:         corev1.SchemeGroupVersion.String(),
+		Kind:               "Secret",
+		Name:               indexSecret.Name,
+		UID:                indexSecret.UID,
+		Controller:         ptr.To(true),
+		BlockOwnerDeletion: ptr.To(false),
+	}
+}
+
+// createChunk creates a chunk secret. Chunk names are derived from their
+// content, so a chunk that already exists has the same data and can be reused.
+func (c *chunkedSecrets) createChunk(ctx context.Context, chunkSecret *corev1.Secret) error {
+	_, err := c.client.Create(ctx, chunkSecret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// adoptChunk makes indexSecret the owner of the chunk secret chunkName.
+func adoptChunk(ctx context.Context, client clientcorev1.SecretInterface, indexSecret *corev1.Secret, chunkName string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"ownerReferences": []metav1.OwnerReference{indexOwnerReference(indexSecret)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Patch(ctx, chunkName, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func (c *chunkedSecrets) adoptChunk(ctx context.Context, indexSecret *corev1.Secret, chunkName string) error {
+	return adoptChunk(ctx, c.client, indexSecret, chunkName)
+}
+
+// deleteReplacedChunks deletes the chunk secrets that previousIndex
+// referenced and indexSecret, which replaced it, does not reference anymore.
+// Other chunk secrets of the release, e.g. those of a concurrent writer, are
+// left to RepairChunkedSecrets. Failures are only logged, since the release
+// itself is consistent.
+func (c *chunkedSecrets) deleteReplacedChunks(ctx context.Context, previousIndex, indexSecret *corev1.Secret) {
+	previous, err := indexChunkNames(previousIndex)
+	if err != nil {
+		c.Log("failed to parse chunk names from index %q: %v", previousIndex.Name, err)
+		return
+	}
+	referenced, err := indexChunkNames(indexSecret)
+	if err != nil {
+		c.Log("failed to parse chunk names from index %q: %v", indexSecret.Name, err)
+		return
+	}
+	for _, name := range previous {
+		if slices.Contains(referenced, name) {
+			continue
+		}
+		if err := c.client.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			c.Log("failed to delete stale chunk secret %q: %v", name, err)
+		}
+	}
+}
+
 func (c *chunkedSecrets) getIndex(ctx context.Context, key string) (*corev1.Secret, error) {
 	indexSecret, err := c.client.Get(ctx, key, metav1.GetOptions{})
 	if err != nil {
@@ -209,6 +347,11 @@ func (c *chunkedSecrets) getIndex(ctx context.Context, key string) (*corev1.Secr
 		}
 		return nil, fmt.Errorf("failed to get secret for key %q: %w", key, err)
 	}
+	// Other drivers, e.g. Helm's secrets driver, may store releases in
+	// secrets with the same name. Those are not releases of this driver.
+	if indexSecret.Type != SecretTypeChunkedIndex || indexSecret.Labels["owner"] != c.owner {
+		return nil, driver.ErrReleaseNotFound
+	}
 	return indexSecret, nil
 }
 
@@ -222,35 +365,37 @@ func (c *chunkedSecrets) Update(key string, rls *release.Release) error {
 		return fmt.Errorf("update: %w", err)
 	}
 
-	// Delete the existing chunk secrets
-	if err := c.client.DeleteCollection(context.Background(), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: newListChunksForKeySelector(c.owner, existingIndex.Name).String()}); err != nil {
-		return fmt.Errorf("update: failed to delete previous chunk secrets for key %q: %w", key, err)
-	}
-
 	// Generate new chunks
-	chunks, err := c.encodeReleaseAsChunks(key, rls)
+	chunks, keyID, err := c.encodeReleaseAsChunks(context.Background(), key, rls)
 	if err != nil {
 		return fmt.Errorf("create: failed to encode release %q: %w", rls.Name, err)
 	}
 
+	// Create the new chunks while the index still references the previous
+	// ones, so that the stored release stays readable until the index is
+	// swapped to the new chunks.
+	for i, ch := range chunks[1:] {
+		chunkSecret := c.chunkSecretFromChunk(key, ch, existingIndex)
+		if err := c.createChunk(context.Background(), chunkSecret); err != nil {
+			return fmt.Errorf("update: failed to create chunk secret %d of %d %q: %w", i+2, len(chunks), ch.name, err)
+		}
+	}
+
 	modifiedAt := time.Now()
 
-	// Update the index secret
-	updatedIndexSecret := c.indexSecretFromChunks(key, rls, chunks)
+	// Update the index secret. The resource version makes sure that the
+	// index has not changed since the previous chunks were read.
+	updatedIndexSecret := c.indexSecretFromChunks(key, rls, chunks, keyID)
+	updatedIndexSecret.ResourceVersion = existingIndex.ResourceVersion
 	updatedIndexSecret.Labels["createdAt"] = existingIndex.Labels["createdAt"]
 	updatedIndexSecret.Labels["modifiedAt"] = strconv.Itoa(int(modifiedAt.Unix()))
 	updatedIndexSecret, err = c.client.Update(context.Background(), updatedIndexSecret, metav1.UpdateOptions{})
 	if err != nil {
-		return fmt.Errorf("create: failed to create index and chunk %d of %d secret %q: %w", 1, len(chunks), key, err)
+		return fmt.Errorf("update: failed to update index and chunk %d of %d secret %q: %w", 1, len(chunks), key, err)
 	}
 
-	// Create the new chunks
-	for i, ch := range chunks[1:] {
-		chunkSecret := c.chunkSecretFromChunk(updatedIndexSecret, ch)
-		if _, err := c.client.Create(context.Background(), chunkSecret, metav1.CreateOptions{}); err != nil {
-			return fmt.Errorf("create: failed to create chunk secret %d of %d %q: %w", i+2, len(chunks), ch.name, err)
-		}
-	}
+	// Delete the previous chunks, which are no longer referenced.
+	c.deleteReplacedChunks(context.Background(), existingIndex, updatedIndexSecret)
 	return nil
 }
 
@@ -265,9 +410,17 @@ func (c *chunkedSecrets) Delete(key string) (*release.Release, error) {
 		}
 		return nil, fmt.Errorf("delete: %w", err)
 	}
-	if err := c.client.DeleteCollection(context.Background(), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: newListAllForKeySelector(c.owner, key).String()}); err != nil {
+	// Delete the index first, so that a partial delete never leaves an index
+	// that references missing chunks.
+	if err := c.client.Delete(context.Background(), indexSecret.Name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &indexSecret.UID}}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, driver.ErrReleaseNotFound
+		}
 		return nil, fmt.Errorf("delete: failed to delete index secret %q: %w", indexSecret.Name, err)
 	}
+	if err := c.client.DeleteCollection(context.Background(), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: newListChunksForKeySelector(c.owner, key).String()}); err != nil {
+		c.Log("delete: failed to delete chunk secrets for key %q: %v", key, err)
+	}
 	return rls, nil
 }
 
@@ -319,48 +472,117 @@ func (c *chunkedSecrets) List(filter func(*release.Release) bool) ([]*release.Re
 	return results, nil
 }
 
-func (c *chunkedSecrets) Query(queryLabels map[string]string) ([]*release.Release, error) {
+// ReleaseMetadata describes a release stored by the chunked secrets driver
+// without its content.
+type ReleaseMetadata struct {
+	// Key is the storage key of the release.
+	Key     string
+	Name    string
+	Version int
+	Status  release.Status
+
+	// Created is the time the release was first stored.
+	Created time.Time
+
+	// Labels are the labels of the release's index secret. Custom release
+	// labels are only stored in the encoded release, so these are the
+	// driver's system labels and the MigratingLabel of temporary copies.
+	Labels map[string]string
+
+	index *corev1.Secret
+}
+
+// MetadataLister lists the metadata of stored releases without reading and
+// decoding their content, e.g. to find revisions by status or to prune the
+// release history.
+type MetadataLister interface {
+	// ListMetadata returns the metadata of the releases whose index labels
+	// match the system labels in queryLabels, sorted by name and version.
+	ListMetadata(ctx context.Context, queryLabels map[string]string) ([]ReleaseMetadata, error)
+}
+
+var _ MetadataLister = (*chunkedSecrets)(nil)
+
+func (c *chunkedSecrets) ListMetadata(ctx context.Context, queryLabels map[string]string) ([]ReleaseMetadata, error) {
+	c.Log("list metadata: labels=%v", queryLabels)
+	defer c.Log("listed metadata: labels=%v", queryLabels)
+
+	selector := newListIndicesLabelSelector(c.owner)
 	for k, v := range queryLabels {
+		if !isSystemLabel(k) {
+			return nil, fmt.Errorf("list metadata: label %q is not stored on index secrets", k)
+		}
 		if k == "owner" && v == "helm" {
-			// Helm hardcodes some queries with owner=helm. We'll translate this
-			// to use our owner value
-			queryLabels[k] = c.owner
+			v = c.owner
+		}
+		req, err := labels.NewRequirement(k, selection.Equals, []string{v})
+		if err != nil {
+			return nil, fmt.Errorf("list metadata: %w", err)
 		}
+		selector = selector.Add(*req)
+	}
+
+	indexSecrets, err := c.client.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("list metadata: %w", err)
 	}
+
+	results := make([]ReleaseMetadata, 0, len(indexSecrets.Items))
+	for i := range indexSecrets.Items {
+		indexSecret := &indexSecrets.Items[i]
+		version, err := strconv.Atoi(indexSecret.Labels["version"])
+		if err != nil {
+			return nil, fmt.Errorf("list metadata: invalid version label of index secret %q: %w", indexSecret.Name, err)
+		}
+		results = append(results, ReleaseMetadata{
+			Key:     indexSecret.Name,
+			Name:    indexSecret.Labels["name"],
+			Version: version,
+			Status:  release.Status(indexSecret.Labels["status"]),
+			Created: indexSecret.CreationTimestamp.Time,
+			Labels:  indexSecret.Labels,
+			index:   indexSecret,
+		})
+	}
+	slices.SortFunc(results, func(a, b ReleaseMetadata) int {
+		if a.Name != b.Name {
+			return strings.Compare(a.Name, b.Name)
+		}
+		return a.Version - b.Version
+	})
+	return results, nil
+}
+
+func (c *chunkedSecrets) Query(queryLabels map[string]string) ([]*release.Release, error) {
 	c.Log("query: labels=%v", queryLabels)
 	defer c.Log("queried: labels=%v", queryLabels)
 
 	// The only labels that get stored on the index secret are system labels, so we'll do a two-pass
-	// query. First, we'll request index secrets from the API server that match the query labels that
-	// are system labels. From there, we decode the releases that match, and then further filter those
-	// based on the rest of the query labels that are not system labels.
-	serverSelectorSet := labels.Set{}
+	// query. First, we'll list the metadata of the releases that match the query labels that are
+	// system labels. From there, we decode the releases that match, and then further filter those
+	// based on the rest of the query labels that are not system labels. Helm hardcodes some
+	// queries with owner=helm, which ListMetadata translates to our owner value.
+	serverLabels := map[string]string{}
 	clientSelectorSet := labels.Set{}
 	for k, v := range queryLabels {
 		if isSystemLabel(k) {
-			serverSelectorSet[k] = v
+			serverLabels[k] = v
 		} else {
 			clientSelectorSet[k] = v
 		}
 	}
 
-	// Pass 1: build the server selector and query for index secrets
-	serverSelector := newListIndicesLabelSelector(c.owner)
-	if queryRequirements, selectable := serverSelectorSet.AsSelector().Requirements(); selectable {
-		serverSelector = serverSelector.Add(queryRequirements...)
-	}
-
-	indexSecrets, err := c.client.List(context.Background(), metav1.ListOptions{LabelSelector: serverSelector.String()})
+	// Pass 1: list the metadata of the releases that match the system labels
+	metadata, err := c.ListMetadata(context.Background(), serverLabels)
 	if err != nil {
 		return nil, fmt.Errorf("query: %w", err)
 	}
 
-	// Pass 2: decode the releases that matched the server selector and filter based on the client selector
-	results := make([]*release.Release, 0, len(indexSecrets.Items))
+	// Pass 2: decode the releases that matched the system labels and filter based on the client selector
+	results := make([]*release.Release, 0, len(metadata))
 	clientSelector := clientSelectorSet.AsSelector()
-	for _, indexSecret := range indexSecrets.Items {
-		indexSecret := indexSecret
-		rls, err := c.decodeRelease(context.Background(), &indexSecret)
+	for _, md := range metadata {
+		rls, err := c.decodeRelease(context.Background(), md.index)
 		if err != nil {
 			return nil, fmt.Errorf("query: failed to decode release: %w", err)
 		}
@@ -383,55 +605,59 @@ func (c *chunkedSecrets) Name() string {
 }
 
 func (c *chunkedSecrets) decodeRelease(ctx context.Context, indexSecret *corev1.Secret) (*release.Release, error) {
-	extraChunkNamesData, ok := indexSecret.Data["extraChunks"]
+	extraChunkNames, err := indexChunkNames(indexSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.MaxReadChunks > 0 && 1+len(extraChunkNames) > c.MaxReadChunks {
+		return nil, fmt.Errorf("release too large: %q consists of %d chunks, which exceeds the maximum of %d", indexSecret.Name, 1+len(extraChunkNames), c.MaxReadChunks)
+	}
+
+	firstChunkData, ok := indexSecret.Data["chunk"]
 	if !ok {
-		return nil, fmt.Errorf("index secret %q missing chunks data: %#v", indexSecret.Name, indexSecret)
+		return nil, fmt.Errorf("index secret %q missing chunk %d data", indexSecret.Name, 1)
+	}
+	keyID, encrypted := indexSecret.Data["keyID"]
+	if encrypted && c.Encrypter == nil {
+		return nil, fmt.Errorf("release %q is encrypted with key %q, but no encrypter is configured", indexSecret.Name, keyID)
 	}
 
-	var extraChunkNames []string
-	if err := json.Unmarshal(extraChunkNamesData, &extraChunkNames); err != nil {
-		return nil, fmt.Errorf("failed to parse chunk names from index: %w", err)
+	// Releases written before the codec was recorded are gzipped.
+	codec, err := codecForName(string(indexSecret.Data["codec"]))
+	if err != nil {
+		return nil, fmt.Errorf("release %q: %w", indexSecret.Name, err)
 	}
 
-	if c.MaxReadChunks > 0 && 1+len(extraChunkNames) > c.MaxReadChunks {
-		return nil, fmt.Errorf("release too large: %q consists of %d chunks, which exceeds the maximum of %d", indexSecret.Name, 1+len(extraChunkNames), c.MaxReadChunks)
+	extraChunks, err := c.getChunks(ctx, indexSecret.Namespace, extraChunkNames)
+	if err != nil {
+		return nil, err
+	}
+	readers := make([]io.Reader, 0, 1+len(extraChunks))
+	readers = append(readers, bytes.NewReader(firstChunkData))
+	for _, chunkData := range extraChunks {
+		readers = append(readers, bytes.NewReader(chunkData))
 	}
 
-	pr, pw := io.Pipe()
-	go func() {
-		defer pw.Close()
-		firstChunkData, ok := indexSecret.Data["chunk"]
-		if !ok {
-			pw.CloseWithError(fmt.Errorf("index secret %q missing chunk %d data", indexSecret.Name, 1))
-			return
-		}
-		if _, err := pw.Write(firstChunkData); err != nil {
-			pw.CloseWithError(fmt.Errorf("failed to write chunk %d data from %q: %w", 1, indexSecret.Name, err))
-			return
+	data := io.MultiReader(readers...)
+	if encrypted {
+		ciphertext, err := io.ReadAll(data)
+		if err != nil {
+			return nil, err
 		}
-		for i, chunkName := range extraChunkNames {
-			chunkSecret, err := c.client.Get(ctx, chunkName, metav1.GetOptions{})
-			if err != nil {
-				pw.CloseWithError(fmt.Errorf("failed to get chunk %d secret %q: %w", i+2, chunkName, err))
-				return
-			}
-			chunkData, ok := chunkSecret.Data["chunk"]
-			if !ok {
-				pw.CloseWithError(fmt.Errorf("chunk %d secret %q missing chunk data", i+2, chunkName))
-				return
-			}
-			if _, err := pw.Write(chunkData); err != nil {
-				pw.CloseWithError(fmt.Errorf("failed to write chunk %d data from %q: %w", i+2, chunkName, err))
-				return
-			}
+		plaintext, err := c.Encrypter.Decrypt(ctx, ciphertext, string(keyID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt release %q with key %q: %w", indexSecret.Name, keyID, err)
 		}
-	}()
+		data = bytes.NewReader(plaintext)
+	}
 
-	gzr, err := gzip.NewReader(pr)
+	decompressed, err := codec.NewReader(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		return nil, fmt.Errorf("failed to create %s reader: %w", codec.Name(), err)
 	}
-	releaseDecoder := json.NewDecoder(gzr)
+	defer decompressed.Close()
+	releaseDecoder := json.NewDecoder(decompressed)
 	var wrappedRelease releaseWrapper
 	if err := releaseDecoder.Decode(&wrappedRelease); err != nil {
 		return nil, fmt.Errorf("failed to decode release: %w", err)
@@ -442,6 +668,65 @@ func (c *chunkedSecrets) decodeRelease(ctx context.Context, indexSecret *corev1.
 	return &r, nil
 }
 
+// getChunks returns the data of the chunk secrets names in namespace, in
+// order. Up to MaxConcurrentChunkReads chunks are fetched at a time.
+func (c *chunkedSecrets) getChunks(ctx context.Context, namespace string, names []string) ([][]byte, error) {
+	data := make([][]byte, len(names))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.MaxConcurrentChunkReads)
+	for i, name := range names {
+		g.Go(func() error {
+			chunkData, err := c.getChunk(ctx, namespace, name)
+			if err != nil {
+				return fmt.Errorf("failed to get chunk %d secret %q: %w", i+2, name, err)
+			}
+			data[i] = chunkData
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// getChunk returns the data of the chunk secret name in namespace. Chunk
+// secrets are immutable, so their data is cached in ChunkCache, if set.
+func (c *chunkedSecrets) getChunk(ctx context.Context, namespace, name string) ([]byte, error) {
+	if c.ChunkCache != nil {
+		if chunkData, ok := c.ChunkCache.Get(namespace, name); ok {
+			return chunkData, nil
+		}
+	}
+	chunkSecret, err := c.client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	chunkData, ok := chunkSecret.Data["chunk"]
+	if !ok {
+		return nil, errors.New("missing chunk data")
+	}
+	if c.ChunkCache != nil && ptr.Deref(chunkSecret.Immutable, false) {
+		c.ChunkCache.Add(namespace, name, chunkData)
+	}
+	return chunkData, nil
+}
+
+// indexChunkNames returns the names of the chunk secrets referenced by
+// indexSecret, in order.
+func indexChunkNames(indexSecret *corev1.Secret) ([]string, error) {
+	extraChunkNamesData, ok := indexSecret.Data["extraChunks"]
+	if !ok {
+		return nil, fmt.Errorf("index secret %q missing chunks data: %#v", indexSecret.Name, indexSecret)
+	}
+
+	var names []string
+	if err := json.Unmarshal(extraChunkNamesData, &names); err != nil {
+		return nil, fmt.Errorf("failed to parse chunk names from index: %w", err)
+	}
+	return names, nil
+}
+
 func (c *chunkedSecrets) hashForData(data []byte) string {
 	c.hashMu.Lock()
 	defer c.hashMu.Unlock()
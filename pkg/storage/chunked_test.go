@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,9 +10,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/klauspost/compress/zstd"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/rand"
 	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -44,6 +47,11 @@ var _ = Describe("chunkedSecrets", func() {
 			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
 			verifySecrets(secretInterface, 2)
 		})
+		It("should make the index the owner of the chunks", func() {
+			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize*2)
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+			verifyChunkOwners(secretInterface, releaseKey(rel))
+		})
 		It("should create a small release with a single secret", func() {
 			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize/2)
 			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
@@ -57,6 +65,16 @@ var _ = Describe("chunkedSecrets", func() {
 			rel.Info.Status = release.StatusDeployed
 			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(MatchError(driver.ErrReleaseExists))
 		})
+		It("should not delete the chunks of concurrent writers if the release already exists", func() {
+			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize*2)
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+			pending := createPendingChunk(secretInterface, "test-owner", releaseKey(rel))
+
+			rel.Info.Status = release.StatusDeployed
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(MatchError(driver.ErrReleaseExists))
+			_, err := secretInterface.Get(context.Background(), pending, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
 		It("should fail if the release is too large", func() {
 			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize*4)
 			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(MatchError(ContainSubstring("release too large")))
@@ -83,6 +101,16 @@ var _ = Describe("chunkedSecrets", func() {
 			_, err := chunkedDriver.Get(releaseKey(rel))
 			Expect(err).To(MatchError(driver.ErrReleaseNotFound))
 		})
+		It("should not return releases stored by other drivers", func() {
+			rel := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize/2)
+			Expect(driver.NewSecrets(secretInterface).Create(releaseKey(rel), rel)).To(Succeed())
+			Expect(NewChunkedSecrets(secretInterface, "other-owner", ChunkedSecretsConfig{}).Create("other-release.v1", rel)).To(Succeed())
+
+			_, err := chunkedDriver.Get(releaseKey(rel))
+			Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+			_, err = chunkedDriver.Get("other-release.v1")
+			Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+		})
 		It("should fail if the release is too large", func() {
 			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize*2)
 			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
@@ -118,6 +146,48 @@ var _ = Describe("chunkedSecrets", func() {
 			Expect(chunkedDriver.Update(releaseKey(rel), rel)).To(Succeed())
 			verifySecrets(secretInterface, 1)
 		})
+		It("should replace the chunks of a multi-secret release", func() {
+			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize*2)
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+			verifySecrets(secretInterface, 2)
+
+			rel = genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize*2)
+			Expect(chunkedDriver.Update(releaseKey(rel), rel)).To(Succeed())
+			verifySecrets(secretInterface, 2)
+			verifyChunkOwners(secretInterface, releaseKey(rel))
+
+			actual, err := chunkedDriver.Get(releaseKey(rel))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(rel))
+		})
+		It("should only delete the chunks of the previous release", func() {
+			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize*2)
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+			previous, err := secretInterface.Get(context.Background(), releaseKey(rel), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			var previousChunks []string
+			Expect(json.Unmarshal(previous.Data["extraChunks"], &previousChunks)).To(Succeed())
+			pending := createPendingChunk(secretInterface, "test-owner", releaseKey(rel))
+
+			rel = genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize*2)
+			Expect(chunkedDriver.Update(releaseKey(rel), rel)).To(Succeed())
+			for _, name := range previousChunks {
+				_, err := secretInterface.Get(context.Background(), name, metav1.GetOptions{})
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+			_, err = secretInterface.Get(context.Background(), pending, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should reuse chunks with unchanged content", func() {
+			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize*2)
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+			Expect(chunkedDriver.Update(releaseKey(rel), rel)).To(Succeed())
+			verifySecrets(secretInterface, 2)
+
+			actual, err := chunkedDriver.Get(releaseKey(rel))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(rel))
+		})
 		It("should fail if the release does not exist", func() {
 			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize/2)
 			Expect(chunkedDriver.Update(releaseKey(rel), rel)).To(MatchError(driver.ErrReleaseNotFound))
@@ -286,6 +356,236 @@ var _ = Describe("chunkedSecrets", func() {
 			Expect(allReleases).To(HaveLen(7))
 		})
 	})
+
+	var _ = Describe("Chunk reads", func() {
+		var manyChunksDriver func(cache ChunkCache) driver.Driver
+
+		BeforeEach(func() {
+			manyChunksDriver = func(cache ChunkCache) driver.Driver {
+				return NewChunkedSecrets(secretInterface, "test-owner", ChunkedSecretsConfig{
+					ChunkSize:               chunkSize,
+					MaxConcurrentChunkReads: 2,
+					ChunkCache:              cache,
+				})
+			}
+		})
+
+		It("should get a release with many chunks", func() {
+			d := manyChunksDriver(nil)
+			expected := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize*8)
+			Expect(d.Create(releaseKey(expected), expected)).To(Succeed())
+			index, err := secretInterface.Get(context.Background(), releaseKey(expected), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			var chunkNames []string
+			Expect(json.Unmarshal(index.Data["extraChunks"], &chunkNames)).To(Succeed())
+			Expect(len(chunkNames)).To(BeNumerically(">", 2))
+
+			actual, err := d.Get(releaseKey(expected))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		})
+		It("should read cached chunks without fetching them", func() {
+			cache := NewLRUChunkCache(10)
+			expected := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize*4)
+			Expect(manyChunksDriver(cache).Create(releaseKey(expected), expected)).To(Succeed())
+			_, err := manyChunksDriver(cache).Get(releaseKey(expected))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(secretInterface.DeleteCollection(context.Background(), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: "type=chunk"})).To(Succeed())
+			_, err = manyChunksDriver(nil).Get(releaseKey(expected))
+			Expect(err).To(MatchError(ContainSubstring("failed to get chunk")))
+
+			actual, err := manyChunksDriver(cache).Get(releaseKey(expected))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		})
+	})
+
+	var _ = Describe("ListMetadata", func() {
+		BeforeEach(func() {
+			releases := []*release.Release{
+				genRelease("b", 1, release.StatusDeployed, nil, chunkSize*2),
+				genRelease("a", 2, release.StatusDeployed, map[string]string{"key1": "val1"}, chunkSize/2),
+				genRelease("a", 1, release.StatusSuperseded, nil, chunkSize*2),
+			}
+			for _, rel := range releases {
+				Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+			}
+		})
+
+		It("should list the metadata of all releases in order", func() {
+			metadata, err := chunkedDriver.(MetadataLister).ListMetadata(context.Background(), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadata).To(HaveLen(3))
+			Expect(metadata[0]).To(And(
+				HaveField("Key", "a.v1"),
+				HaveField("Name", "a"),
+				HaveField("Version", 1),
+				HaveField("Status", release.StatusSuperseded),
+				HaveField("Created", Not(BeZero())),
+			))
+			Expect(metadata[1]).To(HaveField("Key", "a.v2"))
+			Expect(metadata[2]).To(HaveField("Key", "b.v1"))
+			Expect(metadata[2].Labels).To(HaveKeyWithValue("owner", "test-owner"))
+		})
+		It("should filter by system labels", func() {
+			metadata, err := chunkedDriver.(MetadataLister).ListMetadata(context.Background(), map[string]string{"owner": "helm", "status": "deployed"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadata).To(HaveExactElements(HaveField("Key", "a.v2"), HaveField("Key", "b.v1")))
+		})
+		It("should not read chunks", func() {
+			Expect(secretInterface.DeleteCollection(context.Background(), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: "type=chunk"})).To(Succeed())
+			metadata, err := chunkedDriver.(MetadataLister).ListMetadata(context.Background(), map[string]string{"name": "a"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metadata).To(HaveLen(2))
+		})
+		It("should fail for custom labels", func() {
+			_, err := chunkedDriver.(MetadataLister).ListMetadata(context.Background(), map[string]string{"key1": "val1"})
+			Expect(err).To(MatchError(ContainSubstring(`label "key1" is not stored on index secrets`)))
+		})
+	})
+
+	var _ = Describe("Encryption", func() {
+		var (
+			oldKey = AESGCMKey{ID: "key-1", Key: []byte("0123456789abcdef0123456789abcdef")}
+			newKey = AESGCMKey{ID: "key-2", Key: []byte("fedcba9876543210fedcba9876543210")}
+		)
+
+		newEncryptedDriver := func(keys ...AESGCMKey) driver.Driver {
+			GinkgoHelper()
+			encrypter, err := NewAESGCMEncrypter(keys...)
+			Expect(err).ToNot(HaveOccurred())
+			return NewChunkedSecrets(secretInterface, "test-owner", ChunkedSecretsConfig{
+				ChunkSize:      chunkSize,
+				MaxReadChunks:  2,
+				MaxWriteChunks: 2,
+				Encrypter:      encrypter,
+			})
+		}
+
+		It("should store the key ID and read back an encrypted release", func() {
+			encryptedDriver := newEncryptedDriver(oldKey)
+			expected := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize)
+			Expect(encryptedDriver.Create(releaseKey(expected), expected)).To(Succeed())
+
+			index, err := secretInterface.Get(context.Background(), releaseKey(expected), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(index.Labels).To(HaveKeyWithValue("keyID", "key-1"))
+			Expect(index.Data).To(HaveKeyWithValue("keyID", []byte("key-1")))
+
+			actual, err := encryptedDriver.Get(releaseKey(expected))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		})
+		It("should fail to read an encrypted release without an encrypter", func() {
+			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize/2)
+			Expect(newEncryptedDriver(oldKey).Create(releaseKey(rel), rel)).To(Succeed())
+
+			_, err := chunkedDriver.Get(releaseKey(rel))
+			Expect(err).To(MatchError(ContainSubstring("no encrypter is configured")))
+		})
+		It("should read releases that were stored without encryption", func() {
+			expected := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize/2)
+			Expect(chunkedDriver.Create(releaseKey(expected), expected)).To(Succeed())
+
+			actual, err := newEncryptedDriver(oldKey).Get(releaseKey(expected))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		})
+		It("should re-encrypt a release with the new primary key on update", func() {
+			rel := genRelease("test-release", 1, release.StatusPendingInstall, nil, chunkSize/2)
+			Expect(newEncryptedDriver(oldKey).Create(releaseKey(rel), rel)).To(Succeed())
+
+			rotatedDriver := newEncryptedDriver(newKey, oldKey)
+			actual, err := rotatedDriver.Get(releaseKey(rel))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(rel))
+
+			rel.Info.Status = release.StatusDeployed
+			Expect(rotatedDriver.Update(releaseKey(rel), rel)).To(Succeed())
+
+			index, err := secretInterface.Get(context.Background(), releaseKey(rel), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(index.Labels).To(HaveKeyWithValue("keyID", "key-2"))
+
+			actual, err = newEncryptedDriver(newKey).Get(releaseKey(rel))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(rel))
+		})
+		It("should not return the key ID as a release label", func() {
+			encryptedDriver := newEncryptedDriver(oldKey)
+			rel := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize/2)
+			Expect(encryptedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+
+			releases, err := encryptedDriver.Query(map[string]string{"keyID": "key-1"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(releases).To(HaveLen(1))
+			Expect(releases[0].Labels).ToNot(HaveKey("keyID"))
+		})
+	})
+
+	var _ = Describe("Codecs", func() {
+		newCodecDriver := func(codec Codec) driver.Driver {
+			return NewChunkedSecrets(secretInterface, "test-owner", ChunkedSecretsConfig{
+				ChunkSize:      chunkSize,
+				MaxReadChunks:  2,
+				MaxWriteChunks: 2,
+				Codec:          codec,
+			})
+		}
+
+		It("should record the default codec in the index", func() {
+			rel := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize/2)
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+
+			index, err := secretInterface.Get(context.Background(), releaseKey(rel), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(index.Data).To(HaveKeyWithValue("codec", []byte(CodecGzip)))
+		})
+		It("should read releases written with any codec", func() {
+			zstdRelease := genRelease("zstd-release", 1, release.StatusDeployed, nil, chunkSize)
+			Expect(newCodecDriver(ZstdCodec(zstd.SpeedBestCompression)).Create(releaseKey(zstdRelease), zstdRelease)).To(Succeed())
+			gzipRelease := genRelease("gzip-release", 1, release.StatusDeployed, nil, chunkSize)
+			Expect(newCodecDriver(GzipCodec(gzip.BestSpeed)).Create(releaseKey(gzipRelease), gzipRelease)).To(Succeed())
+
+			index, err := secretInterface.Get(context.Background(), releaseKey(zstdRelease), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(index.Data).To(HaveKeyWithValue("codec", []byte(CodecZstd)))
+
+			for _, expected := range []*release.Release{zstdRelease, gzipRelease} {
+				actual, err := chunkedDriver.Get(releaseKey(expected))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual).To(Equal(expected))
+			}
+		})
+		It("should read gzipped releases without a recorded codec", func() {
+			expected := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize/2)
+			Expect(chunkedDriver.Create(releaseKey(expected), expected)).To(Succeed())
+
+			index, err := secretInterface.Get(context.Background(), releaseKey(expected), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			delete(index.Data, "codec")
+			_, err = secretInterface.Update(context.Background(), index, metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			actual, err := newCodecDriver(ZstdCodec(zstd.SpeedDefault)).Get(releaseKey(expected))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		})
+		It("should fail to read releases with an unknown codec", func() {
+			rel := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize/2)
+			Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+
+			index, err := secretInterface.Get(context.Background(), releaseKey(rel), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			index.Data["codec"] = []byte("brotli")
+			_, err = secretInterface.Update(context.Background(), index, metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = chunkedDriver.Get(releaseKey(rel))
+			Expect(err).To(MatchError(ContainSubstring(`unknown codec "brotli"`)))
+		})
+	})
 })
 
 func verifySecrets(secretInterface clientcorev1.SecretInterface, expected int) {
@@ -327,6 +627,41 @@ func verifySecrets(secretInterface clientcorev1.SecretInterface, expected int) {
 	Expect(actualExtraChunkNames).To(ConsistOf(expectedExtraChunkNames))
 }
 
+// createPendingChunk creates a chunk secret of the release stored under key
+// that no index references, like a chunk of a concurrent writer that has not
+// written its index yet. It returns the name of the chunk secret.
+func createPendingChunk(secretInterface clientcorev1.SecretInterface, owner, key string) string {
+	GinkgoHelper()
+
+	chunkSecret := &corev1.Secret{
+		Type: SecretTypeChunkedChunk,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("%s-pending-%s", key, rand.String(5)),
+			Labels: newChunkLabels(owner, key),
+		},
+		Data: map[string][]byte{"chunk": []byte("pending")},
+	}
+	_, err := secretInterface.Create(context.Background(), chunkSecret, metav1.CreateOptions{})
+	Expect(err).ToNot(HaveOccurred())
+	return chunkSecret.Name
+}
+
+func verifyChunkOwners(secretInterface clientcorev1.SecretInterface, key string) {
+	GinkgoHelper()
+
+	index, err := secretInterface.Get(context.Background(), key, metav1.GetOptions{})
+	Expect(err).ToNot(HaveOccurred())
+
+	var chunkNames []string
+	Expect(json.Unmarshal(index.Data["extraChunks"], &chunkNames)).To(Succeed())
+	for _, name := range chunkNames {
+		chunk, err := secretInterface.Get(context.Background(), name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(chunk.OwnerReferences).To(ConsistOf(HaveField("Name", index.Name)))
+		Expect(chunk.OwnerReferences[0].UID).To(Equal(index.UID))
+	}
+}
+
 func releaseKey(rel *release.Release) string {
 	return fmt.Sprintf("%s.v%d", rel.Name, rel.Version)
 }
@@ -0,0 +1,141 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec compresses the encoded releases stored by the chunked secrets driver.
+// The name of the codec is stored in the index secret of each release, so
+// releases can be read regardless of the codec they were written with.
+type Codec interface {
+	// Name identifies the compression format. Codecs that only differ in
+	// their compression level have the same name.
+	Name() string
+
+	// NewWriter returns a writer that compresses data written to it into w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader that decompresses data read from r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+const (
+	// CodecGzip is the name of the gzip codec. Releases without a codec in
+	// their index secret are compressed with gzip.
+	CodecGzip = "gzip"
+
+	// CodecZstd is the name of the zstd codec.
+	CodecZstd = "zstd"
+)
+
+// DefaultCodec is the codec used when ChunkedSecretsConfig.Codec is not set.
+var DefaultCodec = GzipCodec(gzip.BestCompression)
+
+// GzipCodec returns a Codec that compresses data with gzip at level, which
+// ranges from gzip.HuffmanOnly to gzip.BestCompression.
+func GzipCodec(level int) Codec {
+	return gzipCodec{level: level}
+}
+
+type gzipCodec struct {
+	level int
+}
+
+func (gzipCodec) Name() string { return CodecGzip }
+
+func (c gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// ZstdCodec returns a Codec that compresses data with zstd at level. Zstd
+// compresses and in particular decompresses considerably faster than gzip at
+// a similar ratio.
+func ZstdCodec(level zstd.EncoderLevel) Codec {
+	return zstdCodec{level: level}
+}
+
+type zstdCodec struct {
+	level zstd.EncoderLevel
+}
+
+func (zstdCodec) Name() string { return CodecZstd }
+
+func (c zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(c.level), zstd.WithEncoderConcurrency(1))
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// codecForName returns a codec that can read data written by the codec name.
+func codecForName(name string) (Codec, error) {
+	switch name {
+	case "", CodecGzip:
+		return GzipCodec(gzip.DefaultCompression), nil
+	case CodecZstd:
+		return ZstdCodec(zstd.SpeedDefault), nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+}
+
+// ParseCodec parses a codec from a string of the form "<name>[:<level>]".
+// Gzip levels are integers from 1 (fastest) to 9 (best compression), zstd
+// levels are one of "fastest", "default", "better" and "best". Without a
+// level, gzip uses level 9 and zstd uses "default".
+func ParseCodec(s string) (Codec, error) {
+	name, level, hasLevel := strings.Cut(s, ":")
+	switch name {
+	case CodecGzip:
+		if !hasLevel {
+			return GzipCodec(gzip.BestCompression), nil
+		}
+		l, err := strconv.Atoi(level)
+		if err != nil || l < gzip.BestSpeed || l > gzip.BestCompression {
+			return nil, fmt.Errorf("invalid gzip level %q, must be an integer from %d to %d", level, gzip.BestSpeed, gzip.BestCompression)
+		}
+		return GzipCodec(l), nil
+	case CodecZstd:
+		if !hasLevel {
+			return ZstdCodec(zstd.SpeedDefault), nil
+		}
+		ok, l := zstd.EncoderLevelFromString(level)
+		if !ok {
+			return nil, fmt.Errorf("invalid zstd level %q, must be one of fastest, default, better, best", level)
+		}
+		return ZstdCodec(l), nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q, must be %s or %s", name, CodecGzip, CodecZstd)
+	}
+}
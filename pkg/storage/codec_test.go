@@ -0,0 +1,174 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/internal/testutil"
+)
+
+var _ = Describe("ParseCodec", func() {
+	DescribeTable("should parse valid codecs",
+		func(s string, expected Codec) {
+			codec, err := ParseCodec(s)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(codec).To(Equal(expected))
+		},
+		Entry("gzip", "gzip", GzipCodec(gzip.BestCompression)),
+		Entry("gzip with level", "gzip:1", GzipCodec(gzip.BestSpeed)),
+		Entry("zstd", "zstd", ZstdCodec(zstd.SpeedDefault)),
+		Entry("zstd with level", "zstd:best", ZstdCodec(zstd.SpeedBestCompression)),
+	)
+	DescribeTable("should fail for invalid codecs",
+		func(s, expectedErr string) {
+			_, err := ParseCodec(s)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("unknown codec", "brotli", `unknown codec "brotli"`),
+		Entry("gzip level out of range", "gzip:10", `invalid gzip level "10"`),
+		Entry("non-numeric gzip level", "gzip:best", `invalid gzip level "best"`),
+		Entry("unknown zstd level", "zstd:9", `invalid zstd level "9"`),
+	)
+})
+
+// benchmarkCodecs are the codecs compared by BenchmarkCodecs.
+var benchmarkCodecs = []string{"gzip:1", "gzip:6", "gzip:9", "zstd:fastest", "zstd:default", "zstd:better", "zstd:best"}
+
+// BenchmarkCodecs compares the time it takes to encode and decode releases
+// with each codec, and reports the size of the stored data as bytes/release.
+// Run it with:
+//
+//	go test ./pkg/storage -run '^$' -bench Codecs
+func BenchmarkCodecs(b *testing.B) {
+	releases := benchmarkReleases(b)
+	for _, name := range slices.Sorted(maps.Keys(releases)) {
+		rls := releases[name]
+		for _, codecName := range benchmarkCodecs {
+			codec, err := ParseCodec(codecName)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// Without a client, the driver can only encode and decode
+			// releases that fit into the index secret.
+			d := NewChunkedSecrets(nil, "benchmark", ChunkedSecretsConfig{
+				ChunkSize: math.MaxInt32,
+				Codec:     codec,
+			}).(*chunkedSecrets)
+			key := releaseStorageKey(rls.Name, rls.Version)
+
+			b.Run(fmt.Sprintf("release=%s/codec=%s/op=encode", name, codecName), func(b *testing.B) {
+				var size int
+				for i := 0; i < b.N; i++ {
+					chunks, _, err := d.encodeReleaseAsChunks(context.Background(), key, rls)
+					if err != nil {
+						b.Fatal(err)
+					}
+					size = len(chunks[0].data)
+				}
+				b.ReportMetric(float64(size), "bytes/release")
+			})
+
+			chunks, _, err := d.encodeReleaseAsChunks(context.Background(), key, rls)
+			if err != nil {
+				b.Fatal(err)
+			}
+			index := d.indexSecretFromChunks(key, rls, chunks, "")
+			b.Run(fmt.Sprintf("release=%s/codec=%s/op=decode", name, codecName), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := d.decodeRelease(context.Background(), index); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// benchmarkReleases returns releases of the testdata charts and a release
+// with many CRDs, which is typical for operators of large applications.
+func benchmarkReleases(b *testing.B) map[string]*release.Release {
+	b.Helper()
+	releases := map[string]*release.Release{}
+	for _, path := range []string{"test-chart", "test-chart-1.2.0.tgz"} {
+		chrt := testutil.MustLoadChart(filepath.Join("..", "internal", "testdata", path))
+		releases[strings.TrimSuffix(path, ".tgz")] = renderRelease(b, &chrt)
+	}
+
+	var manifest strings.Builder
+	for i := 0; i < 50; i++ {
+		crd := testutil.BuildTestCRD(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: fmt.Sprintf("Kind%d", i)})
+		data, err := yaml.Marshal(crd)
+		if err != nil {
+			b.Fatal(err)
+		}
+		manifest.WriteString("---\n")
+		manifest.Write(data)
+	}
+	crds := renderRelease(b, &chart.Chart{Metadata: &chart.Metadata{Name: "crds", Version: "1.0.0", APIVersion: chart.APIVersionV2}})
+	crds.Manifest = manifest.String()
+	releases["crds"] = crds
+	return releases
+}
+
+// renderRelease returns a deployed release of chrt with its default values.
+func renderRelease(b *testing.B, chrt *chart.Chart) *release.Release {
+	b.Helper()
+	options := chartutil.ReleaseOptions{Name: "benchmark", Namespace: "default", Revision: 1, IsInstall: true}
+	values, err := chartutil.ToRenderValues(chrt, nil, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		b.Fatal(err)
+	}
+	rendered, err := engine.Render(chrt, values)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var manifest strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(rendered)) {
+		if !strings.HasSuffix(name, ".yaml") || strings.TrimSpace(rendered[name]) == "" {
+			continue
+		}
+		fmt.Fprintf(&manifest, "---\n# Source: %s\n%s\n", name, rendered[name])
+	}
+	return &release.Release{
+		Name:      options.Name,
+		Namespace: options.Namespace,
+		Version:   options.Revision,
+		Chart:     chrt,
+		Config:    chrt.Values,
+		Manifest:  manifest.String(),
+		Info:      &release.Info{Status: release.StatusDeployed},
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Encrypter encrypts and decrypts the data of releases stored by the chunked
+// secrets driver. Data is always encrypted with the current primary key, while
+// all keys that may still be in use must remain available for decryption.
+// This allows rotating keys: releases are re-encrypted with the new primary
+// key the next time they are updated.
+//
+// Key IDs are stored in the "keyID" label of index secrets, so they must be
+// valid label values.
+type Encrypter interface {
+	// Encrypt encrypts plaintext and returns the ciphertext along with the ID
+	// of the key that was used.
+	Encrypt(ctx context.Context, plaintext []byte) (ciphertext []byte, keyID string, err error)
+
+	// Decrypt decrypts ciphertext that was encrypted with the key keyID.
+	Decrypt(ctx context.Context, ciphertext []byte, keyID string) ([]byte, error)
+}
+
+// AESGCMKey is a named AES key for the AES-GCM encrypter.
+type AESGCMKey struct {
+	ID  string
+	Key []byte
+}
+
+// NewAESGCMEncrypter returns an Encrypter that encrypts data with AES-GCM.
+// The first key is the primary key that is used for encryption. All keys are
+// used for decryption. Keys must be 16, 24 or 32 bytes long to select
+// AES-128, AES-192 or AES-256.
+func NewAESGCMEncrypter(keys ...AESGCMKey) (Encrypter, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+	e := &aesGCMEncrypter{
+		primary: keys[0].ID,
+		aeads:   make(map[string]cipher.AEAD, len(keys)),
+	}
+	for _, k := range keys {
+		if err := validateKeyID(k.ID); err != nil {
+			return nil, err
+		}
+		if _, ok := e.aeads[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %q", k.ID)
+		}
+		aead, err := newAESGCM(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", k.ID, err)
+		}
+		e.aeads[k.ID] = aead
+	}
+	return e, nil
+}
+
+// LoadAESGCMEncrypter returns an AES-GCM Encrypter with the keys from the file
+// at path, e.g. a mounted secret. Each non-empty line of the file that does
+// not start with '#' contains a key ID and a base64 encoded key, separated by
+// '=':
+//
+//	key-2=9cbXOZ7Ea2Q2z4bW0kxkx2xfWsCz5GZ6oRwU3MbqHnE=
+//	key-1=Pj1F6d6k7yV1c3U2cM2s0dV4mU6uR0eQ4a7D9sQWm3s=
+//
+// The first key is the primary key. To rotate keys, add the new key at the
+// top and keep the old keys until all releases have been updated.
+func LoadAESGCMEncrypter(path string) (Encrypter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+
+	var keys []AESGCMKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("key file line %d: expected <key ID>=<base64 encoded key>", line)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("key file line %d: invalid key encoding: %w", line, err)
+		}
+		keys = append(keys, AESGCMKey{ID: strings.TrimSpace(id), Key: key})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	return NewAESGCMEncrypter(keys...)
+}
+
+type aesGCMEncrypter struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+func (e *aesGCMEncrypter) Encrypt(_ context.Context, plaintext []byte) ([]byte, string, error) {
+	ciphertext, err := seal(e.aeads[e.primary], plaintext)
+	if err != nil {
+		return nil, "", err
+	}
+	return ciphertext, e.primary, nil
+}
+
+func (e *aesGCMEncrypter) Decrypt(_ context.Context, ciphertext []byte, keyID string) ([]byte, error) {
+	aead, ok := e.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", keyID)
+	}
+	return open(aead, ciphertext)
+}
+
+// KeyManagementService wraps and unwraps data encryption keys with key
+// encryption keys that it manages, e.g. in a cloud KMS or an HSM. Plugins for
+// such services implement this interface.
+type KeyManagementService interface {
+	// WrapKey encrypts a data encryption key with the current key encryption
+	// key and returns the wrapped key along with the ID of that key.
+	WrapKey(ctx context.Context, dek []byte) (wrapped []byte, keyID string, err error)
+
+	// UnwrapKey decrypts a data encryption key that was wrapped with the key
+	// encryption key keyID.
+	UnwrapKey(ctx context.Context, wrapped []byte, keyID string) ([]byte, error)
+}
+
+// NewEnvelopeEncrypter returns an Encrypter that uses envelope encryption:
+// data is encrypted with AES-GCM using a new random data encryption key,
+// which is wrapped by kms and stored along with the data. The key ID of an
+// encrypted release is the ID of the key encryption key.
+func NewEnvelopeEncrypter(kms KeyManagementService) Encrypter {
+	return &envelopeEncrypter{kms: kms}
+}
+
+type envelopeEncrypter struct {
+	kms KeyManagementService
+}
+
+// dekSize is the size of data encryption keys, which selects AES-256.
+const dekSize = 32
+
+func (e *envelopeEncrypter) Encrypt(ctx context.Context, plaintext []byte) ([]byte, string, error) {
+	dek := make([]byte, dekSize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, "", fmt.Errorf("generate data encryption key: %w", err)
+	}
+	wrapped, keyID, err := e.kms.WrapKey(ctx, dek)
+	if err != nil {
+		return nil, "", fmt.Errorf("wrap data encryption key: %w", err)
+	}
+	if err := validateKeyID(keyID); err != nil {
+		return nil, "", err
+	}
+	aead, err := newAESGCM(dek)
+	if err != nil {
+		return nil, "", err
+	}
+	sealed, err := seal(aead, plaintext)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The wrapped key is prefixed with its length, followed by the data.
+	out := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(wrapped)+len(sealed)), uint32(len(wrapped)))
+	out = append(out, wrapped...)
+	return append(out, sealed...), keyID, nil
+}
+
+func (e *envelopeEncrypter) Decrypt(ctx context.Context, ciphertext []byte, keyID string) ([]byte, error) {
+	if len(ciphertext) < 4 {
+		return nil, errors.New("ciphertext too short")
+	}
+	n := binary.BigEndian.Uint32(ciphertext)
+	if uint64(len(ciphertext)-4) < uint64(n) {
+		return nil, errors.New("ciphertext too short")
+	}
+	wrapped, sealed := ciphertext[4:4+n], ciphertext[4+n:]
+
+	dek, err := e.kms.UnwrapKey(ctx, wrapped, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data encryption key: %w", err)
+	}
+	aead, err := newAESGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, sealed)
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which is prepended to the
+// returned ciphertext.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
+
+func validateKeyID(keyID string) error {
+	if keyID == "" {
+		return errors.New("key ID must not be empty")
+	}
+	if errs := validation.IsValidLabelValue(keyID); len(errs) > 0 {
+		return fmt.Errorf("invalid key ID %q: %s", keyID, strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encrypter", func() {
+	var (
+		ctx       = context.Background()
+		key1      = AESGCMKey{ID: "key-1", Key: bytes.Repeat([]byte{1}, 32)}
+		key2      = AESGCMKey{ID: "key-2", Key: bytes.Repeat([]byte{2}, 16)}
+		plaintext = []byte("release data")
+	)
+
+	Describe("NewAESGCMEncrypter", func() {
+		It("should encrypt with the primary key", func() {
+			e, err := NewAESGCMEncrypter(key2, key1)
+			Expect(err).ToNot(HaveOccurred())
+
+			ciphertext, keyID, err := e.Encrypt(ctx, plaintext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keyID).To(Equal("key-2"))
+			Expect(ciphertext).ToNot(ContainSubstring(string(plaintext)))
+
+			decrypted, err := e.Decrypt(ctx, ciphertext, keyID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted).To(Equal(plaintext))
+		})
+		It("should decrypt with secondary keys", func() {
+			old, err := NewAESGCMEncrypter(key1)
+			Expect(err).ToNot(HaveOccurred())
+			ciphertext, keyID, err := old.Encrypt(ctx, plaintext)
+			Expect(err).ToNot(HaveOccurred())
+
+			rotated, err := NewAESGCMEncrypter(key2, key1)
+			Expect(err).ToNot(HaveOccurred())
+			decrypted, err := rotated.Decrypt(ctx, ciphertext, keyID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted).To(Equal(plaintext))
+		})
+		It("should fail to decrypt with an unknown key ID", func() {
+			e, err := NewAESGCMEncrypter(key1)
+			Expect(err).ToNot(HaveOccurred())
+			ciphertext, _, err := e.Encrypt(ctx, plaintext)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = e.Decrypt(ctx, ciphertext, "key-2")
+			Expect(err).To(MatchError(ContainSubstring(`unknown key ID "key-2"`)))
+		})
+		It("should fail to decrypt tampered data", func() {
+			e, err := NewAESGCMEncrypter(key1)
+			Expect(err).ToNot(HaveOccurred())
+			ciphertext, keyID, err := e.Encrypt(ctx, plaintext)
+			Expect(err).ToNot(HaveOccurred())
+
+			ciphertext[len(ciphertext)-1] ^= 0xff
+			_, err = e.Decrypt(ctx, ciphertext, keyID)
+			Expect(err).To(HaveOccurred())
+		})
+		DescribeTable("should reject invalid keys",
+			func(keys []AESGCMKey, expectedErr string) {
+				_, err := NewAESGCMEncrypter(keys...)
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			},
+			Entry("no keys", nil, "at least one key is required"),
+			Entry("empty key ID", []AESGCMKey{{Key: key1.Key}}, "key ID must not be empty"),
+			Entry("key ID that is not a label value", []AESGCMKey{{ID: "key/1", Key: key1.Key}}, `invalid key ID "key/1"`),
+			Entry("duplicate key ID", []AESGCMKey{key1, key1}, `duplicate key ID "key-1"`),
+			Entry("invalid key size", []AESGCMKey{{ID: "key-1", Key: []byte("short")}}, `invalid key "key-1"`),
+		)
+	})
+
+	Describe("LoadAESGCMEncrypter", func() {
+		var keyFile string
+
+		BeforeEach(func() {
+			keyFile = filepath.Join(GinkgoT().TempDir(), "keys")
+		})
+
+		It("should load keys with the first key as the primary key", func() {
+			Expect(os.WriteFile(keyFile, []byte(fmt.Sprintf("# rotated\n%s=%s\n\n%s = %s\n",
+				key2.ID, base64.StdEncoding.EncodeToString(key2.Key),
+				key1.ID, base64.StdEncoding.EncodeToString(key1.Key),
+			)), 0600)).To(Succeed())
+
+			e, err := LoadAESGCMEncrypter(keyFile)
+			Expect(err).ToNot(HaveOccurred())
+			_, keyID, err := e.Encrypt(ctx, plaintext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keyID).To(Equal("key-2"))
+		})
+		It("should fail if the file does not exist", func() {
+			_, err := LoadAESGCMEncrypter(keyFile)
+			Expect(err).To(MatchError(ContainSubstring("read key file")))
+		})
+		It("should fail if the file has no keys", func() {
+			Expect(os.WriteFile(keyFile, []byte("# no keys\n"), 0600)).To(Succeed())
+			_, err := LoadAESGCMEncrypter(keyFile)
+			Expect(err).To(MatchError(ContainSubstring("at least one key is required")))
+		})
+		It("should fail on a malformed line", func() {
+			Expect(os.WriteFile(keyFile, []byte("key-1\n"), 0600)).To(Succeed())
+			_, err := LoadAESGCMEncrypter(keyFile)
+			Expect(err).To(MatchError(ContainSubstring("key file line 1")))
+		})
+		It("should fail on a key that is not base64 encoded", func() {
+			Expect(os.WriteFile(keyFile, []byte("key-1=not base64!\n"), 0600)).To(Succeed())
+			_, err := LoadAESGCMEncrypter(keyFile)
+			Expect(err).To(MatchError(ContainSubstring("invalid key encoding")))
+		})
+	})
+
+	Describe("NewEnvelopeEncrypter", func() {
+		It("should encrypt with a wrapped data encryption key", func() {
+			kms := &fakeKMS{keyID: "kek-1"}
+			e := NewEnvelopeEncrypter(kms)
+
+			ciphertext, keyID, err := e.Encrypt(ctx, plaintext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keyID).To(Equal("kek-1"))
+			Expect(kms.wrapped).To(Equal(1))
+
+			decrypted, err := e.Decrypt(ctx, ciphertext, keyID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted).To(Equal(plaintext))
+		})
+		It("should fail if the key cannot be wrapped", func() {
+			e := NewEnvelopeEncrypter(&fakeKMS{err: errors.New("kms unavailable")})
+			_, _, err := e.Encrypt(ctx, plaintext)
+			Expect(err).To(MatchError(ContainSubstring("kms unavailable")))
+		})
+		It("should fail if the key cannot be unwrapped", func() {
+			e := NewEnvelopeEncrypter(&fakeKMS{keyID: "kek-1"})
+			ciphertext, _, err := e.Encrypt(ctx, plaintext)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = e.Decrypt(ctx, ciphertext, "kek-2")
+			Expect(err).To(MatchError(ContainSubstring("unwrap data encryption key")))
+		})
+		It("should fail on truncated data", func() {
+			e := NewEnvelopeEncrypter(&fakeKMS{keyID: "kek-1"})
+			_, err := e.Decrypt(ctx, []byte{0, 0, 1, 0}, "kek-1")
+			Expect(err).To(MatchError(ContainSubstring("ciphertext too short")))
+		})
+	})
+})
+
+// fakeKMS "wraps" keys by XORing them with a constant, and only unwraps keys
+// that were wrapped with its own key ID.
+type fakeKMS struct {
+	keyID   string
+	err     error
+	wrapped int
+}
+
+func (k *fakeKMS) WrapKey(_ context.Context, dek []byte) ([]byte, string, error) {
+	if k.err != nil {
+		return nil, "", k.err
+	}
+	k.wrapped++
+	return xorKey(dek), k.keyID, nil
+}
+
+func (k *fakeKMS) UnwrapKey(_ context.Context, wrapped []byte, keyID string) ([]byte, error) {
+	if keyID != k.keyID {
+		return nil, fmt.Errorf("unknown key encryption key %q", keyID)
+	}
+	return xorKey(wrapped), nil
+}
+
+func xorKey(key []byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ 0x5a
+	}
+	return out
+}
@@ -16,6 +16,11 @@ func newIndexLabels(owner, key string, rls *release.Release) map[string]string {
 	labels["version"] = strconv.Itoa(rls.Version)
 	labels["key"] = key
 	labels["type"] = "index"
+	// Temporary copies of Migrate are marked on the index, so that they can
+	// be told apart without decoding them.
+	if v := rls.Labels[MigratingLabel]; v != "" {
+		labels[MigratingLabel] = v
+	}
 	return labels
 }
 
@@ -31,15 +36,19 @@ func newListIndicesLabelSelector(owner string) labels.Selector {
 	return labels.Set{"owner": owner, "type": "index"}.AsSelector()
 }
 
-func newListAllForKeySelector(owner, key string) labels.Selector {
-	return labels.Set{"owner": owner, "key": key}.AsSelector()
+func newListAllSelector(owner string) labels.Selector {
+	return labels.Set{"owner": owner}.AsSelector()
+}
+
+func newListChunksSelector(owner string) labels.Selector {
+	return labels.Set{"owner": owner, "type": "chunk"}.AsSelector()
 }
 
 func newListChunksForKeySelector(owner, key string) labels.Selector {
 	return labels.Set{"owner": owner, "key": key, "type": "chunk"}.AsSelector()
 }
 
-var systemLabels = sets.New[string]("name", "owner", "status", "version", "key", "type", "createdAt", "modifiedAt")
+var systemLabels = sets.New[string]("name", "owner", "status", "version", "key", "type", "createdAt", "modifiedAt", "keyID")
 
 // Checks if label is system
 func isSystemLabel(key string) bool {
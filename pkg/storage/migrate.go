@@ -0,0 +1,353 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// MigratingLabel is the release label of the temporary copies that Migrate
+// creates while it moves a release, see HideMigratingCopies.
+const MigratingLabel = "helm.sdk.operatorframework.io/migrating"
+
+// MigrateOptions configures Migrate.
+type MigrateOptions struct {
+	// DeleteSource deletes the releases from the source driver once all of
+	// them have been copied and verified.
+	DeleteSource bool
+
+	// Filter, if set, limits the migration to the releases it returns true
+	// for. All revisions of a release should be selected together, since
+	// Helm requires the full history to upgrade or roll back a release.
+	Filter func(*release.Release) bool
+
+	Log func(string, ...interface{})
+}
+
+// MigrateResult describes the releases handled by Migrate, identified by
+// their storage keys.
+type MigrateResult struct {
+	// Migrated are the releases that were copied to the target driver.
+	Migrated []string
+
+	// Skipped are the releases that already existed in the target driver
+	// with the same content.
+	Skipped []string
+
+	// Deleted are the releases that were deleted from the source driver.
+	Deleted []string
+}
+
+// Migrate copies all releases, including their full history, from the driver
+// from to the driver to, e.g. from driver.Secrets to NewChunkedSecrets or the
+// other way around. Revision numbers and custom labels are preserved. Each
+// copied release is read back from the target driver and compared to the
+// source before the migration continues.
+//
+// Migrate can be run again after a failure: releases that already exist in
+// the target driver with the same content are skipped. Releases that exist in
+// the target driver with different content cause an error.
+//
+// Some drivers store releases in objects with the same name, e.g. Helm's
+// secrets driver and the chunked secrets driver. Within the same namespace,
+// such releases cannot be copied, so they are moved one at a time if
+// DeleteSource is set: each release is first copied to a temporary key in the
+// target and verified, then deleted from the source and created in the
+// target. The temporary copy has the MigratingLabel. It is deleted once the
+// release has been moved, and is kept if the release can neither be created
+// in the target nor restored in the source. If Migrate was interrupted while
+// moving a release, the next run finishes the move from the temporary copy.
+func Migrate(from, to driver.Driver, opts MigrateOptions) (*MigrateResult, error) {
+	if opts.Log == nil {
+		opts.Log = func(string, ...interface{}) {}
+	}
+	filter := func(rls *release.Release) bool {
+		return !isMigratingCopy(rls) && (opts.Filter == nil || opts.Filter(rls))
+	}
+
+	result := &MigrateResult{}
+	if err := finishMoves(from, to, result, opts); err != nil {
+		return result, err
+	}
+
+	releases, err := from.List(filter)
+	if err != nil {
+		return result, fmt.Errorf("failed to list releases in %s: %w", from.Name(), err)
+	}
+	slices.SortFunc(releases, func(a, b *release.Release) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return a.Version - b.Version
+	})
+
+	deleted := sets.New[string]()
+	for _, listed := range releases {
+		key := releaseStorageKey(listed.Name, listed.Version)
+
+		// Releases returned by List may include the driver's system labels,
+		// so the release is read again to get only its custom labels.
+		rls, err := from.Get(key)
+		if err != nil {
+			return result, fmt.Errorf("failed to get release %q from %s: %w", key, from.Name(), err)
+		}
+
+		opts.Log("migrate: copying release %q from %s to %s", key, from.Name(), to.Name())
+		err = to.Create(key, rls)
+		if errors.Is(err, driver.ErrReleaseExists) {
+			existing, getErr := getRelease(to, key)
+			if getErr == nil {
+				if err := compareReleases(rls, existing); err != nil {
+					return result, fmt.Errorf("release %q already exists in %s with different content: %w", key, to.Name(), err)
+				}
+				opts.Log("migrate: release %q already exists in %s, skipping", key, to.Name())
+				result.Skipped = append(result.Skipped, key)
+				continue
+			}
+
+			// The key is taken, but not by a release of the target driver.
+			// This happens when both drivers store releases in objects with
+			// the same name, e.g. secrets and chunked secrets in the same
+			// namespace, so the release has to be moved instead of copied.
+			if !opts.DeleteSource {
+				return result, fmt.Errorf("release %q cannot be copied, because %s and %s store it under the same name; delete the source to move it instead", key, from.Name(), to.Name())
+			}
+			opts.Log("migrate: moving release %q from %s to %s", key, from.Name(), to.Name())
+			err = moveRelease(from, to, key, rls)
+			if err == nil {
+				deleted.Insert(key)
+				result.Deleted = append(result.Deleted, key)
+			}
+		}
+		if err != nil {
+			return result, err
+		}
+
+		migrated, err := to.Get(key)
+		if err != nil {
+			return result, fmt.Errorf("failed to verify release %q in %s: %w", key, to.Name(), err)
+		}
+		if err := compareReleases(rls, migrated); err != nil {
+			return result, fmt.Errorf("failed to verify release %q in %s: %w", key, to.Name(), err)
+		}
+		result.Migrated = append(result.Migrated, key)
+	}
+
+	if !opts.DeleteSource {
+		return result, nil
+	}
+	for _, rls := range releases {
+		key := releaseStorageKey(rls.Name, rls.Version)
+		if deleted.Has(key) {
+			continue
+		}
+		opts.Log("migrate: deleting release %q from %s", key, from.Name())
+		if _, err := from.Delete(key); err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+			return result, fmt.Errorf("failed to delete release %q from %s: %w", key, from.Name(), err)
+		}
+		result.Deleted = append(result.Deleted, key)
+	}
+	return result, nil
+}
+
+// moveRelease moves rls from the driver from to the driver to, which store
+// it under the same name. A copy of rls is created in to under a temporary
+// key and verified before rls is deleted from from, so that it is never only
+// held in memory. If rls cannot be created in to, it is restored in from.
+func moveRelease(from, to driver.Driver, key string, rls *release.Release) error {
+	tmpKey := migratingKey(key)
+	tmpRls := migratingCopy(rls)
+	if err := to.Create(tmpKey, tmpRls); err != nil && !errors.Is(err, driver.ErrReleaseExists) {
+		return fmt.Errorf("failed to create temporary copy %q of release %q in %s: %w", tmpKey, key, to.Name(), err)
+	}
+	tmp, err := getRelease(to, tmpKey)
+	if err != nil {
+		return fmt.Errorf("failed to verify temporary copy %q of release %q in %s: %w", tmpKey, key, to.Name(), err)
+	}
+	if err := compareReleases(tmpRls, tmp); err != nil {
+		return fmt.Errorf("failed to verify temporary copy %q of release %q in %s: %w", tmpKey, key, to.Name(), err)
+	}
+
+	if _, err := from.Delete(key); err != nil {
+		return fmt.Errorf("failed to delete release %q from %s: %w", key, from.Name(), err)
+	}
+	if err := to.Create(key, rls); err != nil {
+		if restoreErr := from.Create(key, rls); restoreErr != nil {
+			return fmt.Errorf("failed to create release %q in %s: %w; restoring it in %s failed: %v; a copy is kept in %s as %q", key, to.Name(), err, from.Name(), restoreErr, to.Name(), tmpKey)
+		}
+		if _, err := to.Delete(tmpKey); err != nil {
+			return fmt.Errorf("failed to delete temporary copy %q of release %q from %s: %w", tmpKey, key, to.Name(), err)
+		}
+		return fmt.Errorf("failed to create release %q in %s: %w", key, to.Name(), err)
+	}
+	if _, err := to.Delete(tmpKey); err != nil {
+		return fmt.Errorf("failed to delete temporary copy %q of release %q from %s: %w", tmpKey, key, to.Name(), err)
+	}
+	return nil
+}
+
+// finishMoves finishes the moves of releases that an earlier run of Migrate
+// was interrupted in, which left their temporary copies in to. If the source
+// of such a release still exists, it is moved again by Migrate. Otherwise, it
+// is created in to from the temporary copy, unless that already happened.
+func finishMoves(from, to driver.Driver, result *MigrateResult, opts MigrateOptions) error {
+	copies, err := to.List(isMigratingCopy)
+	if err != nil {
+		return fmt.Errorf("failed to list releases in %s: %w", to.Name(), err)
+	}
+	for _, listed := range copies {
+		key := releaseStorageKey(listed.Name, listed.Version)
+		tmpKey := migratingKey(key)
+		tmp, err := getRelease(to, tmpKey)
+		if err != nil {
+			return fmt.Errorf("failed to get temporary copy %q of release %q from %s: %w", tmpKey, key, to.Name(), err)
+		}
+		if _, err := getRelease(from, key); err == nil {
+			continue
+		}
+
+		rls := withoutMigratingLabel(tmp)
+		opts.Log("migrate: finishing the move of release %q to %s", key, to.Name())
+		if err := to.Create(key, rls); err != nil && !errors.Is(err, driver.ErrReleaseExists) {
+			return fmt.Errorf("failed to create release %q in %s: %w", key, to.Name(), err)
+		}
+		migrated, err := getRelease(to, key)
+		if err != nil {
+			return fmt.Errorf("failed to verify release %q in %s: %w", key, to.Name(), err)
+		}
+		if err := compareReleases(rls, migrated); err != nil {
+			return fmt.Errorf("failed to verify release %q in %s: %w; its temporary copy is kept as %q", key, to.Name(), err, tmpKey)
+		}
+		if _, err := to.Delete(tmpKey); err != nil {
+			return fmt.Errorf("failed to delete temporary copy %q of release %q from %s: %w", tmpKey, key, to.Name(), err)
+		}
+		result.Migrated = append(result.Migrated, key)
+	}
+	return nil
+}
+
+// migratingKey returns the key that moveRelease stores the temporary copy of
+// the release key under.
+func migratingKey(key string) string {
+	return key + ".migrating"
+}
+
+// migratingCopy returns a copy of rls with the MigratingLabel.
+func migratingCopy(rls *release.Release) *release.Release {
+	tmp := *rls
+	tmp.Labels = maps.Clone(rls.Labels)
+	if tmp.Labels == nil {
+		tmp.Labels = map[string]string{}
+	}
+	tmp.Labels[MigratingLabel] = "true"
+	return &tmp
+}
+
+// withoutMigratingLabel returns a copy of the temporary copy tmp without the
+// MigratingLabel.
+func withoutMigratingLabel(tmp *release.Release) *release.Release {
+	rls := *tmp
+	rls.Labels = maps.Clone(tmp.Labels)
+	delete(rls.Labels, MigratingLabel)
+	if len(rls.Labels) == 0 {
+		rls.Labels = nil
+	}
+	return &rls
+}
+
+func isMigratingCopy(rls *release.Release) bool {
+	return rls.Labels[MigratingLabel] != ""
+}
+
+// HideMigratingCopies returns a driver that stores releases in d, but whose
+// List and Query do not return the temporary copies that Migrate creates
+// while it moves releases, so that they are never taken for revisions of a
+// release, even if Migrate was interrupted.
+func HideMigratingCopies(d driver.Driver) driver.Driver {
+	return &hideMigratingCopies{Driver: d}
+}
+
+type hideMigratingCopies struct {
+	driver.Driver
+}
+
+func (d *hideMigratingCopies) List(filter func(*release.Release) bool) ([]*release.Release, error) {
+	return d.Driver.List(func(rls *release.Release) bool {
+		return !isMigratingCopy(rls) && filter(rls)
+	})
+}
+
+func (d *hideMigratingCopies) Query(labels map[string]string) ([]*release.Release, error) {
+	releases, err := d.Driver.Query(labels)
+	if err != nil {
+		return nil, err
+	}
+	releases = slices.DeleteFunc(releases, isMigratingCopy)
+	if len(releases) == 0 {
+		return nil, driver.ErrReleaseNotFound
+	}
+	return releases, nil
+}
+
+// getRelease gets the release key from d. Helm's secrets and config maps
+// drivers panic if the object named key does not contain a release of theirs,
+// which is reported as an error instead.
+func getRelease(d driver.Driver, key string) (rls *release.Release, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to get release %q from %s: %v", key, d.Name(), r)
+		}
+	}()
+	return d.Get(key)
+}
+
+// releaseStorageKey returns the key that Helm stores a release revision under.
+func releaseStorageKey(name string, version int) string {
+	return fmt.Sprintf("%s.%s.v%d", storage.HelmStorageType, name, version)
+}
+
+// compareReleases returns an error if the content or the custom labels of the
+// releases differ. Releases are compared by their serialized form, since that
+// is what the drivers store.
+func compareReleases(expected, actual *release.Release) error {
+	expectedData, err := json.Marshal(expected)
+	if err != nil {
+		return err
+	}
+	actualData, err := json.Marshal(actual)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expectedData, actualData) {
+		return errors.New("release content differs")
+	}
+	if !maps.Equal(expected.Labels, actual.Labels) {
+		return fmt.Errorf("release labels differ: expected %v, got %v", expected.Labels, actual.Labels)
+	}
+	return nil
+}
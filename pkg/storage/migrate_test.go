@@ -0,0 +1,258 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+var _ = Describe("Migrate", func() {
+	var (
+		from, to *driver.Memory
+		history  []*release.Release
+	)
+
+	newMemory := func(releases ...*release.Release) *driver.Memory {
+		GinkgoHelper()
+		d := driver.NewMemory()
+		d.SetNamespace("default")
+		for _, rls := range releases {
+			Expect(d.Create(releaseStorageKey(rls.Name, rls.Version), rls)).To(Succeed())
+		}
+		return d
+	}
+
+	BeforeEach(func() {
+		history = []*release.Release{
+			genRelease("a", 1, release.StatusSuperseded, map[string]string{"team": "x"}, 100),
+			genRelease("a", 2, release.StatusDeployed, map[string]string{"team": "y"}, 100),
+			genRelease("b", 1, release.StatusDeployed, nil, 100),
+		}
+		from = newMemory(history...)
+		to = newMemory()
+	})
+
+	It("should copy the full history with revisions and labels", func() {
+		result, err := Migrate(from, to, MigrateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Migrated).To(Equal([]string{"sh.helm.release.v1.a.v1", "sh.helm.release.v1.a.v2", "sh.helm.release.v1.b.v1"}))
+		Expect(result.Deleted).To(BeEmpty())
+
+		for _, expected := range history {
+			actual, err := to.Get(releaseStorageKey(expected.Name, expected.Version))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual.Version).To(Equal(expected.Version))
+			Expect(actual.Labels).To(Equal(expected.Labels))
+
+			_, err = from.Get(releaseStorageKey(expected.Name, expected.Version))
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("should only copy the selected releases", func() {
+		result, err := Migrate(from, to, MigrateOptions{
+			Filter: func(rls *release.Release) bool { return rls.Name == "b" },
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Migrated).To(Equal([]string{"sh.helm.release.v1.b.v1"}))
+	})
+
+	It("should skip releases that were already copied", func() {
+		_, err := Migrate(from, to, MigrateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := Migrate(from, to, MigrateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Migrated).To(BeEmpty())
+		Expect(result.Skipped).To(HaveLen(3))
+	})
+
+	It("should fail if a release exists in the target with different content", func() {
+		to = newMemory(genRelease("a", 1, release.StatusFailed, nil, 100))
+
+		_, err := Migrate(from, to, MigrateOptions{})
+		Expect(err).To(MatchError(ContainSubstring(`release "sh.helm.release.v1.a.v1" already exists`)))
+	})
+
+	It("should delete the source releases", func() {
+		result, err := Migrate(from, to, MigrateOptions{DeleteSource: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Deleted).To(HaveLen(3))
+
+		remaining, err := from.List(func(*release.Release) bool { return true })
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remaining).To(BeEmpty())
+	})
+
+	When("migrating between secrets and chunked secrets", func() {
+		var secretInterface clientcorev1.SecretInterface
+
+		BeforeEach(func() {
+			secretInterface = clientcorev1.NewForConfigOrDie(cfg).Secrets("default")
+		})
+
+		AfterEach(func() {
+			Expect(secretInterface.DeleteCollection(context.Background(), metav1.DeleteOptions{}, metav1.ListOptions{})).To(Succeed())
+		})
+
+		It("should move releases to chunked secrets and back", func() {
+			secrets := driver.NewSecrets(secretInterface)
+			chunked := NewChunkedSecrets(secretInterface, "test-owner", ChunkedSecretsConfig{ChunkSize: 1000})
+			large := genRelease("c", 1, release.StatusDeployed, map[string]string{"team": "z"}, 5000)
+			Expect(secrets.Create(releaseStorageKey(large.Name, large.Version), large)).To(Succeed())
+
+			result, err := Migrate(secrets, chunked, MigrateOptions{DeleteSource: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Migrated).To(Equal([]string{"sh.helm.release.v1.c.v1"}))
+			actual, err := chunked.Get("sh.helm.release.v1.c.v1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(large))
+			remaining, err := secrets.List(func(*release.Release) bool { return true })
+			Expect(err).ToNot(HaveOccurred())
+			Expect(remaining).To(BeEmpty())
+
+			result, err = Migrate(chunked, secrets, MigrateOptions{DeleteSource: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Migrated).To(Equal([]string{"sh.helm.release.v1.c.v1"}))
+			actual, err = secrets.Get("sh.helm.release.v1.c.v1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual.Labels).To(Equal(large.Labels))
+			items, err := secretInterface.List(context.Background(), metav1.ListOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(items.Items).To(ConsistOf(HaveField("Type", corev1.SecretType("helm.sh/release.v1"))))
+		})
+
+		When("moving a release", func() {
+			var (
+				source, target driver.Driver
+				rls            *release.Release
+				key            string
+			)
+
+			BeforeEach(func() {
+				// Chunked secrets drivers with different owners store
+				// releases under the same names, but do not read each
+				// other's releases.
+				source = NewChunkedSecrets(secretInterface, "source-owner", ChunkedSecretsConfig{})
+				target = NewChunkedSecrets(secretInterface, "target-owner", ChunkedSecretsConfig{})
+				rls = genRelease("d", 1, release.StatusDeployed, map[string]string{"team": "w"}, 100)
+				key = releaseStorageKey(rls.Name, rls.Version)
+				Expect(source.Create(key, rls)).To(Succeed())
+			})
+
+			It("should remove the temporary copy once the release is moved", func() {
+				Expect(moveRelease(source, target, key, rls)).To(Succeed())
+				actual, err := target.Get(key)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual).To(Equal(rls))
+				_, err = target.Get(migratingKey(key))
+				Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+				_, err = source.Get(key)
+				Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+			})
+
+			It("should restore the release in the source if it cannot be created in the target", func() {
+				failingTarget := &failingCreateDriver{Driver: target, failKey: key}
+				Expect(moveRelease(source, failingTarget, key, rls)).To(MatchError(ContainSubstring("create failed")))
+				actual, err := source.Get(key)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual).To(Equal(rls))
+				_, err = target.Get(migratingKey(key))
+				Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+			})
+
+			It("should keep the temporary copy if the release can neither be moved nor restored", func() {
+				failingSource := &failingCreateDriver{Driver: source, failKey: key}
+				failingTarget := &failingCreateDriver{Driver: target, failKey: key}
+				Expect(moveRelease(failingSource, failingTarget, key, rls)).To(MatchError(ContainSubstring("a copy is kept")))
+				actual, err := target.Get(migratingKey(key))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual.Labels).To(HaveKeyWithValue(MigratingLabel, "true"))
+				Expect(withoutMigratingLabel(actual)).To(Equal(rls))
+			})
+
+			It("should finish a move that was interrupted after deleting the source", func() {
+				Expect(target.Create(migratingKey(key), migratingCopy(rls))).To(Succeed())
+				_, err := source.Delete(key)
+				Expect(err).ToNot(HaveOccurred())
+
+				result, err := Migrate(source, target, MigrateOptions{DeleteSource: true})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Migrated).To(Equal([]string{key}))
+				actual, err := target.Get(key)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual).To(Equal(rls))
+				_, err = target.Get(migratingKey(key))
+				Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+			})
+
+			It("should move the release again if the move was interrupted before deleting the source", func() {
+				Expect(target.Create(migratingKey(key), migratingCopy(rls))).To(Succeed())
+
+				result, err := Migrate(source, target, MigrateOptions{DeleteSource: true})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Migrated).To(Equal([]string{key}))
+				Expect(result.Deleted).To(Equal([]string{key}))
+				actual, err := target.Get(key)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual).To(Equal(rls))
+				_, err = target.Get(migratingKey(key))
+				Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+				_, err = source.Get(key)
+				Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+			})
+
+			It("should hide temporary copies from the history of the release", func() {
+				Expect(target.Create(migratingKey(key), migratingCopy(rls))).To(Succeed())
+				hidden := HideMigratingCopies(target)
+
+				_, err := hidden.Query(map[string]string{"name": rls.Name, "owner": "helm"})
+				Expect(err).To(MatchError(driver.ErrReleaseNotFound))
+				releases, err := hidden.List(func(*release.Release) bool { return true })
+				Expect(err).ToNot(HaveOccurred())
+				Expect(releases).To(BeEmpty())
+
+				releases, err = target.List(func(*release.Release) bool { return true })
+				Expect(err).ToNot(HaveOccurred())
+				Expect(releases).To(ConsistOf(HaveField("Labels", HaveKeyWithValue(MigratingLabel, "true"))))
+			})
+		})
+	})
+})
+
+// failingCreateDriver is a driver that fails to create the release failKey.
+type failingCreateDriver struct {
+	driver.Driver
+	failKey string
+}
+
+func (d *failingCreateDriver) Create(key string, rls *release.Release) error {
+	if key == d.failKey {
+		return errors.New("create failed")
+	}
+	return d.Driver.Create(key, rls)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// DefaultRepairMinOrphanAge is a reasonable RepairOptions.MinOrphanAge for
+// repairs that may run concurrently with writes, e.g. when the operator
+// starts while another replica still holds the leader lease.
+const DefaultRepairMinOrphanAge = 5 * time.Minute
+
+// RepairOptions configures RepairChunkedSecrets.
+type RepairOptions struct {
+	// Owner is the owner of the chunked secrets to repair.
+	Owner string
+
+	// MinOrphanAge is the minimum age of chunk secrets that are not referenced
+	// by an index before they are deleted. Chunks are written before the
+	// index that references them, so young orphans may belong to a write
+	// that is still in progress.
+	MinOrphanAge time.Duration
+
+	Log func(string, ...interface{})
+}
+
+// RepairResult describes the changes made by RepairChunkedSecrets. Secrets are
+// identified as "<namespace>/<name>".
+type RepairResult struct {
+	// DeletedChunks are chunk secrets that were not referenced by an index.
+	DeletedChunks []types.NamespacedName
+
+	// AdoptedChunks are chunk secrets that were referenced by an index, but
+	// not owned by it.
+	AdoptedChunks []types.NamespacedName
+
+	// DeletedIndexes are index secrets that referenced chunks that do not
+	// exist. Their releases cannot be decoded, and they would make listing
+	// the release history fail, so they are deleted.
+	DeletedIndexes []types.NamespacedName
+}
+
+// RepairChunkedSecrets finds and fixes inconsistencies of releases stored by
+// the chunked secrets driver, such as those left behind by writes that were
+// interrupted by a crash, or by writes of earlier versions of the driver. If
+// namespace is empty, secrets in all namespaces are repaired.
+//
+// Repairing is best effort: it continues after errors and returns all of
+// them along with the changes that were made.
+func RepairChunkedSecrets(ctx context.Context, client clientcorev1.SecretsGetter, namespace string, opts RepairOptions) (*RepairResult, error) {
+	if opts.Log == nil {
+		opts.Log = func(string, ...interface{}) {}
+	}
+
+	secrets, err := client.Secrets(namespace).List(ctx, metav1.ListOptions{LabelSelector: newListAllSelector(opts.Owner).String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunked secrets: %w", err)
+	}
+
+	type namespacedSecrets struct {
+		indexes []corev1.Secret
+		chunks  map[string]corev1.Secret
+	}
+	byNamespace := map[string]*namespacedSecrets{}
+	for _, s := range secrets.Items {
+		ns, ok := byNamespace[s.Namespace]
+		if !ok {
+			ns = &namespacedSecrets{chunks: map[string]corev1.Secret{}}
+			byNamespace[s.Namespace] = ns
+		}
+		switch s.Type {
+		case SecretTypeChunkedIndex:
+			ns.indexes = append(ns.indexes, s)
+		case SecretTypeChunkedChunk:
+			ns.chunks[s.Name] = s
+		}
+	}
+
+	result := &RepairResult{}
+	var errs []error
+	for _, namespace := range sets.KeySet(byNamespace).UnsortedList() {
+		ns := byNamespace[namespace]
+		secretClient := client.Secrets(namespace)
+		referenced := sets.New[string]()
+
+		for i := range ns.indexes {
+			indexSecret := &ns.indexes[i]
+			indexName := types.NamespacedName{Namespace: namespace, Name: indexSecret.Name}
+			chunkNames, err := indexChunkNames(indexSecret)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("index %q: %w", indexName, err))
+				continue
+			}
+			referenced.Insert(chunkNames...)
+
+			var missing []string
+			for _, name := range chunkNames {
+				if _, ok := ns.chunks[name]; !ok {
+					missing = append(missing, name)
+				}
+			}
+			if len(missing) > 0 {
+				opts.Log("repair: index %q references missing chunks %v, deleting it", indexName, missing)
+				if err := secretClient.Delete(ctx, indexSecret.Name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{
+					UID:             &indexSecret.UID,
+					ResourceVersion: &indexSecret.ResourceVersion,
+				}}); err != nil && !apierrors.IsNotFound(err) {
+					errs = append(errs, fmt.Errorf("failed to delete index %q: %w", indexName, err))
+					continue
+				}
+				result.DeletedIndexes = append(result.DeletedIndexes, indexName)
+				continue
+			}
+
+			for _, name := range chunkNames {
+				if isOwnedBy(ns.chunks[name], indexSecret.UID) {
+					continue
+				}
+				chunkName := types.NamespacedName{Namespace: namespace, Name: name}
+				opts.Log("repair: setting owner of chunk %q to index %q", chunkName, indexName)
+				if err := adoptChunk(ctx, secretClient, indexSecret, name); err != nil {
+					errs = append(errs, fmt.Errorf("failed to set owner of chunk %q: %w", chunkName, err))
+					continue
+				}
+				result.AdoptedChunks = append(result.AdoptedChunks, chunkName)
+			}
+		}
+
+		for name, chunkSecret := range ns.chunks {
+			if referenced.Has(name) || time.Since(chunkSecret.CreationTimestamp.Time) < opts.MinOrphanAge {
+				continue
+			}
+			chunkName := types.NamespacedName{Namespace: namespace, Name: name}
+			opts.Log("repair: deleting orphaned chunk %q", chunkName)
+			if err := secretClient.Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &chunkSecret.UID}}); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete chunk %q: %w", chunkName, err))
+				continue
+			}
+			result.DeletedChunks = append(result.DeletedChunks, chunkName)
+		}
+	}
+
+	for _, names := range [][]types.NamespacedName{result.DeletedChunks, result.AdoptedChunks, result.DeletedIndexes} {
+		slices.SortFunc(names, func(a, b types.NamespacedName) int {
+			return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+		})
+	}
+	return result, utilerrors.NewAggregate(errs)
+}
+
+func isOwnedBy(s corev1.Secret, uid types.UID) bool {
+	for _, ref := range s.OwnerReferences {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
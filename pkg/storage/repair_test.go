@@ -0,0 +1,169 @@
+/*
+Copyright 2025 The Operator-SDK Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("RepairChunkedSecrets", func() {
+	const (
+		chunkSize = 1000
+		owner     = "test-owner"
+	)
+	var (
+		ctx             = context.Background()
+		coreClient      clientcorev1.CoreV1Interface
+		secretInterface clientcorev1.SecretInterface
+		chunkedDriver   driver.Driver
+		opts            RepairOptions
+	)
+
+	BeforeEach(func() {
+		coreClient = clientcorev1.NewForConfigOrDie(cfg)
+		secretInterface = coreClient.Secrets("default")
+		chunkedDriver = NewChunkedSecrets(secretInterface, owner, ChunkedSecretsConfig{ChunkSize: chunkSize})
+		opts = RepairOptions{Owner: owner}
+	})
+
+	AfterEach(func() {
+		Expect(secretInterface.DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{})).To(Succeed())
+	})
+
+	createChunk := func(key, name string, owners ...metav1.OwnerReference) {
+		GinkgoHelper()
+		_, err := secretInterface.Create(ctx, &corev1.Secret{
+			Type: SecretTypeChunkedChunk,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Labels:          newChunkLabels(owner, key),
+				OwnerReferences: owners,
+			},
+			Immutable: ptr.To(true),
+			Data:      map[string][]byte{"chunk": []byte("data")},
+		}, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	chunkNames := func(key string) []string {
+		GinkgoHelper()
+		index, err := secretInterface.Get(ctx, key, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		Expect(json.Unmarshal(index.Data["extraChunks"], &names)).To(Succeed())
+		return names
+	}
+
+	It("should not change consistent releases", func() {
+		rel := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize*3)
+		Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+
+		result, err := RepairChunkedSecrets(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*result).To(Equal(RepairResult{}))
+		verifySecrets(secretInterface, 3)
+	})
+
+	It("should delete chunks that are not referenced by an index", func() {
+		rel := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize*2)
+		Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+		createChunk(releaseKey(rel), "test-release.v1-stale")
+		createChunk("test-release.v2", "test-release.v2-orphan")
+
+		result, err := RepairChunkedSecrets(ctx, coreClient, "", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.DeletedChunks).To(Equal([]types.NamespacedName{
+			{Namespace: "default", Name: "test-release.v1-stale"},
+			{Namespace: "default", Name: "test-release.v2-orphan"},
+		}))
+		verifySecrets(secretInterface, 2)
+
+		actual, err := chunkedDriver.Get(releaseKey(rel))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(Equal(rel))
+	})
+
+	It("should keep recent orphaned chunks", func() {
+		createChunk("test-release.v1", "test-release.v1-pending")
+
+		opts.MinOrphanAge = time.Hour
+		result, err := RepairChunkedSecrets(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.DeletedChunks).To(BeEmpty())
+
+		_, err = secretInterface.Get(ctx, "test-release.v1-pending", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should make the index the owner of referenced chunks", func() {
+		rel := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize*2)
+		Expect(chunkedDriver.Create(releaseKey(rel), rel)).To(Succeed())
+		names := chunkNames(releaseKey(rel))
+		Expect(names).To(HaveLen(1))
+		_, err := secretInterface.Patch(ctx, names[0], types.MergePatchType, []byte(`{"metadata":{"ownerReferences":null}}`), metav1.PatchOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := RepairChunkedSecrets(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.AdoptedChunks).To(Equal([]types.NamespacedName{{Namespace: "default", Name: names[0]}}))
+		verifyChunkOwners(secretInterface, releaseKey(rel))
+	})
+
+	It("should delete indexes that reference missing chunks", func() {
+		broken := genRelease("test-release", 1, release.StatusSuperseded, nil, chunkSize*2)
+		Expect(chunkedDriver.Create(releaseKey(broken), broken)).To(Succeed())
+		intact := genRelease("test-release", 2, release.StatusDeployed, nil, chunkSize/2)
+		Expect(chunkedDriver.Create(releaseKey(intact), intact)).To(Succeed())
+		for _, name := range chunkNames(releaseKey(broken)) {
+			Expect(secretInterface.Delete(ctx, name, metav1.DeleteOptions{})).To(Succeed())
+		}
+		_, err := chunkedDriver.List(func(*release.Release) bool { return true })
+		Expect(err).To(HaveOccurred())
+
+		result, err := RepairChunkedSecrets(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.DeletedIndexes).To(Equal([]types.NamespacedName{{Namespace: "default", Name: releaseKey(broken)}}))
+
+		releases, err := chunkedDriver.List(func(*release.Release) bool { return true })
+		Expect(err).ToNot(HaveOccurred())
+		Expect(releases).To(ConsistOf(HaveField("Version", 2)))
+	})
+
+	It("should ignore secrets of other owners", func() {
+		rel := genRelease("test-release", 1, release.StatusDeployed, nil, chunkSize/2)
+		otherDriver := NewChunkedSecrets(secretInterface, "other-owner", ChunkedSecretsConfig{ChunkSize: chunkSize})
+		Expect(otherDriver.Create(releaseKey(rel), rel)).To(Succeed())
+
+		result, err := RepairChunkedSecrets(ctx, coreClient, "default", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*result).To(Equal(RepairResult{}))
+		verifySecrets(secretInterface, 1)
+	})
+})
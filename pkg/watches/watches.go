@@ -16,10 +16,15 @@ package watches
 
 import (
 	"bytes"
+	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
 	"text/template"
 
 	sprig "github.com/go-task/slim-sprig/v3"
@@ -27,9 +32,48 @@ import (
 	"helm.sh/helm/v3/pkg/chart/loader"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
+	yamlv3 "sigs.k8s.io/yaml/goyaml.v3"
+
+	"github.com/operator-framework/helm-operator-plugins/pkg/annotation"
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
+)
+
+var log = logf.Log.WithName("watches")
+
+const (
+	// APIVersion is the apiVersion of the versioned watches file format.
+	APIVersion = "helm.sdk.operatorframework.io/v2"
+	// Kind is the kind of the versioned watches file format.
+	Kind = "Watches"
 )
 
+// JSONSchema is the JSON schema of the versioned watches file format.
+//
+//go:embed watches.schema.json
+var JSONSchema []byte
+
+// File is the versioned watches file format:
+//
+//	apiVersion: helm.sdk.operatorframework.io/v2
+//	kind: Watches
+//	watches:
+//	- group: example.com
+//	  version: v1
+//	  kind: Example
+//	  chart: helm-charts/example
+//
+// Unlike the legacy format, which is a plain list of watches, a versioned
+// watches file is decoded strictly and unknown fields are reported as errors.
+type File struct {
+	APIVersion string  `json:"apiVersion"`
+	Kind       string  `json:"kind"`
+	Watches    []Watch `json:"watches"`
+}
+
 type Watch struct {
 	schema.GroupVersionKind `json:",inline"`
 	ChartPath               string `json:"chart"`
@@ -39,7 +83,144 @@ type Watch struct {
 	ReconcilePeriod         *metav1.Duration      `json:"reconcilePeriod,omitempty"`
 	MaxConcurrentReconciles *int                  `json:"maxConcurrentReconciles,omitempty"`
 	Selector                *metav1.LabelSelector `json:"selector,omitempty"`
-	Chart                   *chart.Chart          `json:"-"`
+	MaxReleaseHistory       *int                  `json:"maxReleaseHistory,omitempty"`
+	WaitForDeletionTimeout  *metav1.Duration      `json:"waitForDeletionTimeout,omitempty"`
+	EnableFailureRollbacks  *bool                 `json:"enableFailureRollbacks,omitempty"`
+	StorageDriver           string                `json:"storageDriver,omitempty"`
+
+	// PendingReleaseTimeout and PendingReleaseRecovery configure the recovery
+	// of releases that are stuck in a pending state, see
+	// reconciler.WithPendingReleaseRecovery. A timeout of 0 disables it.
+	PendingReleaseTimeout  *metav1.Duration `json:"pendingReleaseTimeout,omitempty"`
+	PendingReleaseRecovery string           `json:"pendingReleaseRecovery,omitempty"`
+
+	// ReleaseRetry configures how failed installs and upgrades are retried,
+	// see reconciler.WithRetryPolicy. If it is not set, they are retried
+	// indefinitely with the default backoff of the controller.
+	ReleaseRetry *RetryPolicy `json:"releaseRetry,omitempty"`
+
+	// RequireUpgradeApproval configures whether upgrades must be approved with
+	// the annotation.ApprovedRevisionHash annotation, see
+	// reconciler.WithUpgradeApprovalRequired.
+	RequireUpgradeApproval *bool `json:"requireUpgradeApproval,omitempty"`
+
+	// MaintenanceWindows restricts upgrades to recurring maintenance windows,
+	// see reconciler.WithMaintenanceWindows.
+	MaintenanceWindows *MaintenanceWindowPolicy `json:"maintenanceWindows,omitempty"`
+
+	// Rollout rolls out chart changes across custom resources in waves, see
+	// reconciler.WithRollout.
+	Rollout *RolloutPolicy `json:"rollout,omitempty"`
+
+	// ReleaseTests runs the tests of the chart after installs and upgrades or
+	// periodically, see reconciler.WithReleaseTests.
+	ReleaseTests *TestPolicy `json:"releaseTests,omitempty"`
+
+	// Policy evaluates the built-in policy rules for the rendered manifests
+	// of installs and upgrades, see reconciler.WithPolicy.
+	Policy *PolicyRules `json:"policy,omitempty"`
+
+	// ImageRewrite rewrites the images of the rendered manifests to registry
+	// mirrors and pins them to digests, see postrenderer.ImageRewriter.
+	ImageRewrite *ImageRewrite `json:"imageRewrite,omitempty"`
+
+	// CommonMetadata adds templated labels and annotations to all objects of
+	// the releases, see reconciler.WithCommonMetadata.
+	CommonMetadata *CommonMetadata `json:"commonMetadata,omitempty"`
+
+	// EnablePostRendererPatches applies the patches in the
+	// spec.postRenderers.patches field of custom resources to the rendered
+	// manifests, see postrenderer.Patcher. The spec.postRenderers field is
+	// then not passed to the chart as a value.
+	EnablePostRendererPatches *bool `json:"enablePostRendererPatches,omitempty"`
+
+	// Namespaces and NamespaceSelector restrict the watch to custom resources
+	// and dependent resources in the listed namespaces or in the namespaces
+	// matching the selector. At most one of them may be set. If neither is
+	// set, the namespaces of the manager are watched. Namespaces that start
+	// or stop matching the selector are picked up while the operator runs.
+	Namespaces        []string              `json:"namespaces,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// InstallAnnotations, UpgradeAnnotations and UninstallAnnotations list
+	// the names of the annotations from the annotation package that are
+	// enabled for this watch. If a list is not set, all default annotations
+	// of that kind are enabled. An empty list disables them.
+	InstallAnnotations   []string `json:"installAnnotations,omitempty"`
+	UpgradeAnnotations   []string `json:"upgradeAnnotations,omitempty"`
+	UninstallAnnotations []string `json:"uninstallAnnotations,omitempty"`
+
+	Chart *chart.Chart `json:"-"`
+}
+
+// RetryPolicy is the watches file representation of reconciler.RetryPolicy.
+// Unset fields use the defaults of reconciler.WithRetryPolicy.
+type RetryPolicy struct {
+	MaxAttempts       int              `json:"maxAttempts,omitempty"`
+	InitialBackoff    *metav1.Duration `json:"initialBackoff,omitempty"`
+	BackoffFactor     float64          `json:"backoffFactor,omitempty"`
+	MaxBackoff        *metav1.Duration `json:"maxBackoff,omitempty"`
+	OnExhausted       string           `json:"onExhausted,omitempty"`
+	SlowRetryInterval *metav1.Duration `json:"slowRetryInterval,omitempty"`
+}
+
+// MaintenanceWindowPolicy is the watches file representation of a
+// reconciler.MaintenanceWindowPolicy. Each window has the form
+// "<cron expression> <duration>", see reconciler.ParseMaintenanceWindow.
+type MaintenanceWindowPolicy struct {
+	Windows                 []string `json:"windows"`
+	RestrictInstalls        bool     `json:"restrictInstalls,omitempty"`
+	RestrictDriftCorrection bool     `json:"restrictDriftCorrection,omitempty"`
+}
+
+// RolloutPolicy is the watches file representation of
+// reconciler.RolloutPolicy.
+type RolloutPolicy struct {
+	WaveLabel        string           `json:"waveLabel,omitempty"`
+	WavePercentages  []int            `json:"wavePercentages,omitempty"`
+	FailureThreshold float64          `json:"failureThreshold,omitempty"`
+	ProgressInterval *metav1.Duration `json:"progressInterval,omitempty"`
+}
+
+// TestPolicy is the watches file representation of reconciler.TestPolicy.
+type TestPolicy struct {
+	AfterRelease      bool             `json:"afterRelease,omitempty"`
+	Interval          *metav1.Duration `json:"interval,omitempty"`
+	Timeout           *metav1.Duration `json:"timeout,omitempty"`
+	RollbackOnFailure bool             `json:"rollbackOnFailure,omitempty"`
+	LogLines          int64            `json:"logLines,omitempty"`
+}
+
+// PolicyRules is the watches file representation of policy.Rules.
+type PolicyRules struct {
+	DenyPrivileged        bool     `json:"denyPrivileged,omitempty"`
+	RequireResourceLimits bool     `json:"requireResourceLimits,omitempty"`
+	AllowedRegistries     []string `json:"allowedRegistries,omitempty"`
+	DenyLatestTag         bool     `json:"denyLatestTag,omitempty"`
+	Audit                 bool     `json:"audit,omitempty"`
+}
+
+// ImageRewrite is the watches file representation of a
+// postrenderer.ImageRewriter. DigestsFile is the path of a YAML file that maps
+// image references to digests, see postrenderer.LoadImageDigests.
+type ImageRewrite struct {
+	Mirrors     []ImageMirror `json:"mirrors,omitempty"`
+	DigestsFile string        `json:"digestsFile,omitempty"`
+}
+
+// ImageMirror is the watches file representation of a
+// postrenderer.ImageMirror.
+type ImageMirror struct {
+	Source string `json:"source"`
+	Mirror string `json:"mirror"`
+}
+
+// CommonMetadata is the watches file representation of a
+// postrenderer.CommonMetadata.
+type CommonMetadata struct {
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	PodTemplates bool              `json:"podTemplates,omitempty"`
 }
 
 // Load loads a slice of Watches from the watch file at `path`. For each entry
@@ -61,54 +242,275 @@ func Load(path string) ([]Watch, error) {
 	return w, err
 }
 
+// LoadReader loads a slice of Watches from reader, which may contain either a
+// versioned watches file or a legacy list of watches. All problems found in
+// the watches are returned together as an aggregate error.
+//
+// Versioned watches files are strict: unknown fields are errors. Unknown
+// fields of legacy watches files are only logged as warnings, since they have
+// always been ignored. Use Validate or the versioned format to reject them.
 func LoadReader(reader io.Reader) ([]Watch, error) {
 	b, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
+	watches, errs := load(b, false)
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return watches, nil
+}
 
-	watches := []Watch{}
-	err = yaml.Unmarshal(b, &watches)
+// Validate reports all problems found in the watches file at path, along
+// with the watches that could be decoded. In contrast to Load, unknown fields
+// are also reported for legacy watches files.
+func Validate(path string) ([]Watch, []error) {
+	b, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, []error{fmt.Errorf("could not read watches file: %w", err)}
 	}
+	return load(b, true)
+}
 
-	watchesMap := make(map[schema.GroupVersionKind]struct{})
-	for i, w := range watches {
-		gvk := w.GroupVersionKind
+func load(b []byte, strict bool) ([]Watch, []error) {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(b, &root); err != nil {
+		return nil, []error{err}
+	}
+	if len(root.Content) == 0 {
+		return []Watch{}, nil
+	}
 
-		if err := verifyGVK(gvk); err != nil {
-			return nil, fmt.Errorf("invalid GVK: %s: %w", gvk, err)
+	var (
+		doc   = root.Content[0]
+		items *yamlv3.Node
+		errs  []error
+	)
+	switch doc.Kind {
+	case yamlv3.SequenceNode:
+		items = doc
+		unknown := unknownFields(doc, reflect.TypeOf([]Watch{}), "watches")
+		if strict {
+			errs = append(errs, unknown...)
+		} else {
+			// Legacy watches files were always decoded leniently, so unknown
+			// fields only produce warnings to keep existing files working.
+			for _, err := range unknown {
+				log.Info("Warning: ignoring unknown field in legacy watches file", "problem", err.Error())
+			}
+		}
+	case yamlv3.MappingNode:
+		errs = append(errs, verifyHeader(doc)...)
+		errs = append(errs, unknownFields(doc, reflect.TypeOf(File{}), "")...)
+		items = mappingValue(doc, "watches")
+		if items == nil {
+			return nil, append(errs, fmt.Errorf("line %d: watches must be set", doc.Line))
+		}
+		if items.Kind != yamlv3.SequenceNode {
+			return nil, append(errs, fmt.Errorf("line %d: watches must be a list", items.Line))
 		}
+	case yamlv3.ScalarNode:
+		if doc.ShortTag() == "!!null" {
+			return []Watch{}, nil
+		}
+		fallthrough
+	default:
+		return nil, []error{fmt.Errorf("line %d: watches file must be a list of watches or a %s document", doc.Line, Kind)}
+	}
 
-		cl, err := loader.Load(w.ChartPath)
+	watches := make([]Watch, 0, len(items.Content))
+	watchesMap := make(map[schema.GroupVersionKind]struct{})
+	for i, item := range items.Content {
+		w, err := decodeWatch(item)
 		if err != nil {
-			return nil, fmt.Errorf("invalid chart %s: %w", w.ChartPath, err)
+			errs = append(errs, fmt.Errorf("watches[%d] (line %d): %w", i, item.Line, err))
+			continue
+		}
+		gvk := w.GroupVersionKind
+		for _, err := range verifyWatch(&w) {
+			errs = append(errs, fmt.Errorf("watches[%d] (line %d): %w", i, item.Line, err))
 		}
-		w.Chart = cl
 
 		if _, ok := watchesMap[gvk]; ok {
-			return nil, fmt.Errorf("duplicate GVK: %s", gvk)
+			errs = append(errs, fmt.Errorf("watches[%d] (line %d): duplicate GVK: %s", i, item.Line, gvk))
 		}
 		watchesMap[gvk] = struct{}{}
 
-		if w.WatchDependentResources == nil {
-			trueVal := true
-			w.WatchDependentResources = &trueVal
+		watches = append(watches, w)
+	}
+	return watches, errs
+}
+
+func decodeWatch(node *yamlv3.Node) (Watch, error) {
+	// Re-encode the node and decode it with the same YAML-to-JSON semantics
+	// that were used for the legacy format, so that both formats accept the
+	// same values.
+	b, err := yamlv3.Marshal(node)
+	if err != nil {
+		return Watch{}, err
+	}
+	var w Watch
+	if err := yaml.Unmarshal(b, &w); err != nil {
+		return Watch{}, err
+	}
+	return w, nil
+}
+
+// verifyWatch verifies w and sets its defaults. It returns all problems found.
+func verifyWatch(w *Watch) []error {
+	var errs []error
+	gvk := w.GroupVersionKind
+
+	if err := verifyGVK(gvk); err != nil {
+		errs = append(errs, fmt.Errorf("invalid GVK: %s: %w", gvk, err))
+	}
+
+	for _, err := range verifyOptions(*w) {
+		errs = append(errs, fmt.Errorf("invalid options for %s: %w", gvk, err))
+	}
+
+	cl, err := loader.Load(w.ChartPath)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid chart %s: %w", w.ChartPath, err))
+	}
+	w.Chart = cl
+
+	if w.WatchDependentResources == nil {
+		trueVal := true
+		w.WatchDependentResources = &trueVal
+	}
+
+	if w.Selector == nil {
+		w.Selector = &metav1.LabelSelector{}
+	}
+
+	w.OverrideValues, err = expandOverrideValues(w.OverrideValues)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to expand override values: %w", err))
+	}
+	return errs
+}
+
+func verifyHeader(doc *yamlv3.Node) []error {
+	var errs []error
+	if v := mappingValue(doc, "apiVersion"); v == nil {
+		errs = append(errs, fmt.Errorf("line %d: apiVersion must be set", doc.Line))
+	} else if v.Value != APIVersion {
+		errs = append(errs, fmt.Errorf("line %d: unsupported apiVersion %q, expected %q", v.Line, v.Value, APIVersion))
+	}
+	if v := mappingValue(doc, "kind"); v == nil {
+		errs = append(errs, fmt.Errorf("line %d: kind must be set", doc.Line))
+	} else if v.Value != Kind {
+		errs = append(errs, fmt.Errorf("line %d: unsupported kind %q, expected %q", v.Line, v.Value, Kind))
+	}
+	return errs
+}
+
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
 		}
+	}
+	return nil
+}
 
-		if w.Selector == nil {
-			w.Selector = &metav1.LabelSelector{}
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownFields returns an error for every mapping key in node that does not
+// correspond to a JSON field of t. Field names are matched case-insensitively,
+// like encoding/json does. Mismatches between the kinds of node and t are not
+// reported, since decoding reports them.
+func unknownFields(node *yamlv3.Node, t reflect.Type, path string) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			return nil
+		}
+		fields := jsonFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if k.ShortTag() == "!!merge" {
+				continue
+			}
+			ft, ok := lookupField(fields, k.Value)
+			if !ok {
+				where := ""
+				if path != "" {
+					where = " in " + path
+				}
+				errs = append(errs, fmt.Errorf("line %d: unknown field %q%s", k.Line, k.Value, where))
+				continue
+			}
+			errs = append(errs, unknownFields(v, ft, joinPath(path, k.Value))...)
+		}
+	case reflect.Slice:
+		if node.Kind != yamlv3.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			errs = append(errs, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			return nil
 		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, unknownFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+	}
+	return errs
+}
 
-		w.OverrideValues, err = expandOverrideValues(w.OverrideValues)
-		if err != nil {
-			return nil, fmt.Errorf("failed to expand override values")
+// jsonFields returns the JSON field names of struct type t mapped to their
+// types, including the fields of embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for n, ft := range jsonFields(f.Type) {
+				fields[n] = ft
+			}
+			continue
 		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
 
-		watches[i] = w
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if t, ok := fields[key]; ok {
+		return t, true
 	}
-	return watches, nil
+	for name, t := range fields {
+		if strings.EqualFold(name, key) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
 }
 
 func expandOverrideValues(in map[string]string) (map[string]string, error) {
@@ -132,6 +534,159 @@ func expandOverrideValues(in map[string]string) (map[string]string, error) {
 	return out, nil
 }
 
+func verifyRetryPolicy(p *RetryPolicy) error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxAttempts < 0 {
+		return errors.New("maxAttempts must not be negative")
+	}
+	if p.BackoffFactor != 0 && p.BackoffFactor < 1 {
+		return errors.New("backoffFactor must be at least 1")
+	}
+	for name, d := range map[string]*metav1.Duration{"initialBackoff": p.InitialBackoff, "maxBackoff": p.MaxBackoff, "slowRetryInterval": p.SlowRetryInterval} {
+		if d != nil && d.Duration < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	switch p.OnExhausted {
+	case "", "Stall", "SlowRetry":
+	default:
+		return fmt.Errorf("unknown onExhausted %q, must be Stall or SlowRetry", p.OnExhausted)
+	}
+	return nil
+}
+
+func verifyRolloutPolicy(p *RolloutPolicy) error {
+	if p == nil {
+		return nil
+	}
+	if p.WaveLabel != "" && len(p.WavePercentages) > 0 {
+		return errors.New("waveLabel and wavePercentages are mutually exclusive")
+	}
+	if p.WaveLabel == "" && len(p.WavePercentages) == 0 {
+		return errors.New("either waveLabel or wavePercentages must be set")
+	}
+	prev := 0
+	for _, pct := range p.WavePercentages {
+		if pct <= prev || pct > 100 {
+			return errors.New("wavePercentages must be increasing and between 1 and 100")
+		}
+		prev = pct
+	}
+	if p.FailureThreshold < 0 || p.FailureThreshold > 1 {
+		return errors.New("failureThreshold must be between 0 and 1")
+	}
+	if p.ProgressInterval != nil && p.ProgressInterval.Duration < 0 {
+		return errors.New("progressInterval must not be negative")
+	}
+	return nil
+}
+
+func verifyTestPolicy(p *TestPolicy) error {
+	if p == nil {
+		return nil
+	}
+	if !p.AfterRelease && (p.Interval == nil || p.Interval.Duration == 0) {
+		return errors.New("either afterRelease or interval must be set")
+	}
+	for name, d := range map[string]*metav1.Duration{"interval": p.Interval, "timeout": p.Timeout} {
+		if d != nil && d.Duration < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if p.LogLines < 0 {
+		return errors.New("logLines must not be negative")
+	}
+	return nil
+}
+
+func verifyPolicyRules(p *PolicyRules) error {
+	if p == nil {
+		return nil
+	}
+	if !p.DenyPrivileged && !p.RequireResourceLimits && len(p.AllowedRegistries) == 0 && !p.DenyLatestTag {
+		return errors.New("at least one rule must be enabled")
+	}
+	for _, registry := range p.AllowedRegistries {
+		if strings.TrimSuffix(registry, "/") == "" {
+			return errors.New("allowedRegistries must not contain empty registries")
+		}
+	}
+	return nil
+}
+
+func verifyOptions(w Watch) []error {
+	var errs []error
+	if w.MaxConcurrentReconciles != nil && *w.MaxConcurrentReconciles < 1 {
+		errs = append(errs, errors.New("maxConcurrentReconciles must be at least 1"))
+	}
+	if w.ReconcilePeriod != nil && w.ReconcilePeriod.Duration < 0 {
+		errs = append(errs, errors.New("reconcilePeriod must not be negative"))
+	}
+	if w.MaxReleaseHistory != nil && *w.MaxReleaseHistory < 0 {
+		errs = append(errs, errors.New("maxReleaseHistory must not be negative"))
+	}
+	if w.WaitForDeletionTimeout != nil && w.WaitForDeletionTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("waitForDeletionTimeout must be positive"))
+	}
+	if w.PendingReleaseTimeout != nil && w.PendingReleaseTimeout.Duration < 0 {
+		errs = append(errs, errors.New("pendingReleaseTimeout must not be negative"))
+	}
+	switch w.PendingReleaseRecovery {
+	case "", "Rollback", "MarkFailed":
+	default:
+		errs = append(errs, fmt.Errorf("unknown pendingReleaseRecovery %q, must be Rollback or MarkFailed", w.PendingReleaseRecovery))
+	}
+	if err := verifyRetryPolicy(w.ReleaseRetry); err != nil {
+		errs = append(errs, fmt.Errorf("invalid releaseRetry: %w", err))
+	}
+	if w.MaintenanceWindows != nil && len(w.MaintenanceWindows.Windows) == 0 {
+		errs = append(errs, errors.New("maintenanceWindows must list at least one window"))
+	}
+	if err := verifyRolloutPolicy(w.Rollout); err != nil {
+		errs = append(errs, fmt.Errorf("invalid rollout: %w", err))
+	}
+	if err := verifyTestPolicy(w.ReleaseTests); err != nil {
+		errs = append(errs, fmt.Errorf("invalid releaseTests: %w", err))
+	}
+	if err := verifyPolicyRules(w.Policy); err != nil {
+		errs = append(errs, fmt.Errorf("invalid policy: %w", err))
+	}
+	if w.ImageRewrite != nil && len(w.ImageRewrite.Mirrors) == 0 && w.ImageRewrite.DigestsFile == "" {
+		errs = append(errs, errors.New("imageRewrite must set mirrors or digestsFile"))
+	}
+	if w.CommonMetadata != nil && len(w.CommonMetadata.Labels) == 0 && len(w.CommonMetadata.Annotations) == 0 {
+		errs = append(errs, errors.New("commonMetadata must set labels or annotations"))
+	}
+	if w.StorageDriver != "" && !slices.Contains(helmclient.StorageDrivers, w.StorageDriver) {
+		errs = append(errs, fmt.Errorf("unknown storageDriver %q", w.StorageDriver))
+	}
+	for _, ns := range w.Namespaces {
+		if msgs := validation.IsDNS1123Label(ns); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid namespace %q: %s", ns, strings.Join(msgs, ", ")))
+		}
+	}
+	if w.NamespaceSelector != nil {
+		if len(w.Namespaces) > 0 {
+			errs = append(errs, errors.New("namespaces and namespaceSelector are mutually exclusive"))
+		}
+		if _, err := metav1.LabelSelectorAsSelector(w.NamespaceSelector); err != nil {
+			errs = append(errs, fmt.Errorf("invalid namespaceSelector: %w", err))
+		}
+	}
+	if _, err := annotation.InstallByName(w.InstallAnnotations...); err != nil {
+		errs = append(errs, fmt.Errorf("invalid installAnnotations: %w", err))
+	}
+	if _, err := annotation.UpgradeByName(w.UpgradeAnnotations...); err != nil {
+		errs = append(errs, fmt.Errorf("invalid upgradeAnnotations: %w", err))
+	}
+	if _, err := annotation.UninstallByName(w.UninstallAnnotations...); err != nil {
+		errs = append(errs, fmt.Errorf("invalid uninstallAnnotations: %w", err))
+	}
+	return errs
+}
+
 func verifyGVK(gvk schema.GroupVersionKind) error {
 	// A GVK without a group is valid. Certain scenarios may cause a GVK
 	// without a group to fail in other ways later in the initialization
@@ -16,12 +16,17 @@ package watches
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
+	"reflect"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	helmclient "github.com/operator-framework/helm-operator-plugins/pkg/client"
 )
 
 var _ = Describe("LoadReader", func() {
@@ -127,6 +132,161 @@ var _ = Describe("LoadReader", func() {
 		verifyEqualWatches(expectedWatches, watches)
 	})
 
+	It("should create valid watches with reconciler options", func() {
+		maxReleaseHistory := 5
+		data = `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+  maxReleaseHistory: 5
+  waitForDeletionTimeout: 10s
+  enableFailureRollbacks: false
+  storageDriver: chunked-secrets
+  pendingReleaseTimeout: 15m
+  pendingReleaseRecovery: MarkFailed
+  releaseRetry:
+    maxAttempts: 3
+    initialBackoff: 10s
+    onExhausted: SlowRetry
+  requireUpgradeApproval: true
+  maintenanceWindows:
+    windows: ["0 2 * * 6 4h"]
+    restrictInstalls: true
+  rollout:
+    waveLabel: rollout-wave
+    failureThreshold: 0.1
+  releaseTests:
+    afterRelease: true
+    interval: 1h
+    rollbackOnFailure: true
+  policy:
+    denyPrivileged: true
+    allowedRegistries: [registry.example.com]
+  imageRewrite:
+    mirrors:
+    - source: docker.io
+      mirror: mirror.example.com/docker.io
+  commonMetadata:
+    labels:
+      team: "{{ .CR.metadata.labels.team }}"
+    podTemplates: true
+  enablePostRendererPatches: true
+  installAnnotations:
+  - helm.sdk.operatorframework.io/install-disable-hooks
+  upgradeAnnotations: []
+  namespaces: [tenant-a, tenant-b]
+`
+		expectedWatches = []Watch{
+			{
+				GroupVersionKind:        schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"},
+				ChartPath:               "../../pkg/internal/testdata/test-chart",
+				WatchDependentResources: &trueVal,
+				MaxReleaseHistory:       &maxReleaseHistory,
+				WaitForDeletionTimeout:  &metav1.Duration{Duration: 10 * time.Second},
+				EnableFailureRollbacks:  &falseVal,
+				StorageDriver:           "chunked-secrets",
+				PendingReleaseTimeout:   &metav1.Duration{Duration: 15 * time.Minute},
+				PendingReleaseRecovery:  "MarkFailed",
+				ReleaseRetry: &RetryPolicy{
+					MaxAttempts:    3,
+					InitialBackoff: &metav1.Duration{Duration: 10 * time.Second},
+					OnExhausted:    "SlowRetry",
+				},
+				RequireUpgradeApproval: &trueVal,
+				MaintenanceWindows: &MaintenanceWindowPolicy{
+					Windows:          []string{"0 2 * * 6 4h"},
+					RestrictInstalls: true,
+				},
+				Rollout: &RolloutPolicy{
+					WaveLabel:        "rollout-wave",
+					FailureThreshold: 0.1,
+				},
+				ReleaseTests: &TestPolicy{
+					AfterRelease:      true,
+					Interval:          &metav1.Duration{Duration: time.Hour},
+					RollbackOnFailure: true,
+				},
+				Policy: &PolicyRules{
+					DenyPrivileged:    true,
+					AllowedRegistries: []string{"registry.example.com"},
+				},
+				ImageRewrite: &ImageRewrite{
+					Mirrors: []ImageMirror{{Source: "docker.io", Mirror: "mirror.example.com/docker.io"}},
+				},
+				CommonMetadata: &CommonMetadata{
+					Labels:       map[string]string{"team": "{{ .CR.metadata.labels.team }}"},
+					PodTemplates: true,
+				},
+				EnablePostRendererPatches: &trueVal,
+				InstallAnnotations:        []string{"helm.sdk.operatorframework.io/install-disable-hooks"},
+				UpgradeAnnotations:        []string{},
+				Namespaces:                []string{"tenant-a", "tenant-b"},
+			},
+		}
+
+		watchesData := bytes.NewBufferString(data)
+		watches, err := LoadReader(watchesData)
+		Expect(err).NotTo(HaveOccurred())
+		verifyEqualWatches(expectedWatches, watches)
+	})
+
+	DescribeTable("should error for invalid reconciler options",
+		func(option, errMsg string) {
+			data = `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+  ` + option + `
+`
+			watchesData := bytes.NewBufferString(data)
+			watches, err := LoadReader(watchesData)
+			Expect(err).To(MatchError(ContainSubstring(errMsg)))
+			Expect(watches).To(BeNil())
+		},
+		Entry("maxConcurrentReconciles", "maxConcurrentReconciles: 0", "maxConcurrentReconciles must be at least 1"),
+		Entry("reconcilePeriod", "reconcilePeriod: -1s", "reconcilePeriod must not be negative"),
+		Entry("maxReleaseHistory", "maxReleaseHistory: -1", "maxReleaseHistory must not be negative"),
+		Entry("waitForDeletionTimeout", "waitForDeletionTimeout: 0s", "waitForDeletionTimeout must be positive"),
+		Entry("pendingReleaseTimeout", "pendingReleaseTimeout: -1s", "pendingReleaseTimeout must not be negative"),
+		Entry("pendingReleaseRecovery", "pendingReleaseRecovery: Retry", `unknown pendingReleaseRecovery "Retry"`),
+		Entry("releaseRetry maxAttempts", "releaseRetry: {maxAttempts: -1}", "invalid releaseRetry: maxAttempts must not be negative"),
+		Entry("releaseRetry backoffFactor", "releaseRetry: {backoffFactor: 0.5}", "invalid releaseRetry: backoffFactor must be at least 1"),
+		Entry("releaseRetry onExhausted", "releaseRetry: {onExhausted: GiveUp}", `invalid releaseRetry: unknown onExhausted "GiveUp"`),
+		Entry("maintenanceWindows without windows", "maintenanceWindows: {windows: []}", "maintenanceWindows must list at least one window"),
+		Entry("rollout without waves", "rollout: {failureThreshold: 0.5}", "invalid rollout: either waveLabel or wavePercentages must be set"),
+		Entry("rollout with both waves", "rollout: {waveLabel: wave, wavePercentages: [50]}", "invalid rollout: waveLabel and wavePercentages are mutually exclusive"),
+		Entry("rollout wavePercentages", "rollout: {wavePercentages: [50, 20]}", "invalid rollout: wavePercentages must be increasing"),
+		Entry("releaseTests without trigger", "releaseTests: {rollbackOnFailure: true}", "invalid releaseTests: either afterRelease or interval must be set"),
+		Entry("policy without rules", "policy: {audit: true}", "invalid policy: at least one rule must be enabled"),
+		Entry("imageRewrite without rewrites", "imageRewrite: {}", "imageRewrite must set mirrors or digestsFile"),
+		Entry("commonMetadata without metadata", "commonMetadata: {podTemplates: true}", "commonMetadata must set labels or annotations"),
+		Entry("storageDriver", "storageDriver: unknown", `unknown storageDriver "unknown"`),
+		Entry("installAnnotations", "installAnnotations: [helm.sdk.operatorframework.io/upgrade-force]", "invalid installAnnotations"),
+		Entry("upgradeAnnotations", "upgradeAnnotations: [unknown]", "invalid upgradeAnnotations"),
+		Entry("uninstallAnnotations", "uninstallAnnotations: [unknown]", "invalid uninstallAnnotations"),
+		Entry("namespaces", "namespaces: [Invalid_Namespace]", `invalid namespace "Invalid_Namespace"`),
+		Entry("namespaceSelector", "namespaceSelector: {matchExpressions: [{key: a, operator: Unknown}]}", "invalid namespaceSelector"),
+		Entry("namespaces and namespaceSelector", "namespaces: [a]\n  namespaceSelector: {matchLabels: {a: b}}", "mutually exclusive"),
+	)
+
+	It("should report every invalid reconciler option of a watch", func() {
+		data = `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+  maxConcurrentReconciles: 0
+  reconcilePeriod: -1s
+`
+		watchesData := bytes.NewBufferString(data)
+		watches, err := LoadReader(watchesData)
+		Expect(err).To(MatchError(ContainSubstring("maxConcurrentReconciles must be at least 1")))
+		Expect(err).To(MatchError(ContainSubstring("reconcilePeriod must not be negative")))
+		Expect(watches).To(BeNil())
+	})
+
 	It("should create valid watches file with override template expansion", func() {
 		data = `---
 - group: mygroup
@@ -288,6 +448,174 @@ foo: bar
 	})
 })
 
+var _ = Describe("LoadReader with a versioned watches file", func() {
+	It("should create valid watches", func() {
+		data := `---
+apiVersion: helm.sdk.operatorframework.io/v2
+kind: Watches
+watches:
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+  maxReleaseHistory: 5
+`
+		trueVal, maxReleaseHistory := true, 5
+		expectedWatches := []Watch{
+			{
+				GroupVersionKind:        schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"},
+				ChartPath:               "../../pkg/internal/testdata/test-chart",
+				WatchDependentResources: &trueVal,
+				MaxReleaseHistory:       &maxReleaseHistory,
+			},
+		}
+
+		watches, err := LoadReader(bytes.NewBufferString(data))
+		Expect(err).NotTo(HaveOccurred())
+		verifyEqualWatches(expectedWatches, watches)
+	})
+
+	It("should error for unknown fields with their line numbers", func() {
+		data := `---
+apiVersion: helm.sdk.operatorframework.io/v2
+kind: Watches
+extra: true
+watches:
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+  selector:
+    matchLabel:
+      app: foo
+  reconcilePeriodd: 1m
+`
+		watches, err := LoadReader(bytes.NewBufferString(data))
+		Expect(watches).To(BeNil())
+		Expect(err).To(MatchError(ContainSubstring(`line 4: unknown field "extra"`)))
+		Expect(err).To(MatchError(ContainSubstring(`line 11: unknown field "matchLabel" in watches[0].selector`)))
+		Expect(err).To(MatchError(ContainSubstring(`line 13: unknown field "reconcilePeriodd" in watches[0]`)))
+	})
+
+	DescribeTable("should error for an invalid header",
+		func(header, expectedErr string) {
+			data := header + `
+watches:
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+`
+			_, err := LoadReader(bytes.NewBufferString(data))
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("missing apiVersion", "kind: Watches", "apiVersion must be set"),
+		Entry("unsupported apiVersion", "apiVersion: v1\nkind: Watches", `unsupported apiVersion "v1"`),
+		Entry("missing kind", "apiVersion: helm.sdk.operatorframework.io/v2", "kind must be set"),
+		Entry("unsupported kind", "apiVersion: helm.sdk.operatorframework.io/v2\nkind: Other", `unsupported kind "Other"`),
+	)
+
+	It("should report all problems at once", func() {
+		data := `---
+apiVersion: helm.sdk.operatorframework.io/v2
+kind: Watches
+watches:
+- group: mygroup
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+  maxConcurrentReconciles: 0
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+`
+		_, err := LoadReader(bytes.NewBufferString(data))
+		Expect(err).To(MatchError(ContainSubstring("watches[0] (line 5): invalid GVK")))
+		Expect(err).To(MatchError(ContainSubstring("watches[1] (line 8): invalid options")))
+		Expect(err).To(MatchError(ContainSubstring("watches[2] (line 13): duplicate GVK")))
+	})
+
+	It("should ignore unknown fields in a legacy watches file", func() {
+		data := `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+  unknown: value
+`
+		watches, err := LoadReader(bytes.NewBufferString(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(watches).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("Validate", func() {
+	It("should report unknown fields in a legacy watches file", func() {
+		f, err := os.CreateTemp("", "osdk-test-validate")
+		Expect(err).NotTo(HaveOccurred())
+		defer removeFile(f)
+		_, err = f.WriteString(`---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../pkg/internal/testdata/test-chart
+  unknown: value
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		ws, errs := Validate(f.Name())
+		Expect(ws).To(HaveLen(1))
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(MatchError(`line 6: unknown field "unknown" in watches[0]`))
+	})
+
+	It("should report a missing file", func() {
+		_, errs := Validate("does-not-exist.yaml")
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(MatchError(ContainSubstring("could not read watches file")))
+	})
+})
+
+var _ = Describe("JSONSchema", func() {
+	It("should describe every field of a watch", func() {
+		var s struct {
+			Definitions struct {
+				Watch struct {
+					Properties map[string]json.RawMessage `json:"properties"`
+				} `json:"watch"`
+			} `json:"definitions"`
+		}
+		Expect(json.Unmarshal(JSONSchema, &s)).To(Succeed())
+
+		fields := jsonFields(reflect.TypeOf(Watch{}))
+		Expect(s.Definitions.Watch.Properties).To(HaveLen(len(fields)))
+		for name := range s.Definitions.Watch.Properties {
+			_, ok := lookupField(fields, name)
+			Expect(ok).To(BeTrue(), "schema property %q is not a field of Watch", name)
+		}
+	})
+
+	It("should list all storage drivers", func() {
+		var s struct {
+			Definitions struct {
+				Watch struct {
+					Properties struct {
+						StorageDriver struct {
+							Enum []string `json:"enum"`
+						} `json:"storageDriver"`
+					} `json:"properties"`
+				} `json:"watch"`
+			} `json:"definitions"`
+		}
+		Expect(json.Unmarshal(JSONSchema, &s)).To(Succeed())
+		Expect(s.Definitions.Watch.Properties.StorageDriver.Enum).To(ConsistOf(helmclient.StorageDrivers))
+	})
+})
+
 var _ = Describe("Load", func() {
 	var (
 		expectedWatches []Watch
@@ -339,6 +667,26 @@ func verifyEqualWatches(expectedWatch, obtainedWatch []Watch) {
 		Expect(expectedWatch[i].OverrideValues).To(BeEquivalentTo(obtainedWatch[i].OverrideValues))
 		Expect(expectedWatch[i].MaxConcurrentReconciles).To(BeEquivalentTo(obtainedWatch[i].MaxConcurrentReconciles))
 		Expect(expectedWatch[i].ReconcilePeriod).To(BeEquivalentTo(obtainedWatch[i].ReconcilePeriod))
+		Expect(expectedWatch[i].MaxReleaseHistory).To(BeEquivalentTo(obtainedWatch[i].MaxReleaseHistory))
+		Expect(expectedWatch[i].WaitForDeletionTimeout).To(BeEquivalentTo(obtainedWatch[i].WaitForDeletionTimeout))
+		Expect(expectedWatch[i].EnableFailureRollbacks).To(BeEquivalentTo(obtainedWatch[i].EnableFailureRollbacks))
+		Expect(expectedWatch[i].StorageDriver).To(BeEquivalentTo(obtainedWatch[i].StorageDriver))
+		Expect(expectedWatch[i].PendingReleaseTimeout).To(BeEquivalentTo(obtainedWatch[i].PendingReleaseTimeout))
+		Expect(expectedWatch[i].PendingReleaseRecovery).To(BeEquivalentTo(obtainedWatch[i].PendingReleaseRecovery))
+		Expect(expectedWatch[i].ReleaseRetry).To(Equal(obtainedWatch[i].ReleaseRetry))
+		Expect(expectedWatch[i].RequireUpgradeApproval).To(Equal(obtainedWatch[i].RequireUpgradeApproval))
+		Expect(expectedWatch[i].MaintenanceWindows).To(Equal(obtainedWatch[i].MaintenanceWindows))
+		Expect(expectedWatch[i].Rollout).To(Equal(obtainedWatch[i].Rollout))
+		Expect(expectedWatch[i].ReleaseTests).To(Equal(obtainedWatch[i].ReleaseTests))
+		Expect(expectedWatch[i].Policy).To(Equal(obtainedWatch[i].Policy))
+		Expect(expectedWatch[i].ImageRewrite).To(Equal(obtainedWatch[i].ImageRewrite))
+		Expect(expectedWatch[i].CommonMetadata).To(Equal(obtainedWatch[i].CommonMetadata))
+		Expect(expectedWatch[i].EnablePostRendererPatches).To(Equal(obtainedWatch[i].EnablePostRendererPatches))
+		Expect(expectedWatch[i].InstallAnnotations).To(BeEquivalentTo(obtainedWatch[i].InstallAnnotations))
+		Expect(expectedWatch[i].UpgradeAnnotations).To(BeEquivalentTo(obtainedWatch[i].UpgradeAnnotations))
+		Expect(expectedWatch[i].UninstallAnnotations).To(BeEquivalentTo(obtainedWatch[i].UninstallAnnotations))
+		Expect(expectedWatch[i].Namespaces).To(BeEquivalentTo(obtainedWatch[i].Namespaces))
+		Expect(expectedWatch[i].NamespaceSelector).To(BeEquivalentTo(obtainedWatch[i].NamespaceSelector))
 		if expectedWatch[i].Selector == nil {
 			Expect(&metav1.LabelSelector{}).To(BeEquivalentTo(obtainedWatch[i].Selector))
 		} else {